@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/codetheuri/poster-gen/config"
+	postersModule "github.com/codetheuri/poster-gen/internal/app/posters"
+	"github.com/codetheuri/poster-gen/internal/app/posters/handlers/dto"
+	"github.com/codetheuri/poster-gen/internal/platform/database"
+	"github.com/codetheuri/poster-gen/pkg/logger"
+	"github.com/codetheuri/poster-gen/pkg/validators"
+)
+
+// posterctl runs maintenance tasks against the posters module using the same
+// configuration, database and storage as the API.
+func main() {
+	rekeyCmd := flag.NewFlagSet("rekey-files", flag.ExitOnError)
+	rekeyDryRun := rekeyCmd.Bool("dry-run", false, "List the files that would be moved without changing anything")
+
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(1)
+	}
+
+	log := logger.NewConsoleLogger()
+	logger.SetGlobalLogger(log)
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatal("Failed to load configuration", err)
+	}
+
+	ctx := context.Background()
+	command := os.Args[1]
+	switch command {
+	case "rekey-files":
+		rekeyCmd.Parse(os.Args[2:])
+		runtime := newRuntime(cfg, log)
+		defer runtime.Close(ctx)
+
+		moved, err := runtime.Services.PosterSvc.RekeyLegacyFiles(ctx, *rekeyDryRun)
+		if err != nil {
+			log.Fatal("Rekeying poster files failed", err)
+		}
+		if *rekeyDryRun {
+			log.Info(fmt.Sprintf("%d poster file(s) would be moved to random keys", moved))
+		} else {
+			log.Info(fmt.Sprintf("%d poster file(s) moved to random keys", moved))
+		}
+	case "regenerate-thumbnails":
+		runtime := newRuntime(cfg, log)
+		defer runtime.Close(ctx)
+
+		generated, err := runtime.Services.ThumbnailSvc.RegenerateAllThumbnails(ctx)
+		if err != nil {
+			log.Fatal("Regenerating template thumbnails failed", err)
+		}
+		log.Info(fmt.Sprintf("%d template thumbnail(s) regenerated", generated))
+	case "lint-layouts":
+		runtime := newRuntime(cfg, log)
+		reports, err := runtime.Services.LayoutSvc.LintLayouts(ctx)
+		if err != nil {
+			runtime.Close(ctx)
+			log.Fatal("Linting layouts failed", err)
+		}
+		failed := printLintReports(reports)
+		runtime.Close(ctx)
+		if failed > 0 {
+			fmt.Printf("%d layout check(s) failed\n", failed)
+			os.Exit(1)
+		}
+		fmt.Printf("%d layout check(s) passed\n", len(reports))
+	case "help":
+		printUsage()
+	default:
+		fmt.Printf("Unknown command: %s\n", command)
+		printUsage()
+		os.Exit(1)
+	}
+}
+
+func newRuntime(cfg *config.Config, log logger.Logger) *postersModule.Runtime {
+	db, err := database.NewGoRMDB(cfg, log)
+	if err != nil {
+		log.Fatal("Failed to connect to database", err)
+	}
+	runtime, err := postersModule.NewRuntime(db, log, validators.NewValidator(), cfg)
+	if err != nil {
+		log.Fatal("Failed to initialize posters module", err)
+	}
+	return runtime
+}
+
+func printUsage() {
+	fmt.Println("Usage: go run ./cmd/posterctl <command> [arguments]")
+	fmt.Println("Commands:")
+	fmt.Println("  rekey-files [-dry-run]   Move files stored under legacy <BusinessName>_<unix> keys to random keys")
+	fmt.Println("  regenerate-thumbnails    Render the thumbnail of every template again from its layout and sample data")
+	fmt.Println("  lint-layouts             Parse every layout in templates/ and every uploaded layout, and check its placeholders against the templates using it")
+	fmt.Println("  help                     Show this help message")
+}
+
+// printLintReports writes one line per issue and returns how many reports had issues.
+func printLintReports(reports []dto.LayoutLintReport) int {
+	failed := 0
+	for _, report := range reports {
+		name := report.FilePath
+		if report.LayoutID != 0 {
+			name = fmt.Sprintf("layout %d %q (uploaded)", report.LayoutID, report.LayoutName)
+		}
+		if report.TemplateID != 0 {
+			name = fmt.Sprintf("%s (template %d)", name, report.TemplateID)
+		}
+		if len(report.Issues) == 0 {
+			fmt.Printf("ok    %s\n", name)
+			continue
+		}
+		failed++
+		fmt.Printf("FAIL  %s\n", name)
+		for _, issue := range report.Issues {
+			location := ""
+			if issue.Location != "" {
+				location = " at " + issue.Location
+			}
+			fmt.Printf("      %-7s %s%s\n", issue.Problem, issue.Message, location)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/codetheuri/poster-gen/config"
+	postersModule "github.com/codetheuri/poster-gen/internal/app/posters"
+	"github.com/codetheuri/poster-gen/internal/platform/database"
+	"github.com/codetheuri/poster-gen/pkg/logger"
+	"github.com/codetheuri/poster-gen/pkg/validators"
+)
+
+// The worker renders posters queued in the render_jobs table (RENDER_QUEUE_DRIVER=database).
+// Run as many copies as needed; jobs are claimed with row locks so each is rendered once.
+func main() {
+	//initialize logger
+	log := logger.NewConsoleLogger()
+	logger.SetGlobalLogger(log)
+
+	// load configs
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatal("Failed to load configuration", err)
+	}
+	if cfg.RenderQueueDriver != "database" {
+		log.Warn("RENDER_QUEUE_DRIVER is not 'database'; the API will not queue jobs for this worker", "driver", cfg.RenderQueueDriver)
+	}
+
+	db, err := database.NewGoRMDB(cfg, log)
+	if err != nil {
+		log.Fatal("Failed to connect to database", err)
+	}
+
+	// Stop claiming new jobs on SIGINT/SIGTERM; renders already running are allowed to finish.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	worker, err := postersModule.NewWorker(db, log, validators.NewValidator(), cfg)
+	if err != nil {
+		log.Fatal("Failed to initialize render worker", err)
+	}
+	log.Info("Starting render worker...")
+	worker.Run(ctx)
+	log.Info("Render worker shut down gracefully.")
+}
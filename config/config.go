@@ -45,7 +45,54 @@ type Config struct {
 	MailerUsername     string
 	MailerPassword string
 	MailerSender   string
-	
+
+	//browser pool config
+	BrowserPoolSize            int
+	BrowserTabMaxUses          int
+	BrowserHealthCheckInterval time.Duration
+	ChromePath                 string
+
+	//renderer config
+	RendererBackend string
+	WkhtmltopdfPath string
+	RenderTimeout   time.Duration
+	// URLs a layout may load while rendering: schemes like "data:" or URL prefixes; "*" disables isolation
+	RenderNetworkAllowlist []string
+
+	//background render workers (async poster generation)
+	RenderWorkers   int
+	RenderQueueSize int
+
+	//durable render queue (RENDER_QUEUE_DRIVER=database, processed by cmd/worker)
+	RenderQueueDriver        string
+	RenderJobMaxAttempts     int
+	RenderJobBackoff         time.Duration
+	RenderJobMaxBackoff      time.Duration
+	RenderJobVisibility      time.Duration
+	RenderWorkerPollInterval time.Duration
+
+	//render cache (reuse files for identical posters)
+	RenderCacheTTL        time.Duration
+	RenderCacheMaxEntries int
+
+	//storage for generated files
+	StorageDriver    string
+	StorageLocalRoot string
+	S3Endpoint       string
+	S3Region         string
+	S3Bucket         string
+	S3AccessKey      string
+	S3SecretKey      string
+	S3UseSSL         bool
+
+	//signed download links for generated posters
+	DownloadSigningKeys      string
+	DownloadSigningActiveKey string
+	DownloadURLTTL           time.Duration
+	DownloadBaseURL          string
+
+	//short links in tracked QR codes
+	ScanLinkBaseURL string
 }
 
 func LoadConfig() (*Config, error) {
@@ -76,8 +123,51 @@ func LoadConfig() (*Config, error) {
 		MailerPassword: os.Getenv("MAIL_PASSWORD"),
 		MailerSender:   os.Getenv("MAIL_SENDER"),
 
-		
+		// Browser pool configuration
+		BrowserPoolSize:            4,
+		BrowserTabMaxUses:          50,
+		BrowserHealthCheckInterval: 30 * time.Second,
+		ChromePath:                 os.Getenv("CHROME_PATH"),
 
+		// Renderer configuration
+		RendererBackend: os.Getenv("RENDERER_BACKEND"),
+		WkhtmltopdfPath: os.Getenv("WKHTMLTOPDF_BIN"),
+		RenderTimeout:   20 * time.Second,
+
+		// Background render workers
+		RenderWorkers:   2,
+		RenderQueueSize: 100,
+
+		// Durable render queue
+		RenderQueueDriver:        os.Getenv("RENDER_QUEUE_DRIVER"),
+		RenderJobMaxAttempts:     5,
+		RenderJobBackoff:         10 * time.Second,
+		RenderJobMaxBackoff:      10 * time.Minute,
+		RenderJobVisibility:      5 * time.Minute,
+		RenderWorkerPollInterval: 2 * time.Second,
+
+		// Render cache
+		RenderCacheTTL:        24 * time.Hour,
+		RenderCacheMaxEntries: 10000,
+
+		// Storage for generated files
+		StorageDriver:    os.Getenv("STORAGE_DRIVER"),
+		StorageLocalRoot: os.Getenv("STORAGE_LOCAL_ROOT"),
+		S3Endpoint:       os.Getenv("S3_ENDPOINT"),
+		S3Region:         os.Getenv("S3_REGION"),
+		S3Bucket:         os.Getenv("S3_BUCKET"),
+		S3AccessKey:      os.Getenv("S3_ACCESS_KEY"),
+		S3SecretKey:      os.Getenv("S3_SECRET_KEY"),
+		S3UseSSL:         true,
+
+		// Signed download links
+		DownloadSigningKeys:      os.Getenv("DOWNLOAD_SIGNING_KEYS"),
+		DownloadSigningActiveKey: os.Getenv("DOWNLOAD_SIGNING_ACTIVE_KEY"),
+		DownloadURLTTL:           15 * time.Minute,
+		DownloadBaseURL:          os.Getenv("DOWNLOAD_BASE_URL"),
+
+		// Scan tracking
+		ScanLinkBaseURL: os.Getenv("SCAN_LINK_BASE_URL"),
 	}
 	JWTSecret :=   os.Getenv("JWT_SECRET")
 	if JWTSecret == "" {
@@ -154,6 +244,152 @@ func LoadConfig() (*Config, error) {
 		}
 	}
 
+	if val := os.Getenv("BROWSER_POOL_SIZE"); val != "" {
+		i, err := strconv.Atoi(val)
+		if err != nil || i <= 0 {
+			return nil, errors.ConfigError(fmt.Sprintf("Invalid BROWSER_POOL_SIZE value: %s", val), err)
+		}
+		cfg.BrowserPoolSize = i
+	}
+	if val := os.Getenv("BROWSER_TAB_MAX_USES"); val != "" {
+		i, err := strconv.Atoi(val)
+		if err != nil || i < 0 {
+			return nil, errors.ConfigError(fmt.Sprintf("Invalid BROWSER_TAB_MAX_USES value: %s", val), err)
+		}
+		cfg.BrowserTabMaxUses = i
+	}
+	if val := os.Getenv("BROWSER_HEALTH_CHECK_INTERVAL"); val != "" {
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return nil, errors.ConfigError(fmt.Sprintf("Invalid BROWSER_HEALTH_CHECK_INTERVAL value: %s", val), err)
+		}
+		cfg.BrowserHealthCheckInterval = d
+	}
+
+	switch cfg.RendererBackend {
+	case "":
+		cfg.RendererBackend = "chromedp"
+	case "chromedp", "wkhtmltopdf":
+	default:
+		return nil, errors.ConfigError(fmt.Sprintf("Unsupported RENDERER_BACKEND: %s", cfg.RendererBackend), nil)
+	}
+
+	if val := os.Getenv("RENDER_TIMEOUT"); val != "" {
+		d, err := time.ParseDuration(val)
+		if err != nil || d <= 0 {
+			return nil, errors.ConfigError(fmt.Sprintf("Invalid RENDER_TIMEOUT value: %s", val), err)
+		}
+		cfg.RenderTimeout = d
+	}
+	if val := os.Getenv("RENDER_WORKERS"); val != "" {
+		i, err := strconv.Atoi(val)
+		if err != nil || i <= 0 {
+			return nil, errors.ConfigError(fmt.Sprintf("Invalid RENDER_WORKERS value: %s", val), err)
+		}
+		cfg.RenderWorkers = i
+	}
+	if val := os.Getenv("RENDER_QUEUE_SIZE"); val != "" {
+		i, err := strconv.Atoi(val)
+		if err != nil || i < 0 {
+			return nil, errors.ConfigError(fmt.Sprintf("Invalid RENDER_QUEUE_SIZE value: %s", val), err)
+		}
+		cfg.RenderQueueSize = i
+	}
+
+	switch cfg.RenderQueueDriver {
+	case "":
+		cfg.RenderQueueDriver = "memory"
+	case "memory", "database":
+	default:
+		return nil, errors.ConfigError(fmt.Sprintf("Unsupported RENDER_QUEUE_DRIVER: %s", cfg.RenderQueueDriver), nil)
+	}
+	if val := os.Getenv("RENDER_JOB_MAX_ATTEMPTS"); val != "" {
+		i, err := strconv.Atoi(val)
+		if err != nil || i <= 0 {
+			return nil, errors.ConfigError(fmt.Sprintf("Invalid RENDER_JOB_MAX_ATTEMPTS value: %s", val), err)
+		}
+		cfg.RenderJobMaxAttempts = i
+	}
+	for env, target := range map[string]*time.Duration{
+		"RENDER_JOB_BACKOFF":            &cfg.RenderJobBackoff,
+		"RENDER_JOB_MAX_BACKOFF":        &cfg.RenderJobMaxBackoff,
+		"RENDER_JOB_VISIBILITY_TIMEOUT": &cfg.RenderJobVisibility,
+		"RENDER_WORKER_POLL_INTERVAL":   &cfg.RenderWorkerPollInterval,
+	} {
+		if val := os.Getenv(env); val != "" {
+			d, err := time.ParseDuration(val)
+			if err != nil || d <= 0 {
+				return nil, errors.ConfigError(fmt.Sprintf("Invalid %s value: %s", env, val), err)
+			}
+			*target = d
+		}
+	}
+	if val := os.Getenv("RENDER_CACHE_TTL"); val != "" {
+		d, err := time.ParseDuration(val)
+		if err != nil || d < 0 {
+			return nil, errors.ConfigError(fmt.Sprintf("Invalid RENDER_CACHE_TTL value: %s", val), err)
+		}
+		cfg.RenderCacheTTL = d
+	}
+	if val := os.Getenv("RENDER_CACHE_MAX_ENTRIES"); val != "" {
+		i, err := strconv.Atoi(val)
+		if err != nil || i < 0 {
+			return nil, errors.ConfigError(fmt.Sprintf("Invalid RENDER_CACHE_MAX_ENTRIES value: %s", val), err)
+		}
+		cfg.RenderCacheMaxEntries = i
+	}
+	switch cfg.StorageDriver {
+	case "":
+		cfg.StorageDriver = "local"
+	case "local":
+	case "s3":
+		if cfg.S3Endpoint == "" || cfg.S3Bucket == "" || cfg.S3AccessKey == "" || cfg.S3SecretKey == "" {
+			return nil, errors.ConfigError("S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY and S3_SECRET_KEY must be set when STORAGE_DRIVER=s3", nil)
+		}
+	default:
+		return nil, errors.ConfigError(fmt.Sprintf("Unsupported STORAGE_DRIVER: %s", cfg.StorageDriver), nil)
+	}
+	if cfg.StorageLocalRoot == "" {
+		cfg.StorageLocalRoot = "./posters"
+	}
+	if val := os.Getenv("RENDER_NETWORK_ALLOWLIST"); val != "" {
+		for _, entry := range strings.Split(val, ",") {
+			if entry = strings.TrimSpace(entry); entry != "" {
+				cfg.RenderNetworkAllowlist = append(cfg.RenderNetworkAllowlist, entry)
+			}
+		}
+	} else {
+		// By default layouts may only use inline data; fonts and logos are inlined too.
+		cfg.RenderNetworkAllowlist = []string{"data:"}
+	}
+	if val := os.Getenv("S3_USE_SSL"); val != "" {
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, errors.ConfigError(fmt.Sprintf("Invalid S3_USE_SSL value: %s", val), err)
+		}
+		cfg.S3UseSSL = b
+	}
+
+	if val := os.Getenv("DOWNLOAD_URL_TTL"); val != "" {
+		d, err := time.ParseDuration(val)
+		if err != nil || d <= 0 {
+			return nil, errors.ConfigError(fmt.Sprintf("Invalid DOWNLOAD_URL_TTL value: %s", val), err)
+		}
+		cfg.DownloadURLTTL = d
+	}
+	if cfg.DownloadBaseURL == "" {
+		cfg.DownloadBaseURL = "/posters"
+	}
+	// The link is printed on paper, so it has to work from any phone.
+	if cfg.ScanLinkBaseURL != "" && !strings.HasPrefix(cfg.ScanLinkBaseURL, "http://") && !strings.HasPrefix(cfg.ScanLinkBaseURL, "https://") {
+		return nil, errors.ConfigError(fmt.Sprintf("SCAN_LINK_BASE_URL must be an absolute http(s) URL, got: %s", cfg.ScanLinkBaseURL), nil)
+	}
+
+	// A job must stay invisible for longer than a render can take, or a second worker picks it up mid-render.
+	if cfg.RenderJobVisibility <= cfg.RenderTimeout {
+		return nil, errors.ConfigError(fmt.Sprintf("RENDER_JOB_VISIBILITY_TIMEOUT (%s) must be longer than RENDER_TIMEOUT (%s)", cfg.RenderJobVisibility, cfg.RenderTimeout), nil)
+	}
+
 	corsOriginStr := os.Getenv("ALLOWED_ORIGINS")
 	if corsOriginStr != "" {
 		cfg.CORSOrigins = strings.Split(corsOriginStr, ",")
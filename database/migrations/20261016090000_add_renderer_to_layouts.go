@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	"log"
+
+	"github.com/codetheuri/poster-gen/internal/app/posters/models"
+	"gorm.io/gorm"
+)
+
+// Addrenderertolayouts struct implements migration interface
+type Addrenderertolayouts struct{}
+
+func (m *Addrenderertolayouts) Version() string {
+	return "20261016090000"
+}
+func (m *Addrenderertolayouts) Name() string {
+	return "add_renderer_to_layouts"
+}
+
+// up migration method
+func (m *Addrenderertolayouts) Up(tx *gorm.DB) error {
+	log.Printf("Running Up migration: %s", m.Name())
+	if !tx.Migrator().HasColumn(&models.Layout{}, "Renderer") {
+		if err := tx.Migrator().AddColumn(&models.Layout{}, "Renderer"); err != nil {
+			return err
+		}
+	}
+	log.Printf("Successfully applied Up migration: %s", m.Name())
+	return nil
+}
+
+// down migration method
+func (m *Addrenderertolayouts) Down(tx *gorm.DB) error {
+	log.Printf("Running Down migration: %s", m.Name())
+	if tx.Migrator().HasColumn(&models.Layout{}, "Renderer") {
+		if err := tx.Migrator().DropColumn(&models.Layout{}, "Renderer"); err != nil {
+			return err
+		}
+	}
+	log.Printf("Successfully applied Down migration: %s", m.Name())
+	return nil
+}
+
+func init() {
+	// Register the migration
+	RegisteredMigrations = append(RegisteredMigrations, &Addrenderertolayouts{})
+}
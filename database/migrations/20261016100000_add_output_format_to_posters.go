@@ -0,0 +1,48 @@
+package migrations
+
+import (
+	"log"
+
+	"github.com/codetheuri/poster-gen/internal/app/posters/models"
+	"gorm.io/gorm"
+)
+
+// Addoutputformattoposters struct implements migration interface
+type Addoutputformattoposters struct{}
+
+func (m *Addoutputformattoposters) Version() string {
+	return "20261016100000"
+}
+func (m *Addoutputformattoposters) Name() string {
+	return "add_output_format_to_posters"
+}
+
+// up migration method
+func (m *Addoutputformattoposters) Up(tx *gorm.DB) error {
+	log.Printf("Running Up migration: %s", m.Name())
+	// Existing rows were all rendered as PDF, which the column default covers.
+	if !tx.Migrator().HasColumn(&models.Poster{}, "OutputFormat") {
+		if err := tx.Migrator().AddColumn(&models.Poster{}, "OutputFormat"); err != nil {
+			return err
+		}
+	}
+	log.Printf("Successfully applied Up migration: %s", m.Name())
+	return nil
+}
+
+// down migration method
+func (m *Addoutputformattoposters) Down(tx *gorm.DB) error {
+	log.Printf("Running Down migration: %s", m.Name())
+	if tx.Migrator().HasColumn(&models.Poster{}, "OutputFormat") {
+		if err := tx.Migrator().DropColumn(&models.Poster{}, "OutputFormat"); err != nil {
+			return err
+		}
+	}
+	log.Printf("Successfully applied Down migration: %s", m.Name())
+	return nil
+}
+
+func init() {
+	// Register the migration
+	RegisteredMigrations = append(RegisteredMigrations, &Addoutputformattoposters{})
+}
@@ -0,0 +1,52 @@
+package migrations
+
+import (
+	"log"
+
+	"github.com/codetheuri/poster-gen/internal/app/posters/models"
+	"gorm.io/gorm"
+)
+
+// Addasyncfieldstoposters struct implements migration interface
+type Addasyncfieldstoposters struct{}
+
+func (m *Addasyncfieldstoposters) Version() string {
+	return "20261016110000"
+}
+func (m *Addasyncfieldstoposters) Name() string {
+	return "add_async_fields_to_posters"
+}
+
+// up migration method
+func (m *Addasyncfieldstoposters) Up(tx *gorm.DB) error {
+	log.Printf("Running Up migration: %s", m.Name())
+	// Existing posters were rendered synchronously, so they keep their status and need no payload.
+	for _, field := range []string{"RequestPayload", "ErrorReason"} {
+		if !tx.Migrator().HasColumn(&models.Poster{}, field) {
+			if err := tx.Migrator().AddColumn(&models.Poster{}, field); err != nil {
+				return err
+			}
+		}
+	}
+	log.Printf("Successfully applied Up migration: %s", m.Name())
+	return nil
+}
+
+// down migration method
+func (m *Addasyncfieldstoposters) Down(tx *gorm.DB) error {
+	log.Printf("Running Down migration: %s", m.Name())
+	for _, field := range []string{"RequestPayload", "ErrorReason"} {
+		if tx.Migrator().HasColumn(&models.Poster{}, field) {
+			if err := tx.Migrator().DropColumn(&models.Poster{}, field); err != nil {
+				return err
+			}
+		}
+	}
+	log.Printf("Successfully applied Down migration: %s", m.Name())
+	return nil
+}
+
+func init() {
+	// Register the migration
+	RegisteredMigrations = append(RegisteredMigrations, &Addasyncfieldstoposters{})
+}
@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"log"
+
+	"github.com/codetheuri/poster-gen/internal/app/posters/models"
+	"gorm.io/gorm"
+)
+
+// Createrenderjobstable struct implements migration interface
+type Createrenderjobstable struct{}
+
+func (m *Createrenderjobstable) Version() string {
+	return "20261016120000"
+}
+func (m *Createrenderjobstable) Name() string {
+	return "create_render_jobs_table"
+}
+
+// up migration method
+func (m *Createrenderjobstable) Up(tx *gorm.DB) error {
+	log.Printf("Running Up migration: %s", m.Name())
+	if err := tx.AutoMigrate(&models.RenderJob{}); err != nil {
+		return err
+	}
+	log.Printf("Successfully applied Up migration: %s", m.Name())
+	return nil
+}
+
+// down migration method
+func (m *Createrenderjobstable) Down(tx *gorm.DB) error {
+	log.Printf("Running Down migration: %s", m.Name())
+	if err := tx.Migrator().DropTable(&models.RenderJob{}); err != nil {
+		return err
+	}
+	log.Printf("Successfully applied Down migration: %s", m.Name())
+	return nil
+}
+
+func init() {
+	// Register the migration
+	RegisteredMigrations = append(RegisteredMigrations, &Createrenderjobstable{})
+}
@@ -0,0 +1,65 @@
+package migrations
+
+import (
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Convertposterpathstostoragekeys struct implements migration interface
+type Convertposterpathstostoragekeys struct{}
+
+func (m *Convertposterpathstostoragekeys) Version() string {
+	return "20261016130000"
+}
+func (m *Convertposterpathstostoragekeys) Name() string {
+	return "convert_poster_paths_to_storage_keys"
+}
+
+// legacyPosterDir is the directory prefix that pdf_url carried before files moved behind the storage layer.
+const legacyPosterDir = "posters/"
+
+type posterPathRow struct {
+	ID     uint
+	PDFURL string `gorm:"column:pdf_url"`
+}
+
+// up migration method
+func (m *Convertposterpathstostoragekeys) Up(tx *gorm.DB) error {
+	log.Printf("Running Up migration: %s", m.Name())
+	// Files written by the local driver keep their names, so "posters/x.pdf" becomes key "x.pdf".
+	var rows []posterPathRow
+	if err := tx.Table("posters").Select("id, pdf_url").Where("pdf_url LIKE ?", legacyPosterDir+"%").Find(&rows).Error; err != nil {
+		return err
+	}
+	for _, row := range rows {
+		key := strings.TrimPrefix(row.PDFURL, legacyPosterDir)
+		if err := tx.Table("posters").Where("id = ?", row.ID).Update("pdf_url", key).Error; err != nil {
+			return err
+		}
+	}
+	log.Printf("Successfully applied Up migration: %s (%d posters updated)", m.Name(), len(rows))
+	return nil
+}
+
+// down migration method
+func (m *Convertposterpathstostoragekeys) Down(tx *gorm.DB) error {
+	log.Printf("Running Down migration: %s", m.Name())
+	var rows []posterPathRow
+	if err := tx.Table("posters").Select("id, pdf_url").Where("pdf_url <> '' AND pdf_url NOT LIKE ?", legacyPosterDir+"%").Find(&rows).Error; err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := tx.Table("posters").Where("id = ?", row.ID).Update("pdf_url", legacyPosterDir+row.PDFURL).Error; err != nil {
+			return err
+		}
+	}
+	log.Printf("Successfully applied Down migration: %s", m.Name())
+	return nil
+}
+
+func init() {
+	// Register the migration
+	RegisteredMigrations = append(RegisteredMigrations, &Convertposterpathstostoragekeys{})
+}
@@ -0,0 +1,53 @@
+package migrations
+
+import (
+	"log"
+
+	"github.com/codetheuri/poster-gen/internal/app/posters/models"
+	"gorm.io/gorm"
+)
+
+// Createrendercacheentriestable struct implements migration interface
+type Createrendercacheentriestable struct{}
+
+func (m *Createrendercacheentriestable) Version() string {
+	return "20261016140000"
+}
+func (m *Createrendercacheentriestable) Name() string {
+	return "create_render_cache_entries_table"
+}
+
+// up migration method
+func (m *Createrendercacheentriestable) Up(tx *gorm.DB) error {
+	log.Printf("Running Up migration: %s", m.Name())
+	if err := tx.AutoMigrate(&models.RenderCacheEntry{}); err != nil {
+		return err
+	}
+	if !tx.Migrator().HasColumn(&models.Poster{}, "RenderCacheHit") {
+		if err := tx.Migrator().AddColumn(&models.Poster{}, "RenderCacheHit"); err != nil {
+			return err
+		}
+	}
+	log.Printf("Successfully applied Up migration: %s", m.Name())
+	return nil
+}
+
+// down migration method
+func (m *Createrendercacheentriestable) Down(tx *gorm.DB) error {
+	log.Printf("Running Down migration: %s", m.Name())
+	if tx.Migrator().HasColumn(&models.Poster{}, "RenderCacheHit") {
+		if err := tx.Migrator().DropColumn(&models.Poster{}, "RenderCacheHit"); err != nil {
+			return err
+		}
+	}
+	if err := tx.Migrator().DropTable(&models.RenderCacheEntry{}); err != nil {
+		return err
+	}
+	log.Printf("Successfully applied Down migration: %s", m.Name())
+	return nil
+}
+
+func init() {
+	// Register the migration
+	RegisteredMigrations = append(RegisteredMigrations, &Createrendercacheentriestable{})
+}
@@ -0,0 +1,51 @@
+package migrations
+
+import (
+	"log"
+
+	"github.com/codetheuri/poster-gen/internal/app/posters/models"
+	"gorm.io/gorm"
+)
+
+// Addthumbnailfieldstopostertemplates struct implements migration interface
+type Addthumbnailfieldstopostertemplates struct{}
+
+func (m *Addthumbnailfieldstopostertemplates) Version() string {
+	return "20261016150000"
+}
+func (m *Addthumbnailfieldstopostertemplates) Name() string {
+	return "add_thumbnail_fields_to_poster_templates"
+}
+
+// up migration method
+func (m *Addthumbnailfieldstopostertemplates) Up(tx *gorm.DB) error {
+	log.Printf("Running Up migration: %s", m.Name())
+	for _, field := range []string{"ThumbnailKey", "SampleData"} {
+		if !tx.Migrator().HasColumn(&models.PosterTemplate{}, field) {
+			if err := tx.Migrator().AddColumn(&models.PosterTemplate{}, field); err != nil {
+				return err
+			}
+		}
+	}
+	log.Printf("Successfully applied Up migration: %s", m.Name())
+	return nil
+}
+
+// down migration method
+func (m *Addthumbnailfieldstopostertemplates) Down(tx *gorm.DB) error {
+	log.Printf("Running Down migration: %s", m.Name())
+	for _, field := range []string{"ThumbnailKey", "SampleData"} {
+		if tx.Migrator().HasColumn(&models.PosterTemplate{}, field) {
+			if err := tx.Migrator().DropColumn(&models.PosterTemplate{}, field); err != nil {
+				return err
+			}
+		}
+	}
+	log.Printf("Successfully applied Down migration: %s", m.Name())
+	return nil
+}
+
+func init() {
+	// Register the migration
+	RegisteredMigrations = append(RegisteredMigrations, &Addthumbnailfieldstopostertemplates{})
+}
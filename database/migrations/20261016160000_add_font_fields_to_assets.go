@@ -0,0 +1,51 @@
+package migrations
+
+import (
+	"log"
+
+	"github.com/codetheuri/poster-gen/internal/app/posters/models"
+	"gorm.io/gorm"
+)
+
+// Addfontfieldstoassets struct implements migration interface
+type Addfontfieldstoassets struct{}
+
+func (m *Addfontfieldstoassets) Version() string {
+	return "20261016160000"
+}
+func (m *Addfontfieldstoassets) Name() string {
+	return "add_font_fields_to_assets"
+}
+
+// up migration method
+func (m *Addfontfieldstoassets) Up(tx *gorm.DB) error {
+	log.Printf("Running Up migration: %s", m.Name())
+	for _, field := range []string{"FontFamily", "FontWeight", "FontStyle", "FontFormat", "StorageKey"} {
+		if !tx.Migrator().HasColumn(&models.Asset{}, field) {
+			if err := tx.Migrator().AddColumn(&models.Asset{}, field); err != nil {
+				return err
+			}
+		}
+	}
+	log.Printf("Successfully applied Up migration: %s", m.Name())
+	return nil
+}
+
+// down migration method
+func (m *Addfontfieldstoassets) Down(tx *gorm.DB) error {
+	log.Printf("Running Down migration: %s", m.Name())
+	for _, field := range []string{"FontFamily", "FontWeight", "FontStyle", "FontFormat", "StorageKey"} {
+		if tx.Migrator().HasColumn(&models.Asset{}, field) {
+			if err := tx.Migrator().DropColumn(&models.Asset{}, field); err != nil {
+				return err
+			}
+		}
+	}
+	log.Printf("Successfully applied Down migration: %s", m.Name())
+	return nil
+}
+
+func init() {
+	// Register the migration
+	RegisteredMigrations = append(RegisteredMigrations, &Addfontfieldstoassets{})
+}
@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"log"
+
+	"github.com/codetheuri/poster-gen/internal/app/posters/models"
+	"gorm.io/gorm"
+)
+
+// Createshortlinkstables struct implements migration interface
+type Createshortlinkstables struct{}
+
+func (m *Createshortlinkstables) Version() string {
+	return "20261016170000"
+}
+func (m *Createshortlinkstables) Name() string {
+	return "create_short_links_tables"
+}
+
+// up migration method
+func (m *Createshortlinkstables) Up(tx *gorm.DB) error {
+	log.Printf("Running Up migration: %s", m.Name())
+	if err := tx.AutoMigrate(&models.ShortLink{}, &models.ScanEvent{}); err != nil {
+		return err
+	}
+	log.Printf("Successfully applied Up migration: %s", m.Name())
+	return nil
+}
+
+// down migration method
+func (m *Createshortlinkstables) Down(tx *gorm.DB) error {
+	log.Printf("Running Down migration: %s", m.Name())
+	if err := tx.Migrator().DropTable(&models.ScanEvent{}, &models.ShortLink{}); err != nil {
+		return err
+	}
+	log.Printf("Successfully applied Down migration: %s", m.Name())
+	return nil
+}
+
+func init() {
+	// Register the migration
+	RegisteredMigrations = append(RegisteredMigrations, &Createshortlinkstables{})
+}
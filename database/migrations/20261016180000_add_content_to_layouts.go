@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	"log"
+
+	"github.com/codetheuri/poster-gen/internal/app/posters/models"
+	"gorm.io/gorm"
+)
+
+// Addcontenttolayouts struct implements migration interface
+type Addcontenttolayouts struct{}
+
+func (m *Addcontenttolayouts) Version() string {
+	return "20261016180000"
+}
+func (m *Addcontenttolayouts) Name() string {
+	return "add_content_to_layouts"
+}
+
+// up migration method
+func (m *Addcontenttolayouts) Up(tx *gorm.DB) error {
+	log.Printf("Running Up migration: %s", m.Name())
+	if !tx.Migrator().HasColumn(&models.Layout{}, "Content") {
+		if err := tx.Migrator().AddColumn(&models.Layout{}, "Content"); err != nil {
+			return err
+		}
+	}
+	log.Printf("Successfully applied Up migration: %s", m.Name())
+	return nil
+}
+
+// down migration method
+func (m *Addcontenttolayouts) Down(tx *gorm.DB) error {
+	log.Printf("Running Down migration: %s", m.Name())
+	if tx.Migrator().HasColumn(&models.Layout{}, "Content") {
+		if err := tx.Migrator().DropColumn(&models.Layout{}, "Content"); err != nil {
+			return err
+		}
+	}
+	log.Printf("Successfully applied Down migration: %s", m.Name())
+	return nil
+}
+
+func init() {
+	// Register the migration
+	RegisteredMigrations = append(RegisteredMigrations, &Addcontenttolayouts{})
+}
@@ -0,0 +1,114 @@
+package migrations
+
+import (
+	"log"
+
+	"github.com/codetheuri/poster-gen/internal/app/posters/models"
+	"gorm.io/gorm"
+)
+
+// Createrevisiontables struct implements migration interface
+type Createrevisiontables struct{}
+
+func (m *Createrevisiontables) Version() string {
+	return "20261016190000"
+}
+func (m *Createrevisiontables) Name() string {
+	return "create_revision_tables"
+}
+
+// up migration method
+func (m *Createrevisiontables) Up(tx *gorm.DB) error {
+	log.Printf("Running Up migration: %s", m.Name())
+	if err := tx.AutoMigrate(&models.PosterTemplateRevision{}, &models.LayoutRevision{}); err != nil {
+		return err
+	}
+	for _, column := range []struct {
+		model interface{}
+		field string
+	}{
+		{&models.PosterTemplate{}, "Revision"},
+		{&models.Layout{}, "Revision"},
+		{&models.Poster{}, "TemplateRevision"},
+		{&models.Poster{}, "LayoutRevision"},
+	} {
+		if !tx.Migrator().HasColumn(column.model, column.field) {
+			if err := tx.Migrator().AddColumn(column.model, column.field); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Existing rows become revision 1. Posters generated before this point keep revision 0
+	// and are regenerated from the current template.
+	var layouts []models.Layout
+	if err := tx.Where("revision = 0").Find(&layouts).Error; err != nil {
+		return err
+	}
+	for _, layout := range layouts {
+		revision := models.LayoutRevision{LayoutID: layout.ID, Revision: 1, Name: layout.Name, FilePath: layout.FilePath, Content: layout.Content, Renderer: layout.Renderer}
+		if err := tx.Create(&revision).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Layout{}).Where("id = ?", layout.ID).Update("revision", 1).Error; err != nil {
+			return err
+		}
+	}
+	var templates []models.PosterTemplate
+	if err := tx.Where("revision = 0").Find(&templates).Error; err != nil {
+		return err
+	}
+	for _, t := range templates {
+		revision := models.PosterTemplateRevision{
+			PosterTemplateID:     t.ID,
+			Revision:             1,
+			Name:                 t.Name,
+			Type:                 t.Type,
+			LayoutID:             t.LayoutID,
+			Price:                t.Price,
+			ThumbnailURL:         t.ThumbnailURL,
+			IsActive:             t.IsActive,
+			RequiredFields:       t.RequiredFields,
+			DefaultCustomization: t.DefaultCustomization,
+			SampleData:           t.SampleData,
+		}
+		if err := tx.Create(&revision).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.PosterTemplate{}).Where("id = ?", t.ID).Update("revision", 1).Error; err != nil {
+			return err
+		}
+	}
+	log.Printf("Successfully applied Up migration: %s", m.Name())
+	return nil
+}
+
+// down migration method
+func (m *Createrevisiontables) Down(tx *gorm.DB) error {
+	log.Printf("Running Down migration: %s", m.Name())
+	for _, column := range []struct {
+		model interface{}
+		field string
+	}{
+		{&models.Poster{}, "LayoutRevision"},
+		{&models.Poster{}, "TemplateRevision"},
+		{&models.Layout{}, "Revision"},
+		{&models.PosterTemplate{}, "Revision"},
+	} {
+		if tx.Migrator().HasColumn(column.model, column.field) {
+			if err := tx.Migrator().DropColumn(column.model, column.field); err != nil {
+				return err
+			}
+		}
+	}
+	if err := tx.Migrator().DropTable(&models.LayoutRevision{}, &models.PosterTemplateRevision{}); err != nil {
+		return err
+	}
+	log.Printf("Successfully applied Down migration: %s", m.Name())
+	return nil
+}
+
+func init() {
+	// Register the migration
+	RegisteredMigrations = append(RegisteredMigrations, &Createrevisiontables{})
+}
@@ -0,0 +1,154 @@
+package migrations
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/codetheuri/poster-gen/internal/app/posters/models"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Addcustomizationoptions struct implements migration interface
+type Addcustomizationoptions struct{}
+
+func (m *Addcustomizationoptions) Version() string {
+	return "20261016200000"
+}
+func (m *Addcustomizationoptions) Name() string {
+	return "add_customization_options"
+}
+
+var (
+	hexColour      = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+	cssSize        = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)(px|pt|mm|em|rem)$`)
+	fontFamilyName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 _-]*$`)
+)
+
+// maxBackfilledSize caps the size options made for existing size defaults, per unit: far beyond
+// any sensible poster text, but no longer unbounded.
+var maxBackfilledSize = map[string]float64{"px": 400, "pt": 300, "mm": 150, "em": 25, "rem": 25}
+
+// up migration method
+func (m *Addcustomizationoptions) Up(tx *gorm.DB) error {
+	log.Printf("Running Up migration: %s", m.Name())
+	for _, model := range []interface{}{&models.PosterTemplate{}, &models.PosterTemplateRevision{}} {
+		if !tx.Migrator().HasColumn(model, "CustomizationOptions") {
+			if err := tx.Migrator().AddColumn(model, "CustomizationOptions"); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Templates only accept overrides of declared keys from now on. Keep the overrides existing
+	// clients rely on working by declaring an option for every default customization key whose
+	// value says what it is: colours, asset IDs, font families and CSS sizes. The current
+	// revision gets the same options, so rolling back to it keeps them.
+	var templates []models.PosterTemplate
+	if err := tx.Find(&templates).Error; err != nil {
+		return err
+	}
+	var uploadedFonts []string
+	if err := tx.Model(&models.Asset{}).Where("type = ? AND font_family <> ''", "font").Distinct().Order("font_family").Pluck("font_family", &uploadedFonts).Error; err != nil {
+		return err
+	}
+	for _, t := range templates {
+		if len(t.CustomizationOptions) > 0 && string(t.CustomizationOptions) != "null" {
+			continue
+		}
+		var defaults map[string]interface{}
+		if err := json.Unmarshal(t.DefaultCustomization, &defaults); err != nil {
+			log.Printf("Skipping template %d: default customization is not an object", t.ID)
+			continue
+		}
+		keys := make([]string, 0, len(defaults))
+		for key := range defaults {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		options := []map[string]interface{}{}
+		for _, key := range keys {
+			option := backfilledOption(key, defaults[key], uploadedFonts)
+			if option == nil {
+				log.Printf("Template %d: no option fits customization key %q, overrides of it are no longer accepted", t.ID, key)
+				continue
+			}
+			options = append(options, option)
+		}
+		if len(options) == 0 {
+			continue
+		}
+		raw, err := json.Marshal(options)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&models.PosterTemplate{}).Where("id = ?", t.ID).Update("customization_options", datatypes.JSON(raw)).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.PosterTemplateRevision{}).Where("poster_template_id = ? AND revision = ?", t.ID, t.Revision).Update("customization_options", datatypes.JSON(raw)).Error; err != nil {
+			return err
+		}
+	}
+	log.Printf("Successfully applied Up migration: %s", m.Name())
+	return nil
+}
+
+// backfilledOption returns the customization option that accepts the current default of key and
+// the overrides clients could send for it, or nil when no option type fits.
+func backfilledOption(key string, value interface{}, uploadedFonts []string) map[string]interface{} {
+	s, isString := value.(string)
+	s = strings.TrimSpace(s)
+	switch {
+	case key == "header_logo_asset_id":
+		return map[string]interface{}{"key": key, "label": "Logo", "type": "asset", "asset_type": "logo"}
+	case strings.HasSuffix(key, "_asset_id"):
+		return map[string]interface{}{"key": key, "type": "asset"}
+	case isString && hexColour.MatchString(s):
+		return map[string]interface{}{"key": key, "type": "color"}
+	case isString && cssSize.MatchString(s):
+		match := cssSize.FindStringSubmatch(s)
+		size, _ := strconv.ParseFloat(match[1], 64)
+		unit := match[2]
+		return map[string]interface{}{"key": key, "type": "size", "min": 0, "max": math.Max(size, maxBackfilledSize[unit]), "unit": unit}
+	case isString && (key == "font_family_name" || strings.Contains(key, "font")):
+		// The default first, then every uploaded font a layout can inline.
+		fonts := []string{}
+		if fontFamilyName.MatchString(s) {
+			fonts = append(fonts, s)
+		}
+		for _, font := range uploadedFonts {
+			if font != s && fontFamilyName.MatchString(font) {
+				fonts = append(fonts, font)
+			}
+		}
+		if len(fonts) == 0 || (s != "" && fonts[0] != s) {
+			return nil
+		}
+		return map[string]interface{}{"key": key, "type": "font", "fonts": fonts}
+	}
+	return nil
+}
+
+// down migration method
+func (m *Addcustomizationoptions) Down(tx *gorm.DB) error {
+	log.Printf("Running Down migration: %s", m.Name())
+	for _, model := range []interface{}{&models.PosterTemplateRevision{}, &models.PosterTemplate{}} {
+		if tx.Migrator().HasColumn(model, "CustomizationOptions") {
+			if err := tx.Migrator().DropColumn(model, "CustomizationOptions"); err != nil {
+				return err
+			}
+		}
+	}
+	log.Printf("Successfully applied Down migration: %s", m.Name())
+	return nil
+}
+
+func init() {
+	// Register the migration
+	RegisteredMigrations = append(RegisteredMigrations, &Addcustomizationoptions{})
+}
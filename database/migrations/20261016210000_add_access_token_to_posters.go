@@ -0,0 +1,49 @@
+package migrations
+
+import (
+	"log"
+
+	"github.com/codetheuri/poster-gen/internal/app/posters/models"
+	"gorm.io/gorm"
+)
+
+// Addaccesstokentoposters struct implements migration interface
+type Addaccesstokentoposters struct{}
+
+func (m *Addaccesstokentoposters) Version() string {
+	return "20261016210000"
+}
+func (m *Addaccesstokentoposters) Name() string {
+	return "add_access_token_to_posters"
+}
+
+// up migration method
+func (m *Addaccesstokentoposters) Up(tx *gorm.DB) error {
+	log.Printf("Running Up migration: %s", m.Name())
+	if !tx.Migrator().HasColumn(&models.Poster{}, "AccessToken") {
+		if err := tx.Migrator().AddColumn(&models.Poster{}, "AccessToken"); err != nil {
+			return err
+		}
+	}
+	// Existing posters are left without a token: no client has one for them, so they stay
+	// readable without it. Only posters created from now on need their token.
+	log.Printf("Successfully applied Up migration: %s", m.Name())
+	return nil
+}
+
+// down migration method
+func (m *Addaccesstokentoposters) Down(tx *gorm.DB) error {
+	log.Printf("Running Down migration: %s", m.Name())
+	if tx.Migrator().HasColumn(&models.Poster{}, "AccessToken") {
+		if err := tx.Migrator().DropColumn(&models.Poster{}, "AccessToken"); err != nil {
+			return err
+		}
+	}
+	log.Printf("Successfully applied Down migration: %s", m.Name())
+	return nil
+}
+
+func init() {
+	// Register the migration
+	RegisteredMigrations = append(RegisteredMigrations, &Addaccesstokentoposters{})
+}
@@ -0,0 +1,58 @@
+package migrations
+
+import (
+	"log"
+
+	"github.com/codetheuri/poster-gen/internal/app/posters/models"
+	"gorm.io/gorm"
+)
+
+// Addkindtorenderjobs struct implements migration interface
+type Addkindtorenderjobs struct{}
+
+func (m *Addkindtorenderjobs) Version() string {
+	return "20261016220000"
+}
+func (m *Addkindtorenderjobs) Name() string {
+	return "add_kind_to_render_jobs"
+}
+
+// up migration method
+func (m *Addkindtorenderjobs) Up(tx *gorm.DB) error {
+	log.Printf("Running Up migration: %s", m.Name())
+	// Existing jobs all render posters, which the column default covers.
+	for _, column := range []string{"Kind", "TemplateID"} {
+		if !tx.Migrator().HasColumn(&models.RenderJob{}, column) {
+			if err := tx.Migrator().AddColumn(&models.RenderJob{}, column); err != nil {
+				return err
+			}
+		}
+	}
+	log.Printf("Successfully applied Up migration: %s", m.Name())
+	return nil
+}
+
+// down migration method
+func (m *Addkindtorenderjobs) Down(tx *gorm.DB) error {
+	log.Printf("Running Down migration: %s", m.Name())
+	// Without the kind, thumbnail jobs would be taken for renders of poster 0.
+	if tx.Migrator().HasColumn(&models.RenderJob{}, "Kind") {
+		if err := tx.Unscoped().Where("kind = ?", models.RenderJobThumbnail).Delete(&models.RenderJob{}).Error; err != nil {
+			return err
+		}
+	}
+	for _, column := range []string{"TemplateID", "Kind"} {
+		if tx.Migrator().HasColumn(&models.RenderJob{}, column) {
+			if err := tx.Migrator().DropColumn(&models.RenderJob{}, column); err != nil {
+				return err
+			}
+		}
+	}
+	log.Printf("Successfully applied Down migration: %s", m.Name())
+	return nil
+}
+
+func init() {
+	// Register the migration
+	RegisteredMigrations = append(RegisteredMigrations, &Addkindtorenderjobs{})
+}
@@ -1,9 +1,19 @@
 package app
 
-import "github.com/codetheuri/poster-gen/internal/app/routers"
+import (
+	"context"
+
+	"github.com/codetheuri/poster-gen/internal/app/routers"
+)
 
 // Module defines the contract that all application modules must follow.
 type Module interface {
 	// RegisterRoutes now requires our generic, framework-agnostic router.
 	RegisterRoutes(r router.Router)
 }
+
+// Shutdowner is implemented by modules that hold resources (workers, browsers, ...)
+// which must be released when the server stops.
+type Shutdowner interface {
+	Shutdown(ctx context.Context) error
+}
@@ -7,6 +7,27 @@ type PosterInput struct {
 	BusinessName      string                 `json:"business_name" validate:"required"`
 	Data              map[string]interface{} `json:"data" validate:"required"`           
 	CustomizationData map[string]interface{} `json:"customization_data" validate:"omitempty"` 
+
+	// Output options: PDF by default, or a PNG/JPEG/WebP image at the given DPI or pixel size.
+	OutputFormat string `json:"output_format" validate:"omitempty,oneof=pdf png jpeg webp"`
+	DPI          int    `json:"dpi" validate:"omitempty,min=72,max=600"`
+	Width        int    `json:"width" validate:"omitempty,min=1,max=10000"`
+	Height       int    `json:"height" validate:"omitempty,min=1,max=10000"`
+
+	// Async queues the render and returns immediately; poll GET /posters/{id}?token=<access_token> for the result.
+	Async bool `json:"async"`
+
+	// BypassCache forces a fresh render instead of reusing a cached file. Only honoured
+	// on the admin route; the public endpoint always clears it.
+	BypassCache bool `json:"bypass_cache,omitempty"`
+}
+
+// RegenerateInput renders a stored poster again. By default the template and layout revisions of
+// the original poster are used; TemplateRevision picks another template revision and Latest the current template.
+type RegenerateInput struct {
+	TemplateRevision int  `json:"template_revision" validate:"omitempty,min=1"`
+	Latest           bool `json:"latest"`
+	Async            bool `json:"async"`
 }
 
 // TemplateInput is the DTO for creating/updating a template.
@@ -19,6 +40,8 @@ type TemplateInput struct {
 	IsActive             bool            `json:"is_active" validate:"omitempty"`
 	RequiredFields       json.RawMessage `json:"required_fields" validate:"required"`       
 	DefaultCustomization json.RawMessage `json:"default_customization" validate:"required"` 
+	SampleData           json.RawMessage `json:"sample_data" validate:"omitempty"` // example field values for the generated thumbnail
+	CustomizationOptions json.RawMessage `json:"customization_options" validate:"omitempty"` // customization keys users may override; none when left out
 }
 
 type AssetInput struct {
@@ -28,8 +51,19 @@ type AssetInput struct {
 	DefaultColor string `json:"default_color" validate:"omitempty,hexcolor|rgb|rgba"`
 }
 
+// FontInput describes an uploaded font file; the file itself is sent as the multipart "file" field.
+type FontInput struct {
+	Family string `json:"family" validate:"required,max=100"`
+	Weight int    `json:"weight" validate:"omitempty,min=100,max=900"`
+	Style  string `json:"style" validate:"omitempty,oneof=normal italic"`
+}
+
+// LayoutInput carries either uploaded HTML in Content or a FilePath inside the templates directory.
+// Multipart uploads send the HTML as the "file" field instead.
 type LayoutInput struct {
 	Name     string `json:"name" validate:"required,max=50"`
-	FilePath string `json:"file_path" validate:"required,max=255"` 
+	FilePath string `json:"file_path" validate:"omitempty,max=255"`
+	Content  string `json:"content" validate:"omitempty"`
+	Renderer string `json:"renderer" validate:"omitempty,oneof=chromedp wkhtmltopdf"`
 }
 
@@ -1,14 +1,39 @@
 package dto
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
 
 // PosterResponse represents the response structure for a generated poster.
 type PosterResponse struct {
-	ID           uint   `json:"id"`
-	TemplateID   uint   `json:"template_id"` // Corresponds to PosterTemplateID
-	BusinessName string `json:"business_name"`
-	PDFURL       string `json:"pdf_url"`
-	Status       string `json:"status"`
+	ID               uint       `json:"id"`
+	AccessToken      string     `json:"access_token"` // pass as ?token= to GET /posters/{id} and /posters/{id}/scans
+	TemplateID       uint       `json:"template_id"`  // Corresponds to PosterTemplateID
+	BusinessName     string     `json:"business_name"`
+	PDFURL           string     `json:"pdf_url"`                  // signed, expiring download URL for the rendered file
+	URLExpiresAt     *time.Time `json:"url_expires_at,omitempty"` // when pdf_url stops working; fetch the poster again for a fresh one
+	StorageKey       string     `json:"storage_key"`              // key of the file in the configured storage
+	Cached           bool       `json:"cached"`                   // true when the file was reused from the render cache
+	OutputFormat     string     `json:"output_format"`
+	Status           string     `json:"status"` // pending, processing, completed or failed
+	ErrorReason      string     `json:"error_reason,omitempty"`
+	TemplateRevision int        `json:"template_revision,omitempty"` // template revision the poster was rendered with
+	LayoutRevision   int        `json:"layout_revision,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// PosterPreview is a rendered preview, written to the client as-is rather than as JSON.
+type PosterPreview struct {
+	ContentType string
+	Body        []byte
+}
+
+// LayoutSource is the HTML of a layout, downloaded as a file rather than as JSON.
+type LayoutSource struct {
+	Filename string
+	Body     []byte
 }
 
 // TemplateResponse represents the response structure for a poster template (customization profile).
@@ -23,6 +48,9 @@ type TemplateResponse struct {
 	IsActive             bool            `json:"is_active"`
 	RequiredFields       json.RawMessage `json:"required_fields"`       // Send raw JSON to frontend
 	DefaultCustomization json.RawMessage `json:"default_customization"` // Send raw JSON to frontend
+	SampleData           json.RawMessage `json:"sample_data,omitempty"`
+	CustomizationOptions json.RawMessage `json:"customization_options,omitempty"`
+	Revision             int             `json:"revision"`
 }
 
 // LayoutResponse represents the response structure for a layout.
@@ -30,6 +58,17 @@ type LayoutResponse struct {
 	ID       uint   `json:"id"`
 	Name     string `json:"name"`
 	FilePath string `json:"file_path"`
+	Renderer string `json:"renderer,omitempty"`
+}
+
+// FontResponse represents an uploaded font.
+type FontResponse struct {
+	ID        uint      `json:"id"`
+	Family    string    `json:"family"`
+	Weight    int       `json:"weight"`
+	Style     string    `json:"style"`
+	Format    string    `json:"format"` // truetype or woff2
+	CreatedAt time.Time `json:"created_at"`
 }
 
 // AssetResponse represents the response structure for an asset.
@@ -40,3 +79,62 @@ type AssetResponse struct {
 	Data         string `json:"data"` // SVG or Base64
 	DefaultColor string `json:"default_color,omitempty"`
 }
+
+// PosterScanStats summarises how often the tracked QR codes of a poster were scanned.
+type PosterScanStats struct {
+	PosterID    uint             `json:"poster_id"`
+	TotalScans  int64            `json:"total_scans"`
+	FirstScanAt *time.Time       `json:"first_scan_at,omitempty"`
+	LastScanAt  *time.Time       `json:"last_scan_at,omitempty"`
+	Links       []ShortLinkStats `json:"links"`
+	Daily       []DailyScans     `json:"daily"` // recent days with at least one scan, oldest first
+}
+
+// ShortLinkStats is the scan count of one tracked QR code.
+type ShortLinkStats struct {
+	Field     string `json:"field"`
+	ShortURL  string `json:"short_url"`
+	TargetURL string `json:"target_url"`
+	Scans     int64  `json:"scans"`
+}
+
+// DailyScans counts the scans of one UTC day.
+type DailyScans struct {
+	Date  string `json:"date"` // YYYY-MM-DD
+	Scans int64  `json:"scans"`
+}
+
+// RevisionDiff lists what changed between two revisions of a template or layout.
+type RevisionDiff struct {
+	From    int              `json:"from"`
+	To      int              `json:"to"`
+	Changes []RevisionChange `json:"changes"`
+}
+
+// RevisionChange is one changed setting. Keys inside JSON settings are joined to the setting with
+// a dot, e.g. "required_fields.till_number" or "default_customization.primary_color".
+type RevisionChange struct {
+	Field  string      `json:"field"`
+	Change string      `json:"change"` // added, removed or changed
+	From   interface{} `json:"from,omitempty"`
+	To     interface{} `json:"to,omitempty"`
+	Lines  []string    `json:"lines,omitempty"` // changed lines of layout HTML as "-<line>: old" and "+<line>: new"; omitted when too many changed
+}
+
+// LayoutIssue is a problem found while checking a layout file against a template's fields.
+type LayoutIssue struct {
+	Key      string `json:"key,omitempty"` // template data key; empty for syntax errors
+	Problem  string `json:"problem"`       // syntax, missing, unknown or unused
+	Message  string `json:"message"`
+	Location string `json:"location,omitempty"` // <file>:<line>:<column> of the first use
+}
+
+// LayoutLintReport lists the issues of one layout file, or of one uploaded layout when LayoutID is
+// set, checked against one template when TemplateID is set.
+type LayoutLintReport struct {
+	FilePath   string        `json:"file_path,omitempty"`
+	LayoutID   uint          `json:"layout_id,omitempty"`
+	LayoutName string        `json:"layout_name,omitempty"`
+	TemplateID uint          `json:"template_id,omitempty"`
+	Issues     []LayoutIssue `json:"issues"`
+}
@@ -0,0 +1,87 @@
+package dto
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// JSONSchemaDialect is the JSON Schema version template schemas are written in.
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema is the part of JSON Schema (draft 2020-12) used to describe the input of a poster
+// template. The same schema drives the front-end form and the server-side validation.
+//
+// Every rule is expressed with standard keywords, so any 2020-12 validator accepts and rejects
+// the same values as the server; date bounds, for one, are patterns under allOf. A few
+// non-standard annotations help forms and are safe to ignore: formatMinimum/formatMaximum carry
+// the date bounds for date pickers (ajv-formats), errorMessage the text shown when a pattern does
+// not match (ajv-errors), and the formats phone, color and data-url pick input widgets. Ajv in
+// strict mode needs those plugins, or strict: false.
+type JSONSchema struct {
+	Schema        string        `json:"$schema,omitempty"`
+	Comment       string        `json:"$comment,omitempty"`
+	Title         string        `json:"title,omitempty"`
+	Description   string        `json:"description,omitempty"`
+	Type          string        `json:"type,omitempty"`
+	Format        string        `json:"format,omitempty"`
+	Pattern       string        `json:"pattern,omitempty"`
+	ErrorMessage  string        `json:"errorMessage,omitempty"`
+	MinLength     *int          `json:"minLength,omitempty"`
+	MaxLength     *int          `json:"maxLength,omitempty"`
+	Minimum       *float64      `json:"minimum,omitempty"`
+	Maximum       *float64      `json:"maximum,omitempty"`
+	FormatMinimum string        `json:"formatMinimum,omitempty"`
+	FormatMaximum string        `json:"formatMaximum,omitempty"`
+	Enum          []interface{} `json:"enum,omitempty"`
+	OneOf         []*JSONSchema `json:"oneOf,omitempty"` // labelled choices, each a const with a title
+	AllOf         []*JSONSchema `json:"allOf,omitempty"` // further patterns the value must match, each with its own message
+	Const         interface{}   `json:"const,omitempty"`
+	Default       interface{}   `json:"default,omitempty"`
+
+	Properties           SchemaProperties `json:"properties,omitempty"`
+	Required             []string         `json:"required,omitempty"`
+	AdditionalProperties *bool            `json:"additionalProperties,omitempty"`
+}
+
+// SchemaProperty is one property of an object schema.
+type SchemaProperty struct {
+	Name   string
+	Schema *JSONSchema
+}
+
+// SchemaProperties keeps the properties of an object schema in the order a form shows them.
+// It is written as a JSON object with the keys in that order.
+type SchemaProperties []SchemaProperty
+
+// Get returns the schema of the named property, or nil.
+func (p SchemaProperties) Get(name string) *JSONSchema {
+	for _, property := range p {
+		if property.Name == name {
+			return property.Schema
+		}
+	}
+	return nil
+}
+
+func (p SchemaProperties) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, property := range p {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(property.Name)
+		if err != nil {
+			return nil, err
+		}
+		schema, err := json.Marshal(property.Schema)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(schema)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -3,7 +3,11 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"path"
+	"strings"
 	"strconv"
 
 	"errors"
@@ -24,19 +28,38 @@ import (
 // PostersHandler interface includes all methods handled by this package.
 type PostersHandler interface {
 	GeneratePoster(w http.ResponseWriter, r *http.Request)
+	GeneratePosterUncached(w http.ResponseWriter, r *http.Request)
+	PreviewPoster(w http.ResponseWriter, r *http.Request)
 	GetPosterByID(w http.ResponseWriter, r *http.Request)
+	ServePosterFile(w http.ResponseWriter, r *http.Request)
+	GetPosterScanStats(w http.ResponseWriter, r *http.Request)
+	FollowShortLink(w http.ResponseWriter, r *http.Request)
 	// UpdatePoster(w http.ResponseWriter, r *http.Request) // Placeholder
 	// DeletePoster(w http.ResponseWriter, r *http.Request) // Placeholder
 	GetActiveTemplates(w http.ResponseWriter, r *http.Request)
 	CreateTemplate(w http.ResponseWriter, r *http.Request)
 	GetTemplateByID(w http.ResponseWriter, r *http.Request)
+	GetTemplateSchema(w http.ResponseWriter, r *http.Request)
 	UpdateTemplate(w http.ResponseWriter, r *http.Request)
 	DeleteTemplate(w http.ResponseWriter, r *http.Request)
+	ListTemplateRevisions(w http.ResponseWriter, r *http.Request)
+	DiffTemplateRevisions(w http.ResponseWriter, r *http.Request)
+	RollbackTemplate(w http.ResponseWriter, r *http.Request)
+	RegeneratePoster(w http.ResponseWriter, r *http.Request)
 	GetLogos(w http.ResponseWriter, r *http.Request) 
 	CreateLayout(w http.ResponseWriter, r *http.Request)
 	ListLayouts(w http.ResponseWriter, r *http.Request)
+	UpdateLayout(w http.ResponseWriter, r *http.Request)
+	DeleteLayout(w http.ResponseWriter, r *http.Request)
+	DownloadLayout(w http.ResponseWriter, r *http.Request)
+	ListLayoutRevisions(w http.ResponseWriter, r *http.Request)
+	DiffLayoutRevisions(w http.ResponseWriter, r *http.Request)
+	RollbackLayout(w http.ResponseWriter, r *http.Request)
 	CreateAsset(w http.ResponseWriter, r *http.Request)
 	ListAssets(w http.ResponseWriter, r *http.Request)
+	UploadFont(w http.ResponseWriter, r *http.Request)
+	ListFonts(w http.ResponseWriter, r *http.Request)
+	DeleteFont(w http.ResponseWriter, r *http.Request)
 
 }
 
@@ -59,44 +82,100 @@ func NewPostersHandler(service *postersServices.PosterService, log logger.Logger
 // GeneratePoster handles requests to create a new poster anonymously.
 func (h *postersHandler) GeneratePoster(w http.ResponseWriter, r *http.Request) {
 	h.log.Info("Handler: Received GeneratePoster request")
+	h.generatePoster(w, r, false)
+}
 
-	var input postersDTO.PosterInput
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		h.log.Warn("Handler: Failed to decode GeneratePoster request", err)
-		web.RespondError(w, appErrors.ValidationError("invalid request payload", err, nil), http.StatusBadRequest)
-		return
-	}
+// GeneratePosterUncached lets admins generate a poster with a fresh render, bypassing the render cache.
+func (h *postersHandler) GeneratePosterUncached(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Handler: Received GeneratePosterUncached request")
+	h.generatePoster(w, r, true)
+}
 
-	validationErrors := h.validator.Struct(input)
-	if validationErrors != nil {
-		h.log.Warn("Handler: Validation failed for GeneratePoster request", validationErrors)
-		web.RespondError(w, appErrors.ValidationError("validation failed", nil, validationErrors), http.StatusBadRequest)
+func (h *postersHandler) generatePoster(w http.ResponseWriter, r *http.Request, bypassCache bool) {
+	input, templateID, ok := h.decodePosterRequest(w, r)
+	if !ok {
 		return
 	}
-	templateIDStr := r.URL.Query().Get("template_id")
 
-	templateID, err := strconv.ParseUint(templateIDStr, 10, 32) // Use 32 for uint
-	if err != nil {
-		h.log.Warn("Handler: Invalid or missing template_id", err, "template_id", templateIDStr)
-		web.RespondError(w, appErrors.ValidationError("invalid template_id", nil, nil), http.StatusBadRequest)
-		return
+	// ?async=true is equivalent to "async": true in the body
+	if async, err := strconv.ParseBool(r.URL.Query().Get("async")); err == nil && async {
+		input.Async = true
 	}
+	input.BypassCache = bypassCache
 
 	ctx := r.Context()
 	// Call the correct sub-service via the main service aggregator
-	poster, err := h.service.PosterSvc.GeneratePoster(ctx, uint(templateID), &input)
+	poster, err := h.service.PosterSvc.GeneratePoster(ctx, templateID, input)
 	if err != nil {
 		h.log.Error("Handler: Failed to generate poster through service", err)
 		h.handleAppError(w, err, "generate poster")
 		return
 	}
 
+	if input.Async {
+		h.log.Info("Handler: Poster queued for rendering", "poster_id", poster.ID)
+		web.RespondData(w, http.StatusAccepted, poster, "Poster queued for generation", web.WithSuccessType("toast"))
+		return
+	}
+
 	h.log.Info("Handler: Poster generated successfully", "poster_id", poster.ID)
 	// --- Send response using the correct payload structure ---
 	// Your web.RespondData likely creates {"datapayload": {"data": poster}, "alertify": ...}
 	web.RespondData(w, http.StatusCreated, poster, "Poster generated successfully", web.WithSuccessType("toast"))
 }
 
+// PreviewPoster renders a poster as HTML (default) or a low-resolution image, selected with
+// ?format=html|png|jpeg|webp. Nothing is stored, so the result is returned directly.
+func (h *postersHandler) PreviewPoster(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Handler: Received PreviewPoster request")
+
+	input, templateID, ok := h.decodePosterRequest(w, r)
+	if !ok {
+		return
+	}
+	preview, err := h.service.PosterSvc.PreviewPoster(r.Context(), templateID, input, r.URL.Query().Get("format"))
+	if err != nil {
+		h.log.Error("Handler: Failed to preview poster through service", err)
+		h.handleAppError(w, err, "preview poster")
+		return
+	}
+
+	w.Header().Set("Content-Type", preview.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(preview.Body)))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(preview.Body); err != nil {
+		h.log.Warn("Handler: Failed to write poster preview", "error", err)
+	}
+}
+
+// decodePosterRequest reads and validates the body and template_id shared by generation and previews.
+// It writes the error response itself and reports whether the caller should continue.
+func (h *postersHandler) decodePosterRequest(w http.ResponseWriter, r *http.Request) (*postersDTO.PosterInput, uint, bool) {
+	var input postersDTO.PosterInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		h.log.Warn("Handler: Failed to decode poster request", err)
+		web.RespondError(w, appErrors.ValidationError("invalid request payload", err, nil), http.StatusBadRequest)
+		return nil, 0, false
+	}
+
+	validationErrors := h.validator.Struct(input)
+	if validationErrors != nil {
+		h.log.Warn("Handler: Validation failed for poster request", validationErrors)
+		web.RespondError(w, appErrors.ValidationError("validation failed", nil, validationErrors), http.StatusBadRequest)
+		return nil, 0, false
+	}
+	templateIDStr := r.URL.Query().Get("template_id")
+
+	templateID, err := strconv.ParseUint(templateIDStr, 10, 32) // Use 32 for uint
+	if err != nil {
+		h.log.Warn("Handler: Invalid or missing template_id", err, "template_id", templateIDStr)
+		web.RespondError(w, appErrors.ValidationError("invalid template_id", nil, nil), http.StatusBadRequest)
+		return nil, 0, false
+	}
+	return &input, uint(templateID), true
+}
+
 // GetLogos handles requests for the predefined logo library.
 func (h *postersHandler) GetLogos(w http.ResponseWriter, r *http.Request) {
 	h.log.Info("Handler: Received GetLogos request")
@@ -117,7 +196,8 @@ func (h *postersHandler) GetLogos(w http.ResponseWriter, r *http.Request) {
 	web.RespondListData(w, http.StatusOK, logos, nil) // Respond with the list
 }
 
-// GetPosterByID retrieves details of a specific generated poster.
+// GetPosterByID retrieves details of a specific generated poster. The poster's access token must
+// be passed as ?token=.
 func (h *postersHandler) GetPosterByID(w http.ResponseWriter, r *http.Request) {
 	h.log.Info("Handler: Received GetPosterByID request")
 
@@ -131,7 +211,7 @@ func (h *postersHandler) GetPosterByID(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 	// Call the correct sub-service
-	poster, err := h.service.PosterSvc.GetPosterByID(ctx, uint(id))
+	poster, err := h.service.PosterSvc.GetPosterByID(ctx, uint(id), r.URL.Query().Get("token"))
 	if err != nil {
 		h.log.Error("Handler: Failed to get poster by ID", err, "id", id)
 		h.handleAppError(w, err, "get poster")
@@ -142,6 +222,65 @@ func (h *postersHandler) GetPosterByID(w http.ResponseWriter, r *http.Request) {
 	web.RespondData(w, http.StatusOK, poster, "Poster retrieved successfully", web.WithoutSuccess())
 }
 
+// GetPosterScanStats reports how often the tracked QR codes of a poster were scanned. Like
+// GetPosterByID it needs the poster's access token as ?token=.
+func (h *postersHandler) GetPosterScanStats(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		h.log.Warn("Handler: Invalid poster ID format", err, "id", idStr)
+		web.RespondError(w, appErrors.ValidationError("invalid poster ID format", nil, nil), http.StatusBadRequest)
+		return
+	}
+
+	stats, err := h.service.ScanSvc.GetPosterScanStats(r.Context(), uint(id), r.URL.Query().Get("token"))
+	if err != nil {
+		h.handleAppError(w, err, "get poster scan stats")
+		return
+	}
+	web.RespondData(w, http.StatusOK, stats, "Poster scan statistics retrieved successfully", web.WithoutSuccess())
+}
+
+// FollowShortLink records a scan of a tracked QR code and redirects to the link's target.
+func (h *postersHandler) FollowShortLink(w http.ResponseWriter, r *http.Request) {
+	target, err := h.service.ScanSvc.ResolveScan(r.Context(), chi.URLParam(r, "code"), r.UserAgent())
+	if err != nil {
+		h.handleAppError(w, err, "follow short link")
+		return
+	}
+	// Every scan must reach the server to be counted, so the redirect may not be cached.
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// ServePosterFile streams a rendered poster from storage. The key is the wildcard part of the route.
+func (h *postersHandler) ServePosterFile(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "*")
+
+	obj, err := h.service.PosterSvc.OpenPosterFile(r.Context(), key, r.URL.Query())
+	if err != nil {
+		h.handleAppError(w, err, "serve poster file")
+		return
+	}
+	defer obj.Body.Close()
+
+	if obj.ContentType != "" {
+		w.Header().Set("Content-Type", obj.ContentType)
+	}
+	// The name is covered by the link signature; sanitise it again so the header is always well-formed.
+	filename := postersServices.DownloadFilename(strings.TrimSuffix(r.URL.Query().Get(postersServices.DownloadNameParam), path.Ext(key)), path.Ext(key))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	// Local files and S3 objects are both seekable, which gives us range requests and conditional GETs.
+	if body, ok := obj.Body.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, path.Base(key), obj.ModTime, body)
+		return
+	}
+	w.Header().Set("Content-Length", strconv.FormatInt(obj.Size, 10))
+	if _, err := io.Copy(w, obj.Body); err != nil {
+		h.log.Warn("Handler: Failed to stream poster file", "key", key, "error", err)
+	}
+}
+
 // GetActiveTemplates retrieves all currently active template profiles.
 func (h *postersHandler) GetActiveTemplates(w http.ResponseWriter, r *http.Request) {
 	h.log.Info("Handler: Received GetActiveTemplates request")
@@ -277,21 +416,199 @@ func (h *postersHandler) DeleteTemplate(w http.ResponseWriter, r *http.Request)
 	web.RespondMessage(w, http.StatusNoContent, "Template deleted successfully", "success", "toast")
 }
 
-func (h *postersHandler) CreateLayout(w http.ResponseWriter, r *http.Request) {
-	h.log.Info("Handler: Received CreateLayout request")
-	var input postersDTO.LayoutInput
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		web.RespondError(w, appErrors.ValidationError("invalid request payload", err, nil), http.StatusBadRequest)
+// RegeneratePoster renders a stored poster again as a new poster. The optional body picks the
+// template revision: {"template_revision": 3} or {"latest": true}; by default the original one is used.
+func (h *postersHandler) RegeneratePoster(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Handler: Received RegeneratePoster request")
+	id, ok := h.uintParam(w, r, "id", "poster ID")
+	if !ok {
 		return
 	}
+	var input postersDTO.RegenerateInput
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil && !errors.Is(err, io.EOF) {
+			web.RespondError(w, appErrors.ValidationError("invalid request payload", err, nil), http.StatusBadRequest)
+			return
+		}
+	}
+	if async, err := strconv.ParseBool(r.URL.Query().Get("async")); err == nil && async {
+		input.Async = true
+	}
 	if validationErrors := h.validator.Struct(input); validationErrors != nil {
 		web.RespondError(w, appErrors.ValidationError("validation failed", nil, validationErrors), http.StatusBadRequest)
 		return
 	}
 
+	poster, err := h.service.PosterSvc.RegeneratePoster(r.Context(), id, &input)
+	if err != nil {
+		h.handleAppError(w, err, "regenerate poster")
+		return
+	}
+	if input.Async {
+		web.RespondData(w, http.StatusAccepted, poster, "Poster queued for generation", web.WithSuccessType("toast"))
+		return
+	}
+	web.RespondData(w, http.StatusCreated, poster, "Poster regenerated successfully", web.WithSuccessType("toast"))
+}
+
+// GetTemplateSchema serves the JSON Schema of a template's poster input as a plain schema
+// document, so form libraries can use it directly.
+func (h *postersHandler) GetTemplateSchema(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.uintParam(w, r, "id", "template ID")
+	if !ok {
+		return
+	}
+	schema, err := h.service.PosterTemplateSvc.GetTemplateSchema(r.Context(), id)
+	if err != nil {
+		h.handleAppError(w, err, "get template schema")
+		return
+	}
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(schema); err != nil {
+		h.log.Warn("Handler: Failed to write template schema", err, "template_id", id)
+	}
+}
+
+func (h *postersHandler) ListTemplateRevisions(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.uintParam(w, r, "id", "template ID")
+	if !ok {
+		return
+	}
+	revisions, err := h.service.PosterTemplateSvc.ListRevisions(r.Context(), id)
+	if err != nil {
+		h.handleAppError(w, err, "list template revisions")
+		return
+	}
+	web.RespondListData(w, http.StatusOK, revisions, nil)
+}
+
+// DiffTemplateRevisions compares ?from=<revision> with ?to=<revision>, or with the current revision when to is left out.
+func (h *postersHandler) DiffTemplateRevisions(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.uintParam(w, r, "id", "template ID")
+	if !ok {
+		return
+	}
+	from, to, ok := h.revisionRange(w, r)
+	if !ok {
+		return
+	}
+	diff, err := h.service.PosterTemplateSvc.DiffRevisions(r.Context(), id, from, to)
+	if err != nil {
+		h.handleAppError(w, err, "diff template revisions")
+		return
+	}
+	web.RespondData(w, http.StatusOK, diff, "Template revisions compared", web.WithoutSuccess())
+}
+
+func (h *postersHandler) RollbackTemplate(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Handler: Received RollbackTemplate request")
+	id, ok := h.uintParam(w, r, "id", "template ID")
+	if !ok {
+		return
+	}
+	revision, ok := h.uintParam(w, r, "revision", "revision")
+	if !ok {
+		return
+	}
+	template, err := h.service.PosterTemplateSvc.RollbackTemplate(r.Context(), id, int(revision))
+	if err != nil {
+		h.handleAppError(w, err, "roll back template")
+		return
+	}
+	web.RespondData(w, http.StatusOK, template, fmt.Sprintf("Template rolled back to revision %d", revision), web.WithSuccessType("toast"))
+}
+
+func (h *postersHandler) ListLayoutRevisions(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.layoutID(w, r)
+	if !ok {
+		return
+	}
+	revisions, err := h.service.LayoutSvc.ListLayoutRevisions(r.Context(), id)
+	if err != nil {
+		h.handleAppError(w, err, "list layout revisions")
+		return
+	}
+	web.RespondListData(w, http.StatusOK, revisions, nil)
+}
+
+// DiffLayoutRevisions compares ?from=<revision> with ?to=<revision>, or with the current revision when to is left out.
+func (h *postersHandler) DiffLayoutRevisions(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.layoutID(w, r)
+	if !ok {
+		return
+	}
+	from, to, ok := h.revisionRange(w, r)
+	if !ok {
+		return
+	}
+	diff, err := h.service.LayoutSvc.DiffLayoutRevisions(r.Context(), id, from, to)
+	if err != nil {
+		h.handleAppError(w, err, "diff layout revisions")
+		return
+	}
+	web.RespondData(w, http.StatusOK, diff, "Layout revisions compared", web.WithoutSuccess())
+}
+
+func (h *postersHandler) RollbackLayout(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Handler: Received RollbackLayout request")
+	id, ok := h.layoutID(w, r)
+	if !ok {
+		return
+	}
+	revision, ok := h.uintParam(w, r, "revision", "revision")
+	if !ok {
+		return
+	}
+	layout, err := h.service.LayoutSvc.RollbackLayout(r.Context(), id, int(revision))
+	if err != nil {
+		h.handleAppError(w, err, "roll back layout")
+		return
+	}
+	web.RespondData(w, http.StatusOK, layout, fmt.Sprintf("Layout rolled back to revision %d", revision), web.WithSuccessType("toast"))
+}
+
+// revisionRange reads the from and to query parameters of a revision diff; to is 0 when left out.
+func (h *postersHandler) revisionRange(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	from, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil || from < 1 {
+		web.RespondError(w, appErrors.ValidationError("invalid revision range", err, map[string]string{"from": "from must be a revision number"}), http.StatusBadRequest)
+		return 0, 0, false
+	}
+	to := 0
+	if toStr := r.URL.Query().Get("to"); toStr != "" {
+		to, err = strconv.Atoi(toStr)
+		if err != nil || to < 1 {
+			web.RespondError(w, appErrors.ValidationError("invalid revision range", err, map[string]string{"to": "to must be a revision number"}), http.StatusBadRequest)
+			return 0, 0, false
+		}
+	}
+	return from, to, true
+}
+
+// uintParam parses a numeric URL parameter, writing the error response when it is invalid.
+func (h *postersHandler) uintParam(w http.ResponseWriter, r *http.Request, name, label string) (uint, bool) {
+	value := chi.URLParam(r, name)
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		h.log.Warn(fmt.Sprintf("Handler: Invalid %s format", label), err, name, value)
+		web.RespondError(w, appErrors.ValidationError(fmt.Sprintf("invalid %s format", label), nil, nil), http.StatusBadRequest)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// CreateLayout accepts a JSON body with name, renderer and either file_path or content, or
+// multipart/form-data with the fields name, renderer and the layout HTML as file.
+func (h *postersHandler) CreateLayout(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Handler: Received CreateLayout request")
+	input, ok := h.decodeLayoutInput(w, r)
+	if !ok {
+		return
+	}
+
 	ctx := r.Context()
-	// Assume LayoutSvc has a CreateLayout method
-	layout, err := h.service.LayoutSvc.CreateLayout(ctx, &input) // You need to implement CreateLayout in LayoutService
+	layout, err := h.service.LayoutSvc.CreateLayout(ctx, input)
 	if err != nil {
 		h.log.Error("Handler: Failed to create layout", err)
 		h.handleAppError(w, err, "create layout")
@@ -301,6 +618,106 @@ func (h *postersHandler) CreateLayout(w http.ResponseWriter, r *http.Request) {
 	web.RespondData(w, http.StatusCreated, layout, "Layout created successfully", web.WithSuccessType("toast"))
 }
 
+// UpdateLayout replaces a layout with a body in the same formats as CreateLayout.
+func (h *postersHandler) UpdateLayout(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Handler: Received UpdateLayout request")
+	id, ok := h.layoutID(w, r)
+	if !ok {
+		return
+	}
+	input, ok := h.decodeLayoutInput(w, r)
+	if !ok {
+		return
+	}
+
+	layout, err := h.service.LayoutSvc.UpdateLayout(r.Context(), id, input)
+	if err != nil {
+		h.log.Error("Handler: Failed to update layout", err, "id", id)
+		h.handleAppError(w, err, "update layout")
+		return
+	}
+	h.log.Info("Handler: Layout updated successfully", "layout_id", id)
+	web.RespondData(w, http.StatusOK, layout, "Layout updated successfully", web.WithSuccessType("toast"))
+}
+
+func (h *postersHandler) DeleteLayout(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Handler: Received DeleteLayout request")
+	id, ok := h.layoutID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.service.LayoutSvc.DeleteLayout(r.Context(), id); err != nil {
+		h.log.Error("Handler: Failed to delete layout", err, "id", id)
+		h.handleAppError(w, err, "delete layout")
+		return
+	}
+	h.log.Info("Handler: Layout deleted successfully", "layout_id", id)
+	web.RespondMessage(w, http.StatusNoContent, "Layout deleted successfully", "success", "toast")
+}
+
+// DownloadLayout returns the layout HTML as an attachment.
+func (h *postersHandler) DownloadLayout(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.layoutID(w, r)
+	if !ok {
+		return
+	}
+	source, err := h.service.LayoutSvc.DownloadLayout(r.Context(), id)
+	if err != nil {
+		h.handleAppError(w, err, "download layout")
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": source.Filename}))
+	w.Header().Set("Content-Length", strconv.Itoa(len(source.Body)))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(source.Body); err != nil {
+		h.log.Warn("Handler: Failed to write layout download", "layout_id", id, "error", err)
+	}
+}
+
+// decodeLayoutInput reads a layout from JSON or from a multipart upload. It writes the error
+// response itself and reports whether the caller should continue.
+func (h *postersHandler) decodeLayoutInput(w http.ResponseWriter, r *http.Request) (*postersDTO.LayoutInput, bool) {
+	var input postersDTO.LayoutInput
+	// Leave room for the other fields on top of the layout itself.
+	r.Body = http.MaxBytesReader(w, r.Body, postersServices.MaxLayoutSize+1<<20)
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		if err := r.ParseMultipartForm(postersServices.MaxLayoutSize); err != nil {
+			web.RespondError(w, appErrors.ValidationError("invalid multipart form or file too large", err, nil), http.StatusBadRequest)
+			return nil, false
+		}
+		input.Name = r.FormValue("name")
+		input.FilePath = r.FormValue("file_path")
+		input.Renderer = r.FormValue("renderer")
+		if file, _, err := r.FormFile("file"); err == nil {
+			defer file.Close()
+			content, err := io.ReadAll(io.LimitReader(file, postersServices.MaxLayoutSize+1))
+			if err != nil {
+				web.RespondError(w, appErrors.ValidationError("failed to read layout file", err, nil), http.StatusBadRequest)
+				return nil, false
+			}
+			input.Content = string(content)
+		} else if !errors.Is(err, http.ErrMissingFile) {
+			web.RespondError(w, appErrors.ValidationError("invalid layout file", err, map[string]string{"file": "Upload the layout HTML as the 'file' field"}), http.StatusBadRequest)
+			return nil, false
+		}
+	} else {
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			web.RespondError(w, appErrors.ValidationError("invalid request payload", err, nil), http.StatusBadRequest)
+			return nil, false
+		}
+	}
+	if validationErrors := h.validator.Struct(input); validationErrors != nil {
+		web.RespondError(w, appErrors.ValidationError("validation failed", nil, validationErrors), http.StatusBadRequest)
+		return nil, false
+	}
+	return &input, true
+}
+
+func (h *postersHandler) layoutID(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	return h.uintParam(w, r, "id", "layout ID")
+}
+
 func (h *postersHandler) ListLayouts(w http.ResponseWriter, r *http.Request) {
 	h.log.Info("Handler: Received ListLayouts request")
 	ctx := r.Context()
@@ -378,6 +795,12 @@ func (h *postersHandler) handleAppError(w http.ResponseWriter, err error, action
 			web.RespondError(w, appErr, http.StatusPaymentRequired)
 		case "BAD_REQUEST":
 			web.RespondError(w, appErr, http.StatusBadRequest)
+		case "TIMEOUT_ERROR":
+			web.RespondError(w, appErr, http.StatusGatewayTimeout)
+		case "SERVICE_UNAVAILABLE":
+			web.RespondError(w, appErr, http.StatusServiceUnavailable)
+		case "AUTHORIZATION_ERROR":
+			web.RespondError(w, appErr, http.StatusForbidden)
 		default:
 			web.RespondError(w, appErrors.InternalServerError(
 				fmt.Sprintf("an unexpected application error occurred with code %s", appErr.Code()), appErr), http.StatusInternalServerError)
@@ -388,4 +811,76 @@ func (h *postersHandler) handleAppError(w http.ResponseWriter, err error, action
 	}
 }
 
+// UploadFont accepts a TTF or WOFF2 file as multipart/form-data with the fields
+// file, family, weight (100-900, default 400) and style (normal or italic).
+func (h *postersHandler) UploadFont(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Handler: Received UploadFont request")
 
+	// Leave room for the other form fields on top of the font itself.
+	r.Body = http.MaxBytesReader(w, r.Body, postersServices.MaxFontSize+1<<20)
+	if err := r.ParseMultipartForm(postersServices.MaxFontSize); err != nil {
+		web.RespondError(w, appErrors.ValidationError("invalid multipart form or file too large", err, nil), http.StatusBadRequest)
+		return
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		web.RespondError(w, appErrors.ValidationError("font file is required", err, map[string]string{"file": "Upload the font as the 'file' field"}), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	input := postersDTO.FontInput{
+		Family: r.FormValue("family"),
+		Style:  r.FormValue("style"),
+	}
+	if weight := r.FormValue("weight"); weight != "" {
+		input.Weight, err = strconv.Atoi(weight)
+		if err != nil {
+			web.RespondError(w, appErrors.ValidationError("invalid font weight", err, map[string]string{"weight": "Weight must be a number between 100 and 900"}), http.StatusBadRequest)
+			return
+		}
+	}
+	if validationErrors := h.validator.Struct(input); validationErrors != nil {
+		web.RespondError(w, appErrors.ValidationError("validation failed", nil, validationErrors), http.StatusBadRequest)
+		return
+	}
+
+	font, err := h.service.FontSvc.UploadFont(r.Context(), &input, file)
+	if err != nil {
+		h.log.Error("Handler: Failed to upload font", err)
+		h.handleAppError(w, err, "upload font")
+		return
+	}
+	h.log.Info("Handler: Font uploaded successfully", "font_id", font.ID)
+	web.RespondData(w, http.StatusCreated, font, "Font uploaded successfully", web.WithSuccessType("toast"))
+}
+
+func (h *postersHandler) ListFonts(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Handler: Received ListFonts request")
+	fonts, err := h.service.FontSvc.ListFonts(r.Context())
+	if err != nil {
+		h.log.Error("Handler: Failed to list fonts", err)
+		h.handleAppError(w, err, "list fonts")
+		return
+	}
+	web.RespondListData(w, http.StatusOK, fonts, nil)
+}
+
+func (h *postersHandler) DeleteFont(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Handler: Received DeleteFont request")
+
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		h.log.Warn("Handler: Invalid font ID format", err, "id", idStr)
+		web.RespondError(w, appErrors.ValidationError("invalid font ID format", nil, nil), http.StatusBadRequest)
+		return
+	}
+	if err := h.service.FontSvc.DeleteFont(r.Context(), uint(id)); err != nil {
+		h.log.Error("Handler: Failed to delete font", err, "id", id)
+		h.handleAppError(w, err, "delete font")
+		return
+	}
+	h.log.Info("Handler: Font deleted successfully", "font_id", id)
+	web.RespondMessage(w, http.StatusNoContent, "Font deleted successfully", "success", "toast")
+}
@@ -9,7 +9,10 @@ import (
 type Layout struct {
 	gorm.Model
 	Name            string `json:"name" gorm:"type:varchar(50);not null;unique"`
-	FilePath        string `json:"file_path" gorm:"type:varchar(255);not null"`
+	FilePath        string `json:"file_path" gorm:"type:varchar(255);not null"` // relative to the templates directory; empty for uploaded layouts
+	Content         string `json:"-" gorm:"type:text"`                          // uploaded HTML, used instead of FilePath when set
+	Renderer        string `json:"renderer" gorm:"type:varchar(30)"` // empty = configured default backend
+	Revision        int    `json:"revision" gorm:"not null;default:0"` // latest LayoutRevision; 0 for layouts saved before revisions existed
 	PosterTemplates []PosterTemplate `json:"-" gorm:"foreignKey:LayoutID"`
 }
 
@@ -24,9 +27,13 @@ type PosterTemplate struct {
 	LayoutID             uint           `json:"layout_id" gorm:"not null;index"`
 	Price                int            `json:"price" gorm:"not null;default:0"`
 	ThumbnailURL         string         `json:"thumbnail_url" gorm:"type:varchar(255)"`
+	ThumbnailKey         string         `json:"-" gorm:"type:varchar(255)"` // storage key of the generated thumbnail; takes precedence over ThumbnailURL
 	IsActive             bool           `json:"is_active" gorm:"default:true;index"`
 	RequiredFields       datatypes.JSON `json:"required_fields" gorm:"not null"`
 	DefaultCustomization datatypes.JSON `json:"default_customization" gorm:"not null"`
+	SampleData           datatypes.JSON `json:"sample_data"` // example field values the thumbnail is rendered with
+	CustomizationOptions datatypes.JSON `json:"customization_options"` // customization keys users may override, see services.CustomizationOption
+	Revision             int            `json:"revision" gorm:"not null;default:0"` // latest PosterTemplateRevision; 0 for templates saved before revisions existed
 	Layout               Layout         `json:"layout" gorm:"foreignKey:LayoutID"`
 }
 
@@ -40,8 +47,18 @@ type Asset struct {
 	Type         string `json:"type" gorm:"type:varchar(50);not null;index"`
 	Data         string `json:"data" gorm:"type:text;not null"`
 	DefaultColor string `json:"default_color" gorm:"type:varchar(7)"`
+
+	// Font assets keep the font file in the poster file storage; Data stays empty.
+	FontFamily string `json:"font_family,omitempty" gorm:"type:varchar(100);index"`
+	FontWeight int    `json:"font_weight,omitempty"`
+	FontStyle  string `json:"font_style,omitempty" gorm:"type:varchar(10)"`
+	FontFormat string `json:"font_format,omitempty" gorm:"type:varchar(10)"` // truetype or woff2
+	StorageKey string `json:"-" gorm:"type:varchar(255)"`
 }
 
+// AssetTypeFont marks assets holding an uploaded font file.
+const AssetTypeFont = "font"
+
 func (Asset) TableName() string {
 	return "assets"
 }
@@ -53,10 +70,25 @@ type Poster struct {
 	UserInputData      datatypes.JSON `json:"user_input_data" gorm:"not null"`
 	FinalCustomization datatypes.JSON `json:"final_customization_data" gorm:"not null"`
 	PDFURL             string         `json:"pdf_url" gorm:"type:varchar(255)"`
+	OutputFormat       string         `json:"output_format" gorm:"type:varchar(10);not null;default:'pdf'"`
 	Status             string         `json:"status" gorm:"type:varchar(50);default:'completed';index"`
+	RequestPayload     datatypes.JSON `json:"-"`                                              // original generate request, replayed by render workers
+	ErrorReason        string         `json:"error_reason,omitempty" gorm:"type:text"`        // why rendering failed, when Status is failed
+	RenderCacheHit     bool           `json:"render_cache_hit" gorm:"not null;default:false"` // PDFURL points at a file shared with an earlier identical poster
+	TemplateRevision   int            `json:"template_revision" gorm:"not null;default:0"`    // template revision rendered; 0 when unknown
+	LayoutRevision     int            `json:"layout_revision" gorm:"not null;default:0"`      // revision of the template revision's layout
+	AccessToken        string         `json:"-" gorm:"type:varchar(64)"`                      // unguessable secret required to look the poster up; empty on posters created before tokens
 	PosterTemplate     PosterTemplate `json:"poster_template" gorm:"foreignKey:PosterTemplateID"`
 }
 
+// Poster lifecycle states.
+const (
+	PosterStatusPending    = "pending"
+	PosterStatusProcessing = "processing"
+	PosterStatusCompleted  = "completed"
+	PosterStatusFailed     = "failed"
+)
+
 func (Poster) TableName() string {
 	return "posters"
 }
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// RenderCacheEntry points a render fingerprint (layout content + final data + output options)
+// at a file that was already rendered for it, so identical posters can share that file.
+type RenderCacheEntry struct {
+	ID           uint      `json:"id" gorm:"primarykey"`
+	CacheKey     string    `json:"cache_key" gorm:"type:varchar(64);not null;uniqueIndex"`
+	StorageKey   string    `json:"storage_key" gorm:"type:varchar(255);not null"`
+	OutputFormat string    `json:"output_format" gorm:"type:varchar(10);not null"`
+	HitCount     int       `json:"hit_count" gorm:"not null;default:0"`
+	LastUsedAt   time.Time `json:"last_used_at" gorm:"not null;index"`
+	ExpiresAt    time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (RenderCacheEntry) TableName() string {
+	return "render_cache_entries"
+}
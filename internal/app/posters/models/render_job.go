@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Render job states.
+const (
+	RenderJobQueued    = "queued"    // waiting for RunAt
+	RenderJobRunning   = "running"   // claimed by a worker until LockedUntil
+	RenderJobCompleted = "completed" // poster rendered
+	RenderJobDead      = "dead"      // gave up; see LastError
+)
+
+// Render job kinds.
+const (
+	RenderJobPoster    = "poster"    // renders the poster PosterID
+	RenderJobThumbnail = "thumbnail" // renders the thumbnail of the template TemplateID
+)
+
+// RenderJob is a durable request to render a poster or a template thumbnail, claimed by background workers.
+type RenderJob struct {
+	gorm.Model
+	Kind        string     `json:"kind" gorm:"type:varchar(20);not null;default:'poster'"`
+	PosterID    uint       `json:"poster_id" gorm:"not null;index"` // 0 for thumbnail jobs
+	TemplateID  uint       `json:"template_id"`                     // thumbnail jobs only
+	Status      string     `json:"status" gorm:"type:varchar(20);not null;default:'queued';index:idx_render_jobs_claim,priority:1"`
+	RunAt       time.Time  `json:"run_at" gorm:"not null;index:idx_render_jobs_claim,priority:2"`
+	Attempts    int        `json:"attempts" gorm:"not null;default:0"`
+	MaxAttempts int        `json:"max_attempts" gorm:"not null;default:5"`
+	LockedBy    string     `json:"locked_by" gorm:"type:varchar(255)"`
+	LockedUntil *time.Time `json:"locked_until"` // visibility timeout; an expired lock makes the job claimable again
+	LastError   string     `json:"last_error" gorm:"type:text"`
+}
+
+func (RenderJob) TableName() string {
+	return "render_jobs"
+}
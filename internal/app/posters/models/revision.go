@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// PosterTemplateRevision is an immutable snapshot of a template, written every time the template is
+// created, updated or rolled back. Posters record the revision they were generated with.
+type PosterTemplateRevision struct {
+	ID                   uint           `json:"id" gorm:"primarykey"`
+	PosterTemplateID     uint           `json:"poster_template_id" gorm:"not null;uniqueIndex:idx_template_revision"`
+	Revision             int            `json:"revision" gorm:"not null;uniqueIndex:idx_template_revision"`
+	Name                 string         `json:"name" gorm:"type:varchar(100);not null"`
+	Type                 string         `json:"type" gorm:"type:varchar(50);not null"`
+	LayoutID             uint           `json:"layout_id" gorm:"not null"`
+	Price                int            `json:"price" gorm:"not null;default:0"`
+	ThumbnailURL         string         `json:"thumbnail_url" gorm:"type:varchar(255)"`
+	IsActive             bool           `json:"is_active"`
+	RequiredFields       datatypes.JSON `json:"required_fields" gorm:"not null"`
+	DefaultCustomization datatypes.JSON `json:"default_customization" gorm:"not null"`
+	SampleData           datatypes.JSON `json:"sample_data"`
+	CustomizationOptions datatypes.JSON `json:"customization_options"`
+	CreatedAt            time.Time      `json:"created_at"`
+}
+
+func (PosterTemplateRevision) TableName() string {
+	return "poster_template_revisions"
+}
+
+// LayoutRevision is an immutable snapshot of a layout. Uploaded layouts keep their HTML in the
+// revision; layouts that name a file only keep the path, so they are as reproducible as the file.
+type LayoutRevision struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	LayoutID  uint      `json:"layout_id" gorm:"not null;uniqueIndex:idx_layout_revision"`
+	Revision  int       `json:"revision" gorm:"not null;uniqueIndex:idx_layout_revision"`
+	Name      string    `json:"name" gorm:"type:varchar(50);not null"`
+	FilePath  string    `json:"file_path" gorm:"type:varchar(255);not null"`
+	Content   string    `json:"-" gorm:"type:text"`
+	Renderer  string    `json:"renderer" gorm:"type:varchar(30)"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (LayoutRevision) TableName() string {
+	return "layout_revisions"
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// ShortLink is the short URL encoded in a poster's tracked QR code. Scanning it records a
+// ScanEvent and redirects to TargetURL.
+type ShortLink struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	Code      string    `json:"code" gorm:"type:varchar(16);not null;uniqueIndex"`
+	PosterID  uint      `json:"poster_id" gorm:"not null;index"`
+	Field     string    `json:"field" gorm:"type:varchar(100);not null"` // qr field the link was made for
+	TargetURL string    `json:"target_url" gorm:"type:text;not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (ShortLink) TableName() string {
+	return "short_links"
+}
+
+// ScanEvent is one visit of a short link.
+type ScanEvent struct {
+	ID          uint      `json:"id" gorm:"primarykey"`
+	ShortLinkID uint      `json:"short_link_id" gorm:"not null;index"`
+	PosterID    uint      `json:"poster_id" gorm:"not null;index"`
+	UserAgent   string    `json:"user_agent" gorm:"type:varchar(512)"`
+	ScannedAt   time.Time `json:"scanned_at" gorm:"not null;index"`
+}
+
+func (ScanEvent) TableName() string {
+	return "scan_events"
+}
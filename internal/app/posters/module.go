@@ -1,13 +1,23 @@
 package posters
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/codetheuri/poster-gen/config"
 	postersHandlers "github.com/codetheuri/poster-gen/internal/app/posters/handlers"
 	postersRepositories "github.com/codetheuri/poster-gen/internal/app/posters/repositories"
 	postersServices "github.com/codetheuri/poster-gen/internal/app/posters/services"
 	"github.com/codetheuri/poster-gen/internal/app/routers"
 	tokenPkg "github.com/codetheuri/poster-gen/pkg/auth/token"
+	"github.com/codetheuri/poster-gen/pkg/browser"
 	"github.com/codetheuri/poster-gen/pkg/logger"
 	"github.com/codetheuri/poster-gen/pkg/middleware"
+	"github.com/codetheuri/poster-gen/pkg/renderer"
+	"github.com/codetheuri/poster-gen/pkg/storage"
+	"github.com/codetheuri/poster-gen/pkg/urlsign"
 	"github.com/codetheuri/poster-gen/pkg/validators"
 	"gorm.io/gorm"
 )
@@ -16,32 +26,172 @@ type Module struct {
 	Handler      postersHandlers.PostersHandler
 	log          logger.Logger
 	TokenService tokenPkg.TokenService // Keep if using authentication middleware
+	runtime      *Runtime
 }
 
 // NewModule initializes the Posters module using the aggregated service.
-func NewModule(db *gorm.DB, log logger.Logger, validator *validators.Validator, tokenService tokenPkg.TokenService) *Module {
-	// 1. Create the aggregated repository
-	repos := postersRepositories.NewPosterRepository(db, log)
-	// 2. Create the aggregated service, passing the aggregated repo
-	services := postersServices.NewPosterService(repos, validator, log) // Pass only needed args
-	// 3. Create the handler, passing the aggregated service
-	handler := postersHandlers.NewPostersHandler(services, log, validator)
+func NewModule(db *gorm.DB, log logger.Logger, validator *validators.Validator, tokenService tokenPkg.TokenService, cfg *config.Config) (*Module, error) {
+	// 1-4. Repositories, storage, renderers and the aggregated service
+	runtime, err := NewRuntime(db, log, validator, cfg)
+	if err != nil {
+		return nil, err
+	}
+	// 5. Create the handler, passing the aggregated service
+	handler := postersHandlers.NewPostersHandler(runtime.Services, log, validator)
 
 	return &Module{
 		Handler:      handler,
 		log:          log,
 		TokenService: tokenService,
+		runtime:      runtime,
+	}, nil
+}
+
+// Runtime is the posters service stack without the HTTP layer. The API module, the
+// render worker and the posterctl maintenance tool all build on it.
+type Runtime struct {
+	Repos       *postersRepositories.PosterRepository
+	Services    *postersServices.PosterService
+	Files       storage.Storage
+	browserPool *browser.Pool
+}
+
+// NewRuntime wires repositories, storage, download signing and renderers into the aggregated service.
+func NewRuntime(db *gorm.DB, log logger.Logger, validator *validators.Validator, cfg *config.Config) (*Runtime, error) {
+	// 1. Create the aggregated repository
+	repos := postersRepositories.NewPosterRepository(db, log)
+	// 2. Storage for generated files (local disk or S3-compatible)
+	files, err := storage.New(storageConfig(cfg))
+	if err != nil {
+		return nil, err
+	}
+	downloads, err := downloadConfig(cfg, log)
+	if err != nil {
+		return nil, err
+	}
+	// 3. Shared headless browser and rendering backends
+	browserPool, renderers := newRenderers(cfg, log)
+	// 4. Create the aggregated service, passing the aggregated repo
+	services := postersServices.NewPosterService(repos, validator, log, renderers, cfg.RenderTimeout, renderQueueConfig(cfg), files, downloads, renderCacheConfig(cfg), scanLinkConfig(cfg, log))
+
+	return &Runtime{Repos: repos, Services: services, Files: files, browserPool: browserPool}, nil
+}
+
+// Close drains queued renders, then releases the headless browser.
+func (rt *Runtime) Close(ctx context.Context) error {
+	err := rt.Services.Shutdown(ctx)
+	rt.browserPool.Close()
+	return err
+}
+
+// newRenderers starts the shared headless browser pool and registers every rendering backend;
+// layouts may pick one, otherwise cfg.RendererBackend is used.
+func newRenderers(cfg *config.Config, log logger.Logger) (*browser.Pool, *renderer.Registry) {
+	browserPool := browser.NewPool(browser.Config{
+		Size:                cfg.BrowserPoolSize,
+		MaxUsesPerTab:       cfg.BrowserTabMaxUses,
+		HealthCheckInterval: cfg.BrowserHealthCheckInterval,
+		ExecPath:            cfg.ChromePath,
+	}, log)
+	policy := networkPolicy(cfg, log)
+	renderers := renderer.NewRegistry(cfg.RendererBackend)
+	renderers.Register(renderer.NewChromeRenderer(browserPool, policy))
+	renderers.Register(renderer.NewWkhtmltopdfRenderer(cfg.WkhtmltopdfPath, policy))
+	return browserPool, renderers
+}
+
+// networkPolicy limits what layouts can load while rendering, so markup that reaches a
+// template cannot make the server fetch internal URLs. "*" turns the restriction off.
+func networkPolicy(cfg *config.Config, log logger.Logger) *renderer.NetworkPolicy {
+	for _, entry := range cfg.RenderNetworkAllowlist {
+		if entry == "*" {
+			log.Warn("RENDER_NETWORK_ALLOWLIST is '*', layouts may load any URL while rendering")
+			return nil
+		}
+	}
+	return renderer.NewNetworkPolicy(cfg.RenderNetworkAllowlist)
+}
+
+func storageConfig(cfg *config.Config) storage.Config {
+	return storage.Config{
+		Driver:      cfg.StorageDriver,
+		LocalRoot:   cfg.StorageLocalRoot,
+		S3Endpoint:  cfg.S3Endpoint,
+		S3Region:    cfg.S3Region,
+		S3Bucket:    cfg.S3Bucket,
+		S3AccessKey: cfg.S3AccessKey,
+		S3SecretKey: cfg.S3SecretKey,
+		S3UseSSL:    cfg.S3UseSSL,
 	}
 }
 
+// downloadConfig builds the signer for download links. Without DOWNLOAD_SIGNING_KEYS a key is
+// derived from JWT_SECRET so links still cannot be forged, but it cannot be rotated on its own.
+func downloadConfig(cfg *config.Config, log logger.Logger) (postersServices.DownloadConfig, error) {
+	keys, activeKID, err := urlsign.ParseKeys(cfg.DownloadSigningKeys)
+	if err != nil {
+		return postersServices.DownloadConfig{}, fmt.Errorf("invalid DOWNLOAD_SIGNING_KEYS: %w", err)
+	}
+	if len(keys) == 0 {
+		log.Warn("DOWNLOAD_SIGNING_KEYS not set, deriving the download signing key from JWT_SECRET")
+		mac := hmac.New(sha256.New, []byte(cfg.JWTSecret))
+		mac.Write([]byte("poster-download-links"))
+		keys, activeKID = map[string][]byte{"jwt": mac.Sum(nil)}, "jwt"
+	}
+	if cfg.DownloadSigningActiveKey != "" {
+		activeKID = cfg.DownloadSigningActiveKey
+	}
+	signer, err := urlsign.NewSigner(keys, activeKID)
+	if err != nil {
+		return postersServices.DownloadConfig{}, fmt.Errorf("invalid download signing configuration: %w", err)
+	}
+	return postersServices.DownloadConfig{Signer: signer, BaseURL: cfg.DownloadBaseURL, TTL: cfg.DownloadURLTTL}, nil
+}
+
+func renderQueueConfig(cfg *config.Config) postersServices.RenderQueueConfig {
+	return postersServices.RenderQueueConfig{
+		Driver:      cfg.RenderQueueDriver,
+		Workers:     cfg.RenderWorkers,
+		QueueSize:   cfg.RenderQueueSize,
+		MaxAttempts: cfg.RenderJobMaxAttempts,
+	}
+}
+
+func renderCacheConfig(cfg *config.Config) postersServices.RenderCacheConfig {
+	return postersServices.RenderCacheConfig{
+		TTL:        cfg.RenderCacheTTL,
+		MaxEntries: cfg.RenderCacheMaxEntries,
+	}
+}
+
+func scanLinkConfig(cfg *config.Config, log logger.Logger) postersServices.ScanLinkConfig {
+	if cfg.ScanLinkBaseURL == "" {
+		log.Info("SCAN_LINK_BASE_URL not set, tracked QR codes point straight at their target")
+	}
+	return postersServices.ScanLinkConfig{BaseURL: cfg.ScanLinkBaseURL}
+}
+
+// Shutdown drains queued renders, then releases the headless browser.
+func (m *Module) Shutdown(ctx context.Context) error {
+	m.log.Info("Shutting down Posters module...")
+	err := m.runtime.Close(ctx)
+	if err != nil {
+		m.log.Error("Render queue did not drain before shutdown deadline", err)
+	}
+	return err
+}
+
 // RegisterRoutes registers the routes for the Posters module using the generic router interface.
 func (m *Module) RegisterRoutes(r router.Router) {
 	m.log.Info("Registering Posters module routes...")
 
 	r.Group(func(r router.Router) {
 		r.Get("/posters/templates", m.Handler.GetActiveTemplates)
+		r.Get("/posters/templates/{id}/schema", m.Handler.GetTemplateSchema) // JSON Schema of the poster input, used to build forms
 		r.Post("/posters/generate", m.Handler.GeneratePoster)
-		r.Get("/posters/{id}", m.Handler.GetPosterByID) // Get generated poster details
+		r.Post("/posters/preview", m.Handler.PreviewPoster)        // HTML or low-res image, nothing is saved
+		r.Get("/posters/{id}", m.Handler.GetPosterByID)            // Get generated poster details; needs ?token=<access_token>
+		r.Get("/posters/{id}/scans", m.Handler.GetPosterScanStats) // How often tracked QR codes were scanned; needs ?token=
 
 		r.Get("/logos", m.Handler.GetLogos)
 	})
@@ -54,16 +204,46 @@ func (m *Module) RegisterRoutes(r router.Router) {
 		r.Get("/posters/templates/{id}", m.Handler.GetTemplateByID)
 		r.Patch("/posters/templates/{id}", m.Handler.UpdateTemplate) // Use Patch for partial updates if applicable
 		r.Delete("/posters/templates/{id}", m.Handler.DeleteTemplate)
+		// Every template change is an immutable revision; posters record the one they were rendered with
+		r.Get("/posters/templates/{id}/revisions", m.Handler.ListTemplateRevisions)
+		r.Get("/posters/templates/{id}/revisions/diff", m.Handler.DiffTemplateRevisions) // ?from=1&to=3, to defaults to current
+		r.Post("/posters/templates/{id}/revisions/{revision}/rollback", m.Handler.RollbackTemplate)
+		r.Post("/posters/{id}/regenerate", m.Handler.RegeneratePoster) // Uses the original revisions unless the body picks others
+
+		// Admins can force a fresh render that skips the render cache
+		r.Group(func(r router.Router) {
+			r.Use(middleware.Authorizer("admin"))
+			r.Post("/posters/generate/uncached", m.Handler.GeneratePosterUncached)
+		})
 
 
-		// Routes for managing Layouts (HTML structures) could go here
+		// Layouts are uploaded as JSON or multipart HTML, or name a file in templates/
 		r.Post("/layouts", m.Handler.CreateLayout)
 		r.Get("/layouts", m.Handler.ListLayouts)
+		r.Put("/layouts/{id}", m.Handler.UpdateLayout)
+		r.Delete("/layouts/{id}", m.Handler.DeleteLayout) // Refused while templates use the layout
+		r.Get("/layouts/{id}/download", m.Handler.DownloadLayout)
+		r.Get("/layouts/{id}/revisions", m.Handler.ListLayoutRevisions)
+		r.Get("/layouts/{id}/revisions/diff", m.Handler.DiffLayoutRevisions)
+		r.Post("/layouts/{id}/revisions/{revision}/rollback", m.Handler.RollbackLayout)
 		
 		r.Post("/assets", m.Handler.CreateAsset)
 		r.Get("/assets", m.Handler.ListAssets)
 
+		// Fonts are inlined into layouts as @font-face rules, see font_family_name
+		r.Post("/assets/fonts", m.Handler.UploadFont)
+		r.Get("/assets/fonts", m.Handler.ListFonts)
+		r.Delete("/assets/fonts/{id}", m.Handler.DeleteFont)
+
 	})
 
 	m.log.Info("Posters module routes registered.")
 }
+
+// RegisterFileRoutes serves rendered posters from storage and follows the short links of tracked
+// QR codes. It is mounted outside /api so download URLs and printed links stay short, and download
+// paths match the ones the local storage driver hands out.
+func (m *Module) RegisterFileRoutes(r router.Router) {
+	r.Get("/posters/*", m.Handler.ServePosterFile)
+	r.Get("/s/{code}", m.Handler.FollowShortLink)
+}
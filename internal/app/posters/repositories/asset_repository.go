@@ -13,6 +13,9 @@ type AssetRepository interface {
 	ListAllAssets(ctx context.Context) ([]*models.Asset, error)
 	GetAssetByID(ctx context.Context, id uint) (*models.Asset, error)
 	GetAssetsByType(ctx context.Context, assetType string) ([]*models.Asset, error)
+	// GetFontsByFamily returns every font asset of a family, matched case-insensitively.
+	GetFontsByFamily(ctx context.Context, family string) ([]*models.Asset, error)
+	DeleteAsset(ctx context.Context, id uint) error
 
 }
 
@@ -57,3 +60,20 @@ func (r *assetRepository) GetAssetsByType(ctx context.Context, assetType string)
 	}
 	return assets, nil
 }
+
+func (r *assetRepository) GetFontsByFamily(ctx context.Context, family string) ([]*models.Asset, error) {
+	var assets []*models.Asset
+	if err := r.db.WithContext(ctx).Where("type = ? AND LOWER(font_family) = LOWER(?)", models.AssetTypeFont, family).Order("font_weight, font_style").Find(&assets).Error; err != nil {
+		r.log.Error("Failed to get fonts by family", err, "font_family", family)
+		return nil, err
+	}
+	return assets, nil
+}
+
+func (r *assetRepository) DeleteAsset(ctx context.Context, id uint) error {
+	if err := r.db.WithContext(ctx).Delete(&models.Asset{}, id).Error; err != nil {
+		r.log.Error("Failed to delete asset", err, "asset_id", id)
+		return err
+	}
+	return nil
+}
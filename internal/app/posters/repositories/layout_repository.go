@@ -5,15 +5,21 @@ import (
 	"github.com/codetheuri/poster-gen/internal/app/posters/models"
 	"github.com/codetheuri/poster-gen/pkg/logger"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 
 type LayoutRepository interface {
-		CreateLayout(ctx context.Context, layout *models.Layout) error 
+	// CreateLayout saves the layout together with its first revision.
+	CreateLayout(ctx context.Context, layout *models.Layout) error
 	ListLayouts(ctx context.Context) ([]*models.Layout, error) 
 	GetLayoutByID(ctx context.Context, id uint) (*models.Layout, error)
 	GetLayoutByName(ctx context.Context, name string) (*models.Layout, error)
-	
+	// UpdateLayout saves the layout and records it as a new revision.
+	UpdateLayout(ctx context.Context, layout *models.Layout) error
+	DeleteLayout(ctx context.Context, id uint) error
+	ListRevisions(ctx context.Context, layoutID uint) ([]*models.LayoutRevision, error)
+	GetRevision(ctx context.Context, layoutID uint, revision int) (*models.LayoutRevision, error)
 }
 
 type layoutRepository struct {
@@ -25,7 +31,14 @@ func NewLayoutRepository(db *gorm.DB, log logger.Logger) LayoutRepository {
 	return &layoutRepository{db: db, log: log}
 }
 func (r *layoutRepository) CreateLayout(ctx context.Context, layout *models.Layout) error {
-	if err := r.db.WithContext(ctx).Create(layout).Error; err != nil {
+	layout.Revision = 1
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(layout).Error; err != nil {
+			return err
+		}
+		return tx.Create(newLayoutRevision(layout)).Error
+	})
+	if err != nil {
 		r.log.Error("Failed to create layout", err, "layout_name", layout.Name)
 		return err // Let service layer wrap the error
 	}
@@ -58,3 +71,58 @@ func (r *layoutRepository) GetLayoutByName(ctx context.Context, name string) (*m
 	}
 	return &layout, nil
 }
+
+func (r *layoutRepository) UpdateLayout(ctx context.Context, layout *models.Layout) error {
+	layout.Revision++
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Omit associations so saving never touches the templates using the layout.
+		if err := tx.Omit(clause.Associations).Save(layout).Error; err != nil {
+			return err
+		}
+		return tx.Create(newLayoutRevision(layout)).Error
+	})
+	if err != nil {
+		layout.Revision--
+		r.log.Error("Failed to update layout", err, "layout_id", layout.ID)
+		return err
+	}
+	return nil
+}
+
+func (r *layoutRepository) DeleteLayout(ctx context.Context, id uint) error {
+	if err := r.db.WithContext(ctx).Delete(&models.Layout{}, id).Error; err != nil {
+		r.log.Error("Failed to delete layout", err, "layout_id", id)
+		return err
+	}
+	return nil
+}
+
+func (r *layoutRepository) ListRevisions(ctx context.Context, layoutID uint) ([]*models.LayoutRevision, error) {
+	var revisions []*models.LayoutRevision
+	if err := r.db.WithContext(ctx).Where("layout_id = ?", layoutID).Order("revision DESC").Find(&revisions).Error; err != nil {
+		r.log.Error("Failed to list layout revisions", err, "layout_id", layoutID)
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (r *layoutRepository) GetRevision(ctx context.Context, layoutID uint, revision int) (*models.LayoutRevision, error) {
+	var rev models.LayoutRevision
+	if err := r.db.WithContext(ctx).Where("layout_id = ? AND revision = ?", layoutID, revision).First(&rev).Error; err != nil {
+		r.log.Error("Failed to get layout revision", err, "layout_id", layoutID, "revision", revision)
+		return nil, err
+	}
+	return &rev, nil
+}
+
+// newLayoutRevision snapshots the current state of a layout.
+func newLayoutRevision(layout *models.Layout) *models.LayoutRevision {
+	return &models.LayoutRevision{
+		LayoutID: layout.ID,
+		Revision: layout.Revision,
+		Name:     layout.Name,
+		FilePath: layout.FilePath,
+		Content:  layout.Content,
+		Renderer: layout.Renderer,
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/codetheuri/poster-gen/internal/app/posters/models"
 	"github.com/codetheuri/poster-gen/pkg/logger"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // PosterRepository defines the interface for poster data operations.
@@ -13,6 +14,8 @@ import (
 type PosterSubRepository interface {
 	CreatePoster(ctx context.Context, poster *models.Poster) error
 	GetPosterByID(ctx context.Context, id uint) (*models.Poster, error)
+	UpdatePoster(ctx context.Context, poster *models.Poster) error
+	ListPostersWithFiles(ctx context.Context) ([]*models.Poster, error)
 	// Add other methods as needed (Update, Delete, ListByUser, etc.)
 }
 
@@ -45,4 +48,23 @@ func (r *posterRepository) GetPosterByID(ctx context.Context, id uint) (*models.
 	return &poster, nil
 }
 
-// Add UpdatePoster, DeletePoster implementations if needed
+func (r *posterRepository) UpdatePoster(ctx context.Context, poster *models.Poster) error {
+	// Omit associations so a preloaded template is never written back
+	if err := r.db.WithContext(ctx).Omit(clause.Associations).Save(poster).Error; err != nil {
+		r.log.Error("Failed to update poster", err, "poster_id", poster.ID)
+		return err
+	}
+	return nil
+}
+
+// ListPostersWithFiles returns every poster that has a rendered file, oldest first.
+func (r *posterRepository) ListPostersWithFiles(ctx context.Context) ([]*models.Poster, error) {
+	var posters []*models.Poster
+	if err := r.db.WithContext(ctx).Where("pdf_url <> ''").Order("id").Find(&posters).Error; err != nil {
+		r.log.Error("Failed to list posters with files", err)
+		return nil, err
+	}
+	return posters, nil
+}
+
+// Add DeletePoster implementation if needed
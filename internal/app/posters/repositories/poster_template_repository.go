@@ -5,15 +5,25 @@ import (
 	"github.com/codetheuri/poster-gen/internal/app/posters/models"
 	"github.com/codetheuri/poster-gen/pkg/logger"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 
 type PosterTemplateRepository interface {
+	// CreateTemplate saves the template together with its first revision.
 	CreateTemplate(ctx context.Context, template *models.PosterTemplate) error
 	GetTemplateByID(ctx context.Context, id uint) (*models.PosterTemplate, error)
 	GetActiveTemplates(ctx context.Context) ([]*models.PosterTemplate, error)
+	ListTemplates(ctx context.Context) ([]*models.PosterTemplate, error)
+	// ListTemplatesByLayout returns the templates rendered with the given layout.
+	ListTemplatesByLayout(ctx context.Context, layoutID uint) ([]*models.PosterTemplate, error)
+	// UpdateTemplate saves the template and records it as a new revision.
 	UpdateTemplate(ctx context.Context, template *models.PosterTemplate) error
 	DeleteTemplate(ctx context.Context, id uint) error
+	// UpdateThumbnailKey points the template at a newly generated thumbnail.
+	UpdateThumbnailKey(ctx context.Context, id uint, key string) error
+	ListRevisions(ctx context.Context, templateID uint) ([]*models.PosterTemplateRevision, error)
+	GetRevision(ctx context.Context, templateID uint, revision int) (*models.PosterTemplateRevision, error)
 	// Add GetTemplateByName if needed
 }
 
@@ -27,7 +37,14 @@ func NewPosterTemplateRepository(db *gorm.DB, log logger.Logger) PosterTemplateR
 }
 
 func (r *posterTemplateRepository) CreateTemplate(ctx context.Context, template *models.PosterTemplate) error {
-	if err := r.db.WithContext(ctx).Create(template).Error; err != nil {
+	template.Revision = 1
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(template).Error; err != nil {
+			return err
+		}
+		return tx.Create(newTemplateRevision(template)).Error
+	})
+	if err != nil {
 		r.log.Error("Failed to create template", err, "template_name", template.Name)
 		return err
 	}
@@ -54,14 +71,78 @@ func (r *posterTemplateRepository) GetActiveTemplates(ctx context.Context) ([]*m
 	return templates, nil
 }
 
+func (r *posterTemplateRepository) ListTemplates(ctx context.Context) ([]*models.PosterTemplate, error) {
+	var templates []*models.PosterTemplate
+	if err := r.db.WithContext(ctx).Preload("Layout").Order("id").Find(&templates).Error; err != nil {
+		r.log.Error("Failed to list templates", err)
+		return nil, err
+	}
+	return templates, nil
+}
+
+func (r *posterTemplateRepository) ListTemplatesByLayout(ctx context.Context, layoutID uint) ([]*models.PosterTemplate, error) {
+	var templates []*models.PosterTemplate
+	if err := r.db.WithContext(ctx).Where("layout_id = ?", layoutID).Order("id").Find(&templates).Error; err != nil {
+		r.log.Error("Failed to list templates by layout", err, "layout_id", layoutID)
+		return nil, err
+	}
+	return templates, nil
+}
+
 func (r *posterTemplateRepository) UpdateTemplate(ctx context.Context, template *models.PosterTemplate) error {
-	if err := r.db.WithContext(ctx).Save(template).Error; err != nil {
+	template.Revision++
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Omit associations: the preloaded Layout would otherwise overwrite a changed LayoutID.
+		if err := tx.Omit(clause.Associations).Save(template).Error; err != nil {
+			return err
+		}
+		// The unique (template, revision) index turns a concurrent update into an error instead of a lost revision.
+		return tx.Create(newTemplateRevision(template)).Error
+	})
+	if err != nil {
+		template.Revision--
 		r.log.Error("Failed to update template", err, "template_id", template.ID)
 		return err
 	}
 	return nil
 }
 
+func (r *posterTemplateRepository) ListRevisions(ctx context.Context, templateID uint) ([]*models.PosterTemplateRevision, error) {
+	var revisions []*models.PosterTemplateRevision
+	if err := r.db.WithContext(ctx).Where("poster_template_id = ?", templateID).Order("revision DESC").Find(&revisions).Error; err != nil {
+		r.log.Error("Failed to list template revisions", err, "template_id", templateID)
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (r *posterTemplateRepository) GetRevision(ctx context.Context, templateID uint, revision int) (*models.PosterTemplateRevision, error) {
+	var rev models.PosterTemplateRevision
+	if err := r.db.WithContext(ctx).Where("poster_template_id = ? AND revision = ?", templateID, revision).First(&rev).Error; err != nil {
+		r.log.Error("Failed to get template revision", err, "template_id", templateID, "revision", revision)
+		return nil, err
+	}
+	return &rev, nil
+}
+
+// newTemplateRevision snapshots the current state of a template.
+func newTemplateRevision(template *models.PosterTemplate) *models.PosterTemplateRevision {
+	return &models.PosterTemplateRevision{
+		PosterTemplateID:     template.ID,
+		Revision:             template.Revision,
+		Name:                 template.Name,
+		Type:                 template.Type,
+		LayoutID:             template.LayoutID,
+		Price:                template.Price,
+		ThumbnailURL:         template.ThumbnailURL,
+		IsActive:             template.IsActive,
+		RequiredFields:       template.RequiredFields,
+		DefaultCustomization: template.DefaultCustomization,
+		SampleData:           template.SampleData,
+		CustomizationOptions: template.CustomizationOptions,
+	}
+}
+
 func (r *posterTemplateRepository) DeleteTemplate(ctx context.Context, id uint) error {
 	if err := r.db.WithContext(ctx).Delete(&models.PosterTemplate{}, id).Error; err != nil {
 		r.log.Error("Failed to delete template", err, "template_id", id)
@@ -69,3 +150,11 @@ func (r *posterTemplateRepository) DeleteTemplate(ctx context.Context, id uint)
 	}
 	return nil
 }
+
+func (r *posterTemplateRepository) UpdateThumbnailKey(ctx context.Context, id uint, key string) error {
+	if err := r.db.WithContext(ctx).Model(&models.PosterTemplate{}).Where("id = ?", id).Update("thumbnail_key", key).Error; err != nil {
+		r.log.Error("Failed to update template thumbnail", err, "template_id", id)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/codetheuri/poster-gen/internal/app/posters/models"
+	"github.com/codetheuri/poster-gen/pkg/logger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RenderCacheRepository stores render fingerprints and the files rendered for them.
+type RenderCacheRepository interface {
+	// GetFreshEntry returns the unexpired entry for cacheKey, or gorm.ErrRecordNotFound.
+	GetFreshEntry(ctx context.Context, cacheKey string, now time.Time) (*models.RenderCacheEntry, error)
+	RecordHit(ctx context.Context, entry *models.RenderCacheEntry, now time.Time) error
+	// PutEntry inserts the entry or replaces the file an existing fingerprint points to.
+	PutEntry(ctx context.Context, entry *models.RenderCacheEntry) error
+	// Evict removes expired entries and, beyond maxEntries, the least recently used ones.
+	// Only cache rows are removed; files stay because posters still reference them.
+	Evict(ctx context.Context, now time.Time, maxEntries int) (int64, error)
+}
+
+type renderCacheRepository struct {
+	db  *gorm.DB
+	log logger.Logger
+}
+
+func NewRenderCacheRepository(db *gorm.DB, log logger.Logger) RenderCacheRepository {
+	return &renderCacheRepository{db: db, log: log}
+}
+
+func (r *renderCacheRepository) GetFreshEntry(ctx context.Context, cacheKey string, now time.Time) (*models.RenderCacheEntry, error) {
+	var entries []models.RenderCacheEntry
+	// Find rather than First: a miss is the common case and not worth an error log line.
+	if err := r.db.WithContext(ctx).Where("cache_key = ? AND expires_at > ?", cacheKey, now).Limit(1).Find(&entries).Error; err != nil {
+		r.log.Error("Failed to look up render cache entry", err, "cache_key", cacheKey)
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &entries[0], nil
+}
+
+func (r *renderCacheRepository) RecordHit(ctx context.Context, entry *models.RenderCacheEntry, now time.Time) error {
+	err := r.db.WithContext(ctx).Model(&models.RenderCacheEntry{}).Where("id = ?", entry.ID).
+		Updates(map[string]interface{}{
+			"hit_count":    gorm.Expr("hit_count + 1"),
+			"last_used_at": now,
+		}).Error
+	if err != nil {
+		r.log.Error("Failed to record render cache hit", err, "cache_key", entry.CacheKey)
+	}
+	return err
+}
+
+func (r *renderCacheRepository) PutEntry(ctx context.Context, entry *models.RenderCacheEntry) error {
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "cache_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"storage_key", "output_format", "last_used_at", "expires_at", "updated_at"}),
+	}).Create(entry).Error
+	if err != nil {
+		r.log.Error("Failed to store render cache entry", err, "cache_key", entry.CacheKey)
+	}
+	return err
+}
+
+func (r *renderCacheRepository) Evict(ctx context.Context, now time.Time, maxEntries int) (int64, error) {
+	db := r.db.WithContext(ctx)
+	expired := db.Where("expires_at <= ?", now).Delete(&models.RenderCacheEntry{})
+	if expired.Error != nil {
+		r.log.Error("Failed to evict expired render cache entries", expired.Error)
+		return 0, expired.Error
+	}
+	evicted := expired.RowsAffected
+	if maxEntries <= 0 {
+		return evicted, nil
+	}
+
+	var count int64
+	if err := db.Model(&models.RenderCacheEntry{}).Count(&count).Error; err != nil {
+		return evicted, err
+	}
+	if over := int(count) - maxEntries; over > 0 {
+		var ids []uint
+		if err := db.Model(&models.RenderCacheEntry{}).Order("last_used_at").Limit(over).Pluck("id", &ids).Error; err != nil {
+			return evicted, err
+		}
+		lru := db.Where("id IN ?", ids).Delete(&models.RenderCacheEntry{})
+		if lru.Error != nil {
+			r.log.Error("Failed to evict least recently used render cache entries", lru.Error)
+			return evicted, lru.Error
+		}
+		evicted += lru.RowsAffected
+	}
+	return evicted, nil
+}
@@ -0,0 +1,151 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/codetheuri/poster-gen/internal/app/posters/models"
+	"github.com/codetheuri/poster-gen/pkg/logger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RenderJobRepository stores the durable render queue.
+type RenderJobRepository interface {
+	CreateJob(ctx context.Context, job *models.RenderJob) error
+	// ClaimJob locks the next due job for workerID until now+visibility. It returns nil, nil when nothing is due.
+	ClaimJob(ctx context.Context, workerID string, visibility time.Duration) (*models.RenderJob, error)
+	// ExtendJob pushes the visibility timeout of a job the caller still holds to lockedUntil.
+	// It returns false when the claim was lost to another worker.
+	ExtendJob(ctx context.Context, job *models.RenderJob, lockedUntil time.Time) (bool, error)
+	CompleteJob(ctx context.Context, job *models.RenderJob) error
+	RetryJob(ctx context.Context, job *models.RenderJob, runAt time.Time, lastError string) error
+	KillJob(ctx context.Context, job *models.RenderJob, lastError string) error
+}
+
+type renderJobRepository struct {
+	db  *gorm.DB
+	log logger.Logger
+}
+
+func NewRenderJobRepository(db *gorm.DB, log logger.Logger) RenderJobRepository {
+	return &renderJobRepository{db: db, log: log}
+}
+
+func (r *renderJobRepository) CreateJob(ctx context.Context, job *models.RenderJob) error {
+	if err := r.db.WithContext(ctx).Create(job).Error; err != nil {
+		r.log.Error("Failed to create render job", err, "poster_id", job.PosterID)
+		return err
+	}
+	return nil
+}
+
+func (r *renderJobRepository) ClaimJob(ctx context.Context, workerID string, visibility time.Duration) (*models.RenderJob, error) {
+	var claimed *models.RenderJob
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		query := tx.Where("(status = ? AND run_at <= ?) OR (status = ? AND locked_until < ?)",
+			models.RenderJobQueued, now, models.RenderJobRunning, now).
+			Order("run_at").
+			Limit(1)
+		// Postgres and MySQL 8 let concurrent workers skip rows another worker is claiming.
+		// SQLite has no row locks; it serialises writers, and the guarded update below
+		// makes a lost race return no job instead of a double claim.
+		if name := tx.Dialector.Name(); name == "postgres" || name == "mysql" {
+			query = query.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+		}
+
+		// Find rather than Take: an empty queue is the normal idle case, not an error worth logging.
+		var jobs []models.RenderJob
+		if err := query.Find(&jobs).Error; err != nil {
+			return err
+		}
+		if len(jobs) == 0 {
+			return nil
+		}
+		job := jobs[0]
+
+		lockedUntil := now.Add(visibility)
+		result := tx.Model(&models.RenderJob{}).
+			Where("id = ? AND status = ? AND attempts = ?", job.ID, job.Status, job.Attempts).
+			Updates(map[string]interface{}{
+				"status":       models.RenderJobRunning,
+				"attempts":     job.Attempts + 1,
+				"locked_by":    workerID,
+				"locked_until": lockedUntil,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil // claimed by someone else in the meantime
+		}
+
+		job.Status = models.RenderJobRunning
+		job.Attempts++
+		job.LockedBy = workerID
+		job.LockedUntil = &lockedUntil
+		claimed = &job
+		return nil
+	})
+	if err != nil {
+		r.log.Error("Failed to claim render job", err, "worker_id", workerID)
+		return nil, err
+	}
+	return claimed, nil
+}
+
+func (r *renderJobRepository) ExtendJob(ctx context.Context, job *models.RenderJob, lockedUntil time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.RenderJob{}).
+		Where("id = ? AND status = ? AND locked_by = ? AND attempts = ?", job.ID, models.RenderJobRunning, job.LockedBy, job.Attempts).
+		Update("locked_until", lockedUntil)
+	if result.Error != nil {
+		r.log.Error("Failed to extend render job claim", result.Error, "job_id", job.ID)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *renderJobRepository) CompleteJob(ctx context.Context, job *models.RenderJob) error {
+	return r.finish(ctx, job, map[string]interface{}{
+		"status":       models.RenderJobCompleted,
+		"locked_by":    "",
+		"locked_until": nil,
+		"last_error":   "",
+	})
+}
+
+func (r *renderJobRepository) RetryJob(ctx context.Context, job *models.RenderJob, runAt time.Time, lastError string) error {
+	return r.finish(ctx, job, map[string]interface{}{
+		"status":       models.RenderJobQueued,
+		"run_at":       runAt,
+		"locked_by":    "",
+		"locked_until": nil,
+		"last_error":   lastError,
+	})
+}
+
+func (r *renderJobRepository) KillJob(ctx context.Context, job *models.RenderJob, lastError string) error {
+	return r.finish(ctx, job, map[string]interface{}{
+		"status":       models.RenderJobDead,
+		"locked_by":    "",
+		"locked_until": nil,
+		"last_error":   lastError,
+	})
+}
+
+// finish applies updates only while the caller still holds the claim, so a worker whose
+// visibility timeout expired cannot overwrite the outcome of the worker that took over.
+func (r *renderJobRepository) finish(ctx context.Context, job *models.RenderJob, updates map[string]interface{}) error {
+	result := r.db.WithContext(ctx).Model(&models.RenderJob{}).
+		Where("id = ? AND locked_by = ? AND attempts = ?", job.ID, job.LockedBy, job.Attempts).
+		Updates(updates)
+	if result.Error != nil {
+		r.log.Error("Failed to update render job", result.Error, "job_id", job.ID)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		r.log.Warn("Render job was reclaimed by another worker before it finished", "job_id", job.ID, "worker_id", job.LockedBy)
+	}
+	return nil
+}
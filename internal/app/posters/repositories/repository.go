@@ -11,6 +11,9 @@ type PosterRepository struct {
 	PosterTemplateRepo PosterTemplateRepository
 	AssetRepo          AssetRepository
 	PosterRepo         PosterSubRepository
+	RenderJobRepo      RenderJobRepository
+	RenderCacheRepo    RenderCacheRepository
+	ShortLinkRepo      ShortLinkRepository
 	// OrderRepo       OrderSubRepository // Keep commented if Order model is optional
 }
 
@@ -21,6 +24,9 @@ func NewPosterRepository(db *gorm.DB, log logger.Logger) *PosterRepository {
 		PosterTemplateRepo: NewPosterTemplateRepository(db, log),
 		AssetRepo:          NewAssetRepository(db, log),
 		PosterRepo:         NewPosterSubRepository(db, log),
+		RenderJobRepo:      NewRenderJobRepository(db, log),
+		RenderCacheRepo:    NewRenderCacheRepository(db, log),
+		ShortLinkRepo:      NewShortLinkRepository(db, log),
 		// OrderRepo:       NewOrderSubRepository(db, log), // Keep commented if Order model is optional
 	}
 }
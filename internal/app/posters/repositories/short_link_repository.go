@@ -0,0 +1,139 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/codetheuri/poster-gen/internal/app/posters/models"
+	"github.com/codetheuri/poster-gen/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// ShortLinkRepository stores the short links of tracked QR codes and their scans.
+type ShortLinkRepository interface {
+	// GetPosterLink returns the link made for a poster's qr field, or gorm.ErrRecordNotFound.
+	GetPosterLink(ctx context.Context, posterID uint, field string) (*models.ShortLink, error)
+	CreateShortLink(ctx context.Context, link *models.ShortLink) error
+	GetShortLinkByCode(ctx context.Context, code string) (*models.ShortLink, error)
+	ListPosterLinks(ctx context.Context, posterID uint) ([]*models.ShortLink, error)
+	RecordScan(ctx context.Context, event *models.ScanEvent) error
+	// ScanCountsByLink returns the number of scans per short link ID for a poster.
+	ScanCountsByLink(ctx context.Context, posterID uint) (map[uint]int64, error)
+	// ScanTimes returns when a poster was scanned since the given time, oldest first.
+	ScanTimes(ctx context.Context, posterID uint, since time.Time) ([]time.Time, error)
+	// ScanRange returns the first and last scan of a poster; both are nil when it was never scanned.
+	ScanRange(ctx context.Context, posterID uint) (first, last *time.Time, err error)
+}
+
+type shortLinkRepository struct {
+	db  *gorm.DB
+	log logger.Logger
+}
+
+func NewShortLinkRepository(db *gorm.DB, log logger.Logger) ShortLinkRepository {
+	return &shortLinkRepository{db: db, log: log}
+}
+
+func (r *shortLinkRepository) GetPosterLink(ctx context.Context, posterID uint, field string) (*models.ShortLink, error) {
+	var links []models.ShortLink
+	if err := r.db.WithContext(ctx).Where("poster_id = ? AND field = ?", posterID, field).Limit(1).Find(&links).Error; err != nil {
+		r.log.Error("Failed to look up poster short link", err, "poster_id", posterID, "field", field)
+		return nil, err
+	}
+	if len(links) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &links[0], nil
+}
+
+func (r *shortLinkRepository) CreateShortLink(ctx context.Context, link *models.ShortLink) error {
+	if err := r.db.WithContext(ctx).Create(link).Error; err != nil {
+		r.log.Error("Failed to create short link", err, "poster_id", link.PosterID, "field", link.Field)
+		return err
+	}
+	return nil
+}
+
+func (r *shortLinkRepository) GetShortLinkByCode(ctx context.Context, code string) (*models.ShortLink, error) {
+	var link models.ShortLink
+	if err := r.db.WithContext(ctx).Where("code = ?", code).First(&link).Error; err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (r *shortLinkRepository) ListPosterLinks(ctx context.Context, posterID uint) ([]*models.ShortLink, error) {
+	var links []*models.ShortLink
+	if err := r.db.WithContext(ctx).Where("poster_id = ?", posterID).Order("id").Find(&links).Error; err != nil {
+		r.log.Error("Failed to list poster short links", err, "poster_id", posterID)
+		return nil, err
+	}
+	return links, nil
+}
+
+func (r *shortLinkRepository) RecordScan(ctx context.Context, event *models.ScanEvent) error {
+	if err := r.db.WithContext(ctx).Create(event).Error; err != nil {
+		r.log.Error("Failed to record scan", err, "short_link_id", event.ShortLinkID)
+		return err
+	}
+	return nil
+}
+
+func (r *shortLinkRepository) ScanCountsByLink(ctx context.Context, posterID uint) (map[uint]int64, error) {
+	var rows []struct {
+		ShortLinkID uint
+		Scans       int64
+	}
+	err := r.db.WithContext(ctx).Model(&models.ScanEvent{}).
+		Select("short_link_id, COUNT(*) AS scans").
+		Where("poster_id = ?", posterID).
+		Group("short_link_id").
+		Scan(&rows).Error
+	if err != nil {
+		r.log.Error("Failed to count poster scans", err, "poster_id", posterID)
+		return nil, err
+	}
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.ShortLinkID] = row.Scans
+	}
+	return counts, nil
+}
+
+func (r *shortLinkRepository) ScanTimes(ctx context.Context, posterID uint, since time.Time) ([]time.Time, error) {
+	var times []time.Time
+	err := r.db.WithContext(ctx).Model(&models.ScanEvent{}).
+		Where("poster_id = ? AND scanned_at >= ?", posterID, since).
+		Order("scanned_at").
+		Pluck("scanned_at", &times).Error
+	if err != nil {
+		r.log.Error("Failed to load poster scan times", err, "poster_id", posterID)
+		return nil, err
+	}
+	return times, nil
+}
+
+func (r *shortLinkRepository) ScanRange(ctx context.Context, posterID uint) (*time.Time, *time.Time, error) {
+	first, err := r.edgeScanTime(ctx, posterID, "scanned_at")
+	if err != nil || first == nil {
+		return nil, nil, err
+	}
+	last, err := r.edgeScanTime(ctx, posterID, "scanned_at DESC")
+	if err != nil {
+		return nil, nil, err
+	}
+	return first, last, nil
+}
+
+// edgeScanTime returns the time of the first scan of a poster in the given order, or nil without scans.
+func (r *shortLinkRepository) edgeScanTime(ctx context.Context, posterID uint, order string) (*time.Time, error) {
+	var events []models.ScanEvent
+	if err := r.db.WithContext(ctx).Where("poster_id = ?", posterID).Order(order).Limit(1).Find(&events).Error; err != nil {
+		r.log.Error("Failed to load poster scan range", err, "poster_id", posterID)
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, nil
+	}
+	return &events[0].ScannedAt, nil
+}
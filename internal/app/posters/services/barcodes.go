@@ -0,0 +1,119 @@
+package services
+
+import (
+	"fmt"
+	"html/template"
+	"strconv"
+
+	"github.com/codetheuri/poster-gen/pkg/barcode"
+)
+
+// FieldTypeBarcode marks a required field whose value is also rendered as a linear barcode.
+// The layout receives the barcode as inline SVG under "<name>_barcode".
+const FieldTypeBarcode = "barcode"
+
+// barcodeDataSuffix is appended to a barcode field's name to form the data key of its SVG.
+const barcodeDataSuffix = "_barcode"
+
+// BarcodeFieldConfig holds the drawing options of a barcode field. Every option is optional.
+type BarcodeFieldConfig struct {
+	Symbology   string  `json:"symbology,omitempty"` // code128 (default) or ean13
+	Height      int     `json:"height,omitempty"`
+	ModuleWidth float64 `json:"moduleWidth,omitempty"`
+	HideText    bool    `json:"hideText,omitempty"`
+	Color       string  `json:"color,omitempty"`
+	Background  string  `json:"background,omitempty"`
+}
+
+func (c *BarcodeFieldConfig) symbology() string {
+	if c == nil {
+		return ""
+	}
+	return c.Symbology
+}
+
+func (c *BarcodeFieldConfig) options() barcode.Options {
+	if c == nil {
+		return barcode.Options{}
+	}
+	return barcode.Options{
+		Height:      c.Height,
+		ModuleWidth: c.ModuleWidth,
+		HideText:    c.HideText,
+		Foreground:  c.Color,
+		Background:  c.Background,
+	}
+}
+
+// withBarcodes adds the SVG of every barcode field to data.
+func withBarcodes(fields []RequiredFieldConfig, data map[string]interface{}) error {
+	for _, field := range fields {
+		if field.Type != FieldTypeBarcode {
+			continue
+		}
+		value := fmt.Sprintf("%v", data[field.Name])
+		if data[field.Name] == nil || value == "" {
+			continue
+		}
+		svg, err := barcode.SVG(field.Barcode.symbology(), value, field.Barcode.options())
+		if err != nil {
+			return fmt.Errorf("failed to draw barcode for %s: %w", field.Name, err)
+		}
+		data[field.Name+barcodeDataSuffix] = template.HTML(svg)
+	}
+	return nil
+}
+
+// barcodeFunc backs the "barcode" layout function, which returns inline SVG:
+//
+//	{{barcode .sku}}
+//	{{barcode .ean "type" "ean13" "height" 60 "text" false}}
+//
+// Options come as name/value pairs: type (code128 or ean13), height, module (bar width in
+// pixels), text (show the value under the bars), color and background.
+func barcodeFunc(value interface{}, options ...interface{}) (template.HTML, error) {
+	if len(options)%2 != 0 {
+		return "", fmt.Errorf("barcode options must be name/value pairs")
+	}
+	cfg := &BarcodeFieldConfig{}
+	for i := 0; i < len(options); i += 2 {
+		name, ok := options[i].(string)
+		if !ok {
+			return "", fmt.Errorf("barcode option name %v is not a string", options[i])
+		}
+		text := fmt.Sprintf("%v", options[i+1])
+		switch name {
+		case "type":
+			cfg.Symbology = text
+		case "height":
+			height, err := strconv.Atoi(text)
+			if err != nil {
+				return "", fmt.Errorf("barcode height %v is not a number", text)
+			}
+			cfg.Height = height
+		case "module":
+			width, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return "", fmt.Errorf("barcode module width %v is not a number", text)
+			}
+			cfg.ModuleWidth = width
+		case "text":
+			show, err := strconv.ParseBool(text)
+			if err != nil {
+				return "", fmt.Errorf("barcode text %v is not true or false", text)
+			}
+			cfg.HideText = !show
+		case "color":
+			cfg.Color = text
+		case "background":
+			cfg.Background = text
+		default:
+			return "", fmt.Errorf("unknown barcode option %q", name)
+		}
+	}
+	svg, err := barcode.SVG(cfg.Symbology, fmt.Sprintf("%v", value), cfg.options())
+	if err != nil {
+		return "", err
+	}
+	return template.HTML(svg), nil
+}
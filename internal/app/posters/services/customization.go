@@ -0,0 +1,294 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/codetheuri/poster-gen/internal/app/posters/handlers/dto"
+	"github.com/codetheuri/poster-gen/internal/app/posters/models"
+	"github.com/codetheuri/poster-gen/pkg/errors"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Types of customization options. Overrides of other keys, or of a type's wrong form, are rejected
+// because customization values are written straight into layout CSS.
+const (
+	CustomizationColor = "color" // #rgb or #rrggbb, passed on as lower-case #rrggbb
+	CustomizationFont  = "font"  // one of the option's font families
+	CustomizationSize  = "size"  // a number within min and max, passed on with the option's unit, e.g. "18px"
+	CustomizationAsset = "asset" // the ID of an asset of the option's asset type
+)
+
+var colorRegexp = regexp.MustCompile(colorPattern)
+
+// sizeUnits are the CSS units a size option may use.
+var sizeUnits = map[string]bool{"px": true, "pt": true, "mm": true, "em": true, "rem": true}
+
+// CustomizationOption declares a customization key users may override when generating a poster.
+type CustomizationOption struct {
+	Key       string   `json:"key"`
+	Label     string   `json:"label,omitempty"`
+	Type      string   `json:"type"`
+	Fonts     []string `json:"fonts,omitempty"`      // font families a font option allows
+	Min       *float64 `json:"min,omitempty"`        // smallest size, in Unit
+	Max       *float64 `json:"max,omitempty"`        // largest size, in Unit
+	Unit      string   `json:"unit,omitempty"`       // CSS unit of a size; px by default
+	AssetType string   `json:"asset_type,omitempty"` // type of the asset an asset option refers to; logo by default
+}
+
+func (o CustomizationOption) label() string {
+	if o.Label != "" {
+		return o.Label
+	}
+	return o.Key
+}
+
+func (o CustomizationOption) unit() string {
+	if o.Unit == "" {
+		return "px"
+	}
+	return o.Unit
+}
+
+func (o CustomizationOption) assetType() string {
+	if o.AssetType == "" {
+		return "logo"
+	}
+	return o.AssetType
+}
+
+// decodeCustomizationOptions parses the customization options stored on a template. Templates
+// without options accept no overrides.
+func decodeCustomizationOptions(raw datatypes.JSON) ([]CustomizationOption, error) {
+	var options []CustomizationOption
+	if len(raw) == 0 || string(raw) == "null" {
+		return options, nil
+	}
+	if err := json.Unmarshal(raw, &options); err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+// Why reservedDataKeys keeps a key from poster data.
+const (
+	reservedForCustomization = "customization" // only customization_data may override it, within the template's options
+	reservedForService       = "service"       // the poster service sets it
+)
+
+// reservedDataKeys returns the keys poster data may not set unless the template declares them as
+// fields: those of its default customization and options, and those the service sets itself.
+// Call it once templateSchema has accepted the template.
+func reservedDataKeys(templateRecord *models.PosterTemplate) map[string]string {
+	reserved := map[string]string{}
+	for _, key := range append(append([]string{}, systemDataKeys...), consumedDataKeys...) {
+		reserved[key] = reservedForService
+	}
+	var defaults map[string]interface{}
+	_ = json.Unmarshal(templateRecord.DefaultCustomization, &defaults)
+	for key := range defaults {
+		reserved[key] = reservedForCustomization
+	}
+	options, _ := decodeCustomizationOptions(templateRecord.CustomizationOptions)
+	for _, option := range options {
+		reserved[option.Key] = reservedForCustomization
+	}
+	return reserved
+}
+
+// customizationSchema describes the overrides a template accepts: one property per option,
+// defaulting to the template's default customization, and nothing else.
+func customizationSchema(options []CustomizationOption, defaults map[string]interface{}) *dto.JSONSchema {
+	closed := false
+	schema := &dto.JSONSchema{Title: "Customization", Type: "object", Properties: dto.SchemaProperties{}, AdditionalProperties: &closed}
+	for _, option := range options {
+		property := optionSchema(option)
+		if value, ok := defaults[option.Key]; ok && !isBlankValue(value) {
+			if option.Type == CustomizationSize {
+				if size, ok := sizeValue(value, option.unit()); ok {
+					property.Default = size
+				}
+			} else {
+				property.Default = value
+			}
+		}
+		schema.Properties = append(schema.Properties, dto.SchemaProperty{Name: option.Key, Schema: property})
+	}
+	return schema
+}
+
+// optionSchema describes the values one customization option accepts.
+func optionSchema(option CustomizationOption) *dto.JSONSchema {
+	schema := &dto.JSONSchema{Title: option.label()}
+	switch option.Type {
+	case CustomizationColor:
+		schema.Type = "string"
+		schema.Format = "color"
+		schema.Pattern = colorPattern
+		schema.ErrorMessage = "Enter a colour such as #0369a1."
+	case CustomizationFont:
+		schema.Type = "string"
+		for _, font := range option.Fonts {
+			schema.Enum = append(schema.Enum, font)
+		}
+	case CustomizationSize:
+		schema.Type = "number"
+		schema.Minimum = option.Min
+		schema.Maximum = option.Max
+		schema.Description = fmt.Sprintf("Size in %s", option.unit())
+	case CustomizationAsset:
+		one := 1.0
+		schema.Type = "integer"
+		schema.Minimum = &one
+		schema.Description = fmt.Sprintf("ID of a %s asset", option.assetType())
+	}
+	return schema
+}
+
+// sizeValue reads a size given as a number or as a string such as "18px" in the given unit.
+func sizeValue(v interface{}, unit string) (float64, bool) {
+	if s, ok := v.(string); ok {
+		v = strings.TrimSuffix(strings.TrimSpace(s), unit)
+	}
+	return numberValue(v)
+}
+
+// finishCustomization brings overrides that passed the template's schema into the form layouts
+// use, and checks that asset options refer to an existing asset of the right type.
+func (s *posterSubService) finishCustomization(ctx context.Context, options []CustomizationOption, values map[string]interface{}, problems map[string]string) error {
+	for _, option := range options {
+		value, ok := values[option.Key]
+		if !ok {
+			continue
+		}
+		key := "customization_data." + option.Key
+		switch option.Type {
+		case CustomizationColor:
+			values[option.Key] = expandColor(value.(string))
+		case CustomizationSize:
+			values[option.Key] = formatNumber(value.(float64)) + option.unit()
+		case CustomizationAsset:
+			asset, err := s.assetRepo.GetAssetByID(ctx, uint(value.(float64)))
+			if err == gorm.ErrRecordNotFound || (err == nil && asset.Type != option.assetType()) {
+				problems[key] = fmt.Sprintf("%s must be the ID of a %s asset.", option.label(), option.assetType())
+				delete(values, option.Key)
+				continue
+			}
+			if err != nil {
+				s.log.Error("Failed to look up customization asset", err, "asset_id", value)
+				return errors.DatabaseError("failed to retrieve asset", err)
+			}
+		}
+	}
+	return nil
+}
+
+// expandColor writes a colour that matched colorPattern as lower-case #rrggbb.
+func expandColor(s string) string {
+	s = strings.ToLower(s)
+	if len(s) == 4 {
+		s = string([]byte{'#', s[1], s[1], s[2], s[2], s[3], s[3]})
+	}
+	return s
+}
+
+// validateCustomizationOptions checks the customization options of a template about to be saved,
+// including that the template's own defaults are values the options accept.
+func validateCustomizationOptions(raw, defaults datatypes.JSON) error {
+	options, err := decodeCustomizationOptions(raw)
+	if err != nil {
+		return errors.ValidationError("invalid customization options", err, map[string]string{"customization_options": "Customization options must be a list of option definitions"})
+	}
+	defaultValues := map[string]interface{}{}
+	if len(defaults) > 0 && string(defaults) != "null" {
+		if err := json.Unmarshal(defaults, &defaultValues); err != nil {
+			return errors.ValidationError("invalid default customization", err, map[string]string{"default_customization": "Default customization must be an object"})
+		}
+	}
+
+	problems := map[string]string{}
+	for i, option := range options {
+		if option.Key == "" {
+			problems[fmt.Sprintf("customization_options[%d]", i)] = "Option has no key"
+			continue
+		}
+		key := "customization_options." + option.Key
+		if _, seen := problems[key]; seen {
+			continue
+		}
+		if message := checkCustomizationOption(option, options[:i]); message != "" {
+			problems[key] = message
+			continue
+		}
+		if value, ok := defaultValues[option.Key]; ok && !isBlankValue(value) {
+			if message := checkCustomizationDefault(option, value); message != "" {
+				problems[key] = message
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return errors.ValidationError("invalid customization options", nil, problems)
+	}
+	return nil
+}
+
+func checkCustomizationOption(option CustomizationOption, earlier []CustomizationOption) string {
+	for _, other := range earlier {
+		if other.Key == option.Key {
+			return "Option is defined more than once"
+		}
+	}
+	switch option.Type {
+	case CustomizationColor, CustomizationAsset:
+	case CustomizationFont:
+		if len(option.Fonts) == 0 {
+			return "Font options need at least one font family"
+		}
+		for _, font := range option.Fonts {
+			if !fontFamilyPattern.MatchString(font) {
+				return fmt.Sprintf("Font family %q may only contain letters, digits, spaces, dashes and underscores", font)
+			}
+		}
+	case CustomizationSize:
+		if option.Min == nil || option.Max == nil {
+			return "Size options need a min and max"
+		}
+		if *option.Min > *option.Max {
+			return "Min cannot be greater than max"
+		}
+		if !sizeUnits[option.unit()] {
+			return "Unit must be one of px, pt, mm, em or rem"
+		}
+	default:
+		return fmt.Sprintf("Unknown option type %q; use color, font, size or asset", option.Type)
+	}
+	return ""
+}
+
+// checkCustomizationDefault checks a default customization value against its option. Asset
+// defaults are not looked up: the asset may be added after the template.
+func checkCustomizationDefault(option CustomizationOption, value interface{}) string {
+	switch option.Type {
+	case CustomizationColor:
+		if s, ok := value.(string); !ok || !colorRegexp.MatchString(s) {
+			return "Default value must be a colour such as #0369a1"
+		}
+	case CustomizationFont:
+		for _, font := range option.Fonts {
+			if font == value {
+				return ""
+			}
+		}
+		return "Default value must be one of the option's fonts"
+	case CustomizationSize:
+		size, ok := sizeValue(value, option.unit())
+		if !ok || size < *option.Min || size > *option.Max {
+			return fmt.Sprintf("Default value must be a size between %s and %s%s", formatNumber(*option.Min), formatNumber(*option.Max), option.unit())
+		}
+	}
+	return ""
+}
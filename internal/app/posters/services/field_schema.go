@@ -0,0 +1,395 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/codetheuri/poster-gen/internal/app/posters/handlers/dto"
+	"github.com/codetheuri/poster-gen/internal/app/posters/models"
+)
+
+// maxImageDataURLLength is the longest data URL an image field can hold within MaxImageFieldSize.
+var maxImageDataURLLength = len("data:image/jpeg;base64,") + base64.StdEncoding.EncodedLen(MaxImageFieldSize)
+
+// schemaComment is published at the root of every template schema.
+const schemaComment = "All rules use standard keywords. formatMinimum/formatMaximum (ajv-formats) and errorMessage (ajv-errors) " +
+	"are annotations for forms that repeat rules stated in patterns; phone, color and data-url are formats that pick input widgets."
+
+// Patterns that stand in for formats, which 2020-12 validators need not assert. Like the patterns
+// in field_types.go they must mean the same in Go and in JavaScript.
+const (
+	datePattern = `^[0-9]{4}-(0[1-9]|1[0-2])-(0[1-9]|[12][0-9]|3[01])$`
+	// webAddressPattern is the part of "uri" the server insists on: an http or https address.
+	webAddressPattern = `^[hH][tT][tT][pP][sS]?://[^/?#\s]+`
+)
+
+// templateSchema describes the poster input a template accepts: the business name, its fields
+// under data and the overrides of its customization options under customization_data.
+func templateSchema(templateRecord *models.PosterTemplate) (*dto.JSONSchema, error) {
+	var fields []RequiredFieldConfig
+	if len(templateRecord.RequiredFields) > 0 {
+		if err := json.Unmarshal(templateRecord.RequiredFields, &fields); err != nil {
+			return nil, fmt.Errorf("invalid required fields: %w", err)
+		}
+	}
+	customization := map[string]interface{}{}
+	if len(templateRecord.DefaultCustomization) > 0 && string(templateRecord.DefaultCustomization) != "null" {
+		if err := json.Unmarshal(templateRecord.DefaultCustomization, &customization); err != nil {
+			return nil, fmt.Errorf("invalid default customization: %w", err)
+		}
+	}
+	options, err := decodeCustomizationOptions(templateRecord.CustomizationOptions)
+	if err != nil {
+		return nil, fmt.Errorf("invalid customization options: %w", err)
+	}
+
+	one := 1
+	return &dto.JSONSchema{
+		Schema:  dto.JSONSchemaDialect,
+		Comment: schemaComment,
+		Title:   templateRecord.Name,
+		Type:    "object",
+		Properties: dto.SchemaProperties{
+			{Name: "business_name", Schema: &dto.JSONSchema{Title: "Business Name", Type: "string", MinLength: &one}},
+			{Name: "data", Schema: dataSchema(fields)},
+			{Name: "customization_data", Schema: customizationSchema(options, customization)},
+		},
+		Required: []string{"business_name", "data"},
+	}, nil
+}
+
+// dataSchema describes the data object, with a property for each field in template order.
+func dataSchema(fields []RequiredFieldConfig) *dto.JSONSchema {
+	schema := &dto.JSONSchema{Title: "Data", Type: "object", Properties: dto.SchemaProperties{}, Required: []string{}}
+	for _, field := range fields {
+		schema.Properties = append(schema.Properties, dto.SchemaProperty{Name: field.Name, Schema: fieldSchema(field)})
+		if fieldIsRequired(field) {
+			schema.Required = append(schema.Required, field.Name)
+		}
+	}
+	return schema
+}
+
+// fieldSchema describes the values a field accepts.
+func fieldSchema(field RequiredFieldConfig) *dto.JSONSchema {
+	schema := &dto.JSONSchema{Title: field.Label, Type: "string", Default: field.Default}
+	switch field.kind() {
+	case FieldTypeNumber:
+		schema.Type = "number"
+		if min, ok := numberValue(field.Min); ok {
+			schema.Minimum = &min
+		}
+		if max, ok := numberValue(field.Max); ok {
+			schema.Maximum = &max
+		}
+	case FieldTypeBoolean:
+		schema.Type = "boolean"
+	case FieldTypeDate:
+		schema.Format = "date"
+		schema.Pattern = datePattern
+		schema.ErrorMessage = "Enter a date in the form YYYY-MM-DD."
+		schema.FormatMinimum, _ = field.Min.(string)
+		schema.FormatMaximum, _ = field.Max.(string)
+		if schema.FormatMinimum != "" {
+			schema.AllOf = append(schema.AllOf, &dto.JSONSchema{Type: "string", Pattern: dateBoundPattern(schema.FormatMinimum, true),
+				ErrorMessage: fmt.Sprintf("Choose a date on or after %s.", schema.FormatMinimum)})
+		}
+		if schema.FormatMaximum != "" {
+			schema.AllOf = append(schema.AllOf, &dto.JSONSchema{Type: "string", Pattern: dateBoundPattern(schema.FormatMaximum, false),
+				ErrorMessage: fmt.Sprintf("Choose a date on or before %s.", schema.FormatMaximum)})
+		}
+	case FieldTypeEmail:
+		schema.Format = "email"
+	case FieldTypeURL:
+		schema.Format = "uri"
+		schema.AllOf = append(schema.AllOf, webAddressSchema())
+	case FieldTypePhone:
+		schema.Format = "phone"
+		schema.Pattern = phonePattern
+		schema.ErrorMessage = "Enter a phone number such as 0712 345 678."
+	case FieldTypeColor:
+		schema.Format = "color"
+		schema.Pattern = colorPattern
+		schema.ErrorMessage = "Enter a colour such as #0369a1."
+	case FieldTypeImage:
+		maxLength := maxImageDataURLLength
+		schema.Format = "data-url"
+		schema.Pattern = imagePattern
+		schema.MaxLength = &maxLength
+		schema.ErrorMessage = "Choose a PNG, JPEG, GIF or WebP image."
+	case FieldTypeEnum:
+		labelled := false
+		for _, option := range field.Options {
+			labelled = labelled || option.Label != ""
+		}
+		for _, option := range field.Options {
+			if labelled {
+				title := option.Label
+				if title == "" {
+					title = option.Value
+				}
+				schema.OneOf = append(schema.OneOf, &dto.JSONSchema{Const: option.Value, Title: title})
+			} else {
+				schema.Enum = append(schema.Enum, option.Value)
+			}
+		}
+	case FieldTypeQR:
+		if field.QR != nil && field.QR.Track {
+			schema.Format = "uri"
+			schema.AllOf = append(schema.AllOf, webAddressSchema())
+		}
+	}
+	if schema.Type == "string" {
+		if field.Pattern != "" && schema.Pattern == "" {
+			schema.Pattern = field.Pattern
+			schema.ErrorMessage = field.PatternTitle
+		}
+		if field.MaxLength > 0 && schema.MaxLength == nil {
+			maxLength := field.MaxLength
+			schema.MaxLength = &maxLength
+		}
+		if fieldIsRequired(field) {
+			one := 1
+			schema.MinLength = &one
+		}
+	}
+	return schema
+}
+
+func webAddressSchema() *dto.JSONSchema {
+	return &dto.JSONSchema{Type: "string", Pattern: webAddressPattern, ErrorMessage: "Enter a web address starting with http:// or https://."}
+}
+
+// dateBoundPattern matches the YYYY-MM-DD dates on or after bound, or on or before it. Dates of
+// that form sort like strings, so the pattern lists, for each digit of bound, the dates that share
+// the digits before it and have a greater (or smaller) one there.
+func dateBoundPattern(bound string, after bool) string {
+	rest := func(from int) string {
+		var b strings.Builder
+		for _, c := range bound[from:] {
+			if c == '-' {
+				b.WriteByte('-')
+			} else {
+				b.WriteString("[0-9]")
+			}
+		}
+		return b.String()
+	}
+	alternatives := []string{bound}
+	for i := 0; i < len(bound); i++ {
+		d := bound[i]
+		if d < '0' || d > '9' {
+			continue
+		}
+		switch {
+		case after && d < '9':
+			alternatives = append(alternatives, fmt.Sprintf("%s[%c-9]%s", bound[:i], d+1, rest(i+1)))
+		case !after && d > '0':
+			alternatives = append(alternatives, fmt.Sprintf("%s[0-%c]%s", bound[:i], d-1, rest(i+1)))
+		}
+	}
+	return "^(" + strings.Join(alternatives, "|") + ")$"
+}
+
+// applyProperties checks the properties of obj against an object schema and returns a copy of
+// obj with the values coerced and defaults filled in. Keys the schema does not describe are kept
+// as they are unless additionalProperties is false. Problems are recorded under the property name,
+// prefixed with path when it is set.
+func applyProperties(schema *dto.JSONSchema, obj map[string]interface{}, path string, problems map[string]string) map[string]interface{} {
+	out := make(map[string]interface{}, len(obj))
+	for key, value := range obj {
+		out[key] = value
+	}
+	required := map[string]bool{}
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+	if schema.AdditionalProperties != nil && !*schema.AdditionalProperties {
+		for name := range obj {
+			if schema.Properties.Get(name) == nil {
+				problems[joinSchemaPath(path, name)] = fmt.Sprintf("%s cannot be set for this template.", name)
+				delete(out, name)
+			}
+		}
+	}
+	for _, property := range schema.Properties {
+		key := joinSchemaPath(path, property.Name)
+		value, ok := applyProperty(property.Name, property.Schema, required[property.Name], obj[property.Name], key, problems)
+		if ok && value != nil {
+			out[property.Name] = value
+		} else {
+			delete(out, property.Name)
+		}
+	}
+	return out
+}
+
+// applyProperty checks one property value. A missing or empty value takes the schema's default;
+// without one it is a problem for required properties and resolves to nil otherwise.
+func applyProperty(name string, schema *dto.JSONSchema, required bool, value interface{}, key string, problems map[string]string) (interface{}, bool) {
+	if isBlankValue(value) {
+		switch {
+		case schema.Default != nil:
+			value = schema.Default
+		case required:
+			problems[key] = fmt.Sprintf("%s is required.", schemaLabel(schema, name))
+			return nil, false
+		default:
+			return nil, true
+		}
+	}
+	return applySchema(schema, value, name, key, problems)
+}
+
+// applySchema checks a value against a schema and returns it coerced to the schema's type. Like
+// an HTML form, it accepts strings holding numbers or booleans for number and boolean schemas, and
+// numbers or booleans for string schemas.
+func applySchema(schema *dto.JSONSchema, value interface{}, name, key string, problems map[string]string) (interface{}, bool) {
+	label := schemaLabel(schema, name)
+	fail := func(format string, args ...interface{}) (interface{}, bool) {
+		problems[key] = fmt.Sprintf(format, append([]interface{}{label}, args...)...)
+		return nil, false
+	}
+
+	switch schema.Type {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return fail("%s must be an object.")
+		}
+		return applyProperties(schema, obj, key, problems), true
+
+	case "number", "integer":
+		n, ok := numberValue(value)
+		if !ok {
+			return fail("%s must be a number.")
+		}
+		if schema.Type == "integer" && n != math.Trunc(n) {
+			return fail("%s must be a whole number.")
+		}
+		if schema.Minimum != nil && n < *schema.Minimum {
+			return fail("%s must be at least %s.", formatNumber(*schema.Minimum))
+		}
+		if schema.Maximum != nil && n > *schema.Maximum {
+			return fail("%s must be at most %s.", formatNumber(*schema.Maximum))
+		}
+		value = n
+
+	case "boolean":
+		b, ok := booleanValue(value)
+		if !ok {
+			return fail("%s must be true or false.")
+		}
+		value = b
+
+	case "string":
+		s, ok := scalarString(value)
+		if !ok {
+			return fail("%s must be text.")
+		}
+		length := utf8.RuneCountInString(s)
+		if schema.MinLength != nil && length < *schema.MinLength {
+			if *schema.MinLength == 1 {
+				return fail("%s is required.")
+			}
+			return fail("%s must be at least %d characters.", *schema.MinLength)
+		}
+		if schema.MaxLength != nil && length > *schema.MaxLength {
+			return fail("%s cannot exceed %d characters.", *schema.MaxLength)
+		}
+		if schema.Pattern != "" {
+			if matched, _ := regexp.MatchString(schema.Pattern, s); !matched {
+				message := schema.ErrorMessage
+				if message == "" {
+					message = "Invalid format."
+				}
+				return fail("%s: %s", message)
+			}
+		}
+		if message := checkFormat(schema, s); message != "" {
+			return fail("%s %s", message)
+		}
+		for _, sub := range schema.AllOf {
+			if _, ok := applySchema(sub, s, label, key, problems); !ok {
+				return nil, false
+			}
+		}
+		value = s
+	}
+
+	if len(schema.Enum) > 0 || len(schema.OneOf) > 0 {
+		choices := make([]string, 0, len(schema.Enum)+len(schema.OneOf))
+		found := false
+		for _, choice := range schema.Enum {
+			found = found || choice == value
+			choices = append(choices, fmt.Sprintf("%v", choice))
+		}
+		for _, choice := range schema.OneOf {
+			found = found || choice.Const == value
+			choices = append(choices, fmt.Sprintf("%v", choice.Const))
+		}
+		if !found {
+			return fail("%s must be one of: %s.", strings.Join(choices, ", "))
+		}
+	}
+	return value, true
+}
+
+// checkFormat checks what the patterns of a format cannot express and returns what is wrong, or
+// "". The message continues a sentence that starts with the field's label.
+func checkFormat(schema *dto.JSONSchema, s string) string {
+	switch schema.Format {
+	case "email":
+		if addr, err := mail.ParseAddress(s); err != nil || addr.Address != s {
+			return "must be an email address."
+		}
+	case "uri":
+		if !isTrackableTarget(s) {
+			return "must be a web address starting with http:// or https://."
+		}
+	case "date":
+		// The patterns check the form and the bounds; this catches days such as 2026-02-30.
+		if _, err := time.Parse(dateFieldLayout, s); err != nil {
+			return "must be a date in the form YYYY-MM-DD."
+		}
+	}
+	return ""
+}
+
+func joinSchemaPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func schemaLabel(schema *dto.JSONSchema, name string) string {
+	if schema.Title != "" {
+		return schema.Title
+	}
+	return name
+}
+
+// scalarString reads a string, or writes a number or boolean as one.
+func scalarString(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case float64:
+		return formatNumber(v), true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		return strconv.FormatBool(v), true
+	default:
+		return "", false
+	}
+}
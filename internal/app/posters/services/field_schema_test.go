@@ -0,0 +1,49 @@
+package services
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestDateBoundPatternMatchesStringOrder(t *testing.T) {
+	for _, bound := range []string{"2026-01-01", "2026-10-16", "1999-12-31", "2030-09-09"} {
+		after := regexp.MustCompile(dateBoundPattern(bound, true))
+		before := regexp.MustCompile(dateBoundPattern(bound, false))
+		for d := time.Date(1995, 1, 1, 0, 0, 0, 0, time.UTC); d.Year() < 2035; d = d.AddDate(0, 0, 3) {
+			date := d.Format(dateFieldLayout)
+			if got, want := after.MatchString(date), date >= bound; got != want {
+				t.Errorf("on or after %s matches %s = %v, want %v", bound, date, got, want)
+			}
+			if got, want := before.MatchString(date), date <= bound; got != want {
+				t.Errorf("on or before %s matches %s = %v, want %v", bound, date, got, want)
+			}
+		}
+		if !after.MatchString(bound) || !before.MatchString(bound) {
+			t.Errorf("bound %s does not match its own patterns", bound)
+		}
+	}
+}
+
+func TestDataSchemaEnforcesDateBoundsWithPatterns(t *testing.T) {
+	fields := []RequiredFieldConfig{{Name: "expiry", Label: "Expiry", Type: FieldTypeDate, Min: "2026-01-01", Max: "2026-12-31"}}
+	schema := dataSchema(fields)
+	property := schema.Properties[0].Schema
+	if len(property.AllOf) != 2 {
+		t.Fatalf("date schema has %d allOf entries, want one per bound", len(property.AllOf))
+	}
+
+	for value, want := range map[string]string{
+		"2026-06-15": "",
+		"2025-12-31": "Expiry: Choose a date on or after 2026-01-01.",
+		"2027-01-01": "Expiry: Choose a date on or before 2026-12-31.",
+		"2026-02-30": "Expiry must be a date in the form YYYY-MM-DD.",
+		"15/06/2026": "Expiry: Enter a date in the form YYYY-MM-DD.",
+	} {
+		problems := map[string]string{}
+		applyProperties(schema, map[string]interface{}{"expiry": value}, "", problems)
+		if got := problems["expiry"]; got != want {
+			t.Errorf("expiry %q: problem %q, want %q", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,334 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codetheuri/poster-gen/pkg/barcode"
+	"github.com/codetheuri/poster-gen/pkg/errors"
+	"github.com/codetheuri/poster-gen/pkg/qr"
+	"gorm.io/datatypes"
+)
+
+// Field types a template's required fields may have. A field without a type is text.
+// Values are coerced before they reach the layout: numbers become float64, booleans bool,
+// images a data URL usable in src attributes and everything else a string.
+const (
+	FieldTypeText    = "text"
+	FieldTypeNumber  = "number"
+	FieldTypePhone   = "phone" // digits with an optional leading +; spaces, dashes, dots and brackets are dropped
+	FieldTypeEmail   = "email"
+	FieldTypeColor   = "color"   // #rgb or #rrggbb, passed on as lower-case #rrggbb
+	FieldTypeEnum    = "enum"    // one of the field's options
+	FieldTypeSelect  = "select"  // same as enum
+	FieldTypeDate    = "date"    // YYYY-MM-DD
+	FieldTypeURL     = "url"     // an http or https address
+	FieldTypeImage   = "image"   // a base64 data URL of a PNG, JPEG, GIF or WebP image
+	FieldTypeBoolean = "boolean" // true/false, also 1/0, yes/no and on/off
+)
+
+// MaxImageFieldSize is the largest decoded image accepted in an image field.
+const MaxImageFieldSize = 2 << 20
+
+// dateFieldLayout is the form of date field values and of the min and max of date fields.
+const dateFieldLayout = "2006-01-02"
+
+// Patterns of the field types with a fixed form. They are written into template schemas, so they
+// must mean the same in Go and in JavaScript.
+const (
+	// phonePattern allows 7 to 15 digits with spaces, dashes, dots or brackets between them.
+	phonePattern = `^\+?\(?[0-9]([ ().-]*[0-9]){6,14}$`
+	colorPattern = `^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`
+	imagePattern = `^data:image/(png|jpeg|gif|webp);base64,`
+)
+
+var (
+	phoneSeparator = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "")
+	imageDataURL   = regexp.MustCompile(`^data:(image/(?:png|jpeg|gif|webp));base64,`)
+)
+
+// FieldOption is one choice of an enum or select field. In the template JSON an option is
+// either {"value": "...", "label": "..."} or just the value.
+type FieldOption struct {
+	Value string `json:"value"`
+	Label string `json:"label,omitempty"`
+}
+
+func (o *FieldOption) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		o.Label = ""
+		return json.Unmarshal(b, &o.Value)
+	}
+	type option FieldOption
+	return json.Unmarshal(b, (*option)(o))
+}
+
+// isRequired reports whether the field must be filled in. Fields are required unless marked
+// "required": false.
+func (f RequiredFieldConfig) isRequired() bool {
+	return f.Required == nil || *f.Required
+}
+
+// kind is the field's type with the default and aliases resolved.
+func (f RequiredFieldConfig) kind() string {
+	switch f.Type {
+	case "":
+		return FieldTypeText
+	case FieldTypeSelect:
+		return FieldTypeEnum
+	default:
+		return f.Type
+	}
+}
+
+// resolveField turns the submitted value of a field into the value passed to the layout: it is
+// checked against the field's schema, then by finishField. Empty values take the field's default;
+// an empty optional field without one resolves to nil. The message explains why the value was
+// rejected and is empty when it was accepted.
+func resolveField(field RequiredFieldConfig, raw interface{}) (interface{}, string) {
+	problems := map[string]string{}
+	value, ok := applyProperty(field.Name, fieldSchema(field), fieldIsRequired(field), raw, field.Name, problems)
+	if !ok {
+		return nil, problems[field.Name]
+	}
+	if value == nil {
+		return nil, ""
+	}
+	return finishField(field, value)
+}
+
+// fieldIsRequired reports whether leaving the field empty is an error, which is not the case
+// when a default takes its place.
+func fieldIsRequired(field RequiredFieldConfig) bool {
+	return field.isRequired() && field.Default == nil
+}
+
+func isBlankValue(v interface{}) bool {
+	return v == nil || v == ""
+}
+
+// finishField makes the checks a JSON Schema cannot express, such as whether a value fits in a
+// QR code, and brings a value that passed the field's schema into the form the layout receives.
+func finishField(field RequiredFieldConfig, value interface{}) (interface{}, string) {
+	label := field.Label
+	s, _ := value.(string)
+	switch field.kind() {
+	case FieldTypePhone:
+		return phoneSeparator.Replace(s), ""
+
+	case FieldTypeColor:
+		return expandColor(s), ""
+
+	case FieldTypeImage:
+		if message := checkImageDataURL(s); message != "" {
+			return nil, fmt.Sprintf("%s %s.", label, message)
+		}
+		return template.URL(s), ""
+
+	case FieldTypeQR:
+		// The value must fit in a code at the configured error-correction level
+		level := ""
+		if field.QR != nil {
+			level = field.QR.Level
+		}
+		if err := qr.Validate(s, level); err != nil {
+			return nil, fmt.Sprintf("%s is too long to fit in a QR code.", label)
+		}
+
+	case FieldTypeBarcode:
+		// The value must be encodable, e.g. an EAN-13 code with a correct check digit
+		if err := barcode.Validate(field.Barcode.symbology(), s); err != nil {
+			return nil, fmt.Sprintf("%s: %s.", label, err.Error())
+		}
+	}
+	return value, ""
+}
+
+// numberValue reads a JSON number or a string holding one.
+func numberValue(v interface{}) (float64, bool) {
+	var n float64
+	switch v := v.(type) {
+	case float64:
+		n = v
+	case int:
+		n = float64(v)
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return 0, false
+		}
+		n = f
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, false
+		}
+		n = f
+	default:
+		return 0, false
+	}
+	return n, !math.IsNaN(n) && !math.IsInf(n, 0)
+}
+
+// formatNumber writes n without an exponent, so 1234567 stays "1234567".
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+func booleanValue(v interface{}) (bool, bool) {
+	switch v := v.(type) {
+	case bool:
+		return v, true
+	case float64:
+		return v != 0, v == 0 || v == 1
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "true", "1", "yes", "on":
+			return true, true
+		case "false", "0", "no", "off":
+			return false, true
+		}
+	}
+	return false, false
+}
+
+func dateValue(v interface{}) (time.Time, bool) {
+	s, ok := v.(string)
+	if !ok {
+		return time.Time{}, false
+	}
+	d, err := time.Parse(dateFieldLayout, s)
+	return d, err == nil
+}
+
+// checkImageDataURL describes what is wrong with an image field value, or returns "".
+func checkImageDataURL(s string) string {
+	m := imageDataURL.FindStringSubmatch(s)
+	if m == nil {
+		return "must be a PNG, JPEG, GIF or WebP image given as a base64 data URL"
+	}
+	encoded := s[len(m[0]):]
+	if base64.StdEncoding.DecodedLen(len(encoded)) > MaxImageFieldSize+2 {
+		return fmt.Sprintf("may be at most %d KB", MaxImageFieldSize>>10)
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "is not valid base64"
+	}
+	if len(data) > MaxImageFieldSize {
+		return fmt.Sprintf("may be at most %d KB", MaxImageFieldSize>>10)
+	}
+	if sniffed := http.DetectContentType(data); sniffed != m[1] {
+		return fmt.Sprintf("is not a %s image", strings.TrimPrefix(m[1], "image/"))
+	}
+	return ""
+}
+
+// fieldTypes lists every type a field may declare.
+var fieldTypes = map[string]bool{
+	FieldTypeText: true, FieldTypeNumber: true, FieldTypePhone: true, FieldTypeEmail: true,
+	FieldTypeColor: true, FieldTypeEnum: true, FieldTypeDate: true, FieldTypeURL: true,
+	FieldTypeImage: true, FieldTypeBoolean: true, FieldTypeQR: true, FieldTypeBarcode: true,
+}
+
+// patternFieldTypes lists the types whose fields may add their own pattern; the others either
+// have one built in or are not text.
+var patternFieldTypes = map[string]bool{
+	FieldTypeText: true, FieldTypeEmail: true, FieldTypeURL: true, FieldTypeQR: true, FieldTypeBarcode: true,
+}
+
+// validateFieldConfigs checks the field definitions of a template about to be saved for
+// mistakes that would otherwise only show when a poster is generated.
+func validateFieldConfigs(raw datatypes.JSON) error {
+	var fields []RequiredFieldConfig
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return errors.ValidationError("invalid template fields", err, map[string]string{"required_fields": "Required fields must be a list of field definitions"})
+	}
+	if problems := checkFieldConfigs(fields); len(problems) > 0 {
+		return errors.ValidationError("invalid template fields", nil, problems)
+	}
+	return nil
+}
+
+// checkFieldConfigs returns the problems of each field keyed by "required_fields.<name>".
+func checkFieldConfigs(fields []RequiredFieldConfig) map[string]string {
+	problems := map[string]string{}
+	seen := map[string]bool{}
+	for i, field := range fields {
+		if field.Name == "" {
+			problems[fmt.Sprintf("required_fields[%d]", i)] = "Field has no name"
+			continue
+		}
+		key := "required_fields." + field.Name
+		if seen[key] {
+			problems[key] = "Field is defined more than once"
+			continue
+		}
+		seen[key] = true
+		if message := checkFieldConfig(field); message != "" {
+			problems[key] = message
+		}
+	}
+	return problems
+}
+
+func checkFieldConfig(field RequiredFieldConfig) string {
+	kind := field.kind()
+	if !fieldTypes[kind] {
+		return fmt.Sprintf("Unknown field type %q", field.Type)
+	}
+	if field.Pattern != "" {
+		if _, err := regexp.Compile(field.Pattern); err != nil {
+			return fmt.Sprintf("Pattern is not a valid regular expression: %v", err)
+		}
+	}
+	if kind == FieldTypeEnum && len(field.Options) == 0 {
+		return "Enum and select fields need at least one option"
+	}
+	for _, option := range field.Options {
+		if option.Value == "" {
+			return "Options need a value"
+		}
+	}
+	if field.Min != nil || field.Max != nil {
+		switch kind {
+		case FieldTypeNumber:
+			min, minOK := numberValue(field.Min)
+			max, maxOK := numberValue(field.Max)
+			if (field.Min != nil && !minOK) || (field.Max != nil && !maxOK) {
+				return "Min and max of a number field must be numbers"
+			}
+			if minOK && maxOK && min > max {
+				return "Min cannot be greater than max"
+			}
+		case FieldTypeDate:
+			min, minOK := dateValue(field.Min)
+			max, maxOK := dateValue(field.Max)
+			if (field.Min != nil && !minOK) || (field.Max != nil && !maxOK) {
+				return "Min and max of a date field must be dates in the form YYYY-MM-DD"
+			}
+			if minOK && maxOK && min.After(max) {
+				return "Min cannot be after max"
+			}
+		default:
+			return "Only number and date fields take a min and max"
+		}
+	}
+	if field.Pattern != "" && !patternFieldTypes[kind] {
+		return "Only text, email, url, qr and barcode fields take a pattern"
+	}
+	if field.Default != nil {
+		if _, message := resolveField(field, field.Default); message != "" {
+			return fmt.Sprintf("Default value is invalid: %s", message)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"regexp"
+	"strings"
+
+	"github.com/codetheuri/poster-gen/internal/app/posters/models"
+
+	"github.com/google/uuid"
+)
+
+// DownloadNameParam is the signed query parameter carrying the suggested download filename.
+const DownloadNameParam = "name"
+
+const maxDownloadNameLength = 80
+
+var (
+	unsafeFilenameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+	storageKeyPattern   = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\.[a-z0-9]+$`)
+)
+
+// newStorageKey returns a collision-free key such as "0b6f...e1.pdf".
+func newStorageKey(extension string) string {
+	return uuid.NewString() + "." + extension
+}
+
+// newAccessToken returns a random 32-character token that grants access to a single poster.
+func newAccessToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// validAccessToken reports whether token is the poster's access token. Posters created before
+// tokens were introduced have none; their clients never received one, so they are looked up
+// without it.
+func validAccessToken(poster *models.Poster, token string) bool {
+	if poster.AccessToken == "" {
+		return true
+	}
+	return subtle.ConstantTimeCompare([]byte(poster.AccessToken), []byte(token)) == 1
+}
+
+// isGeneratedStorageKey reports whether key was produced by newStorageKey, as opposed to the
+// "<BusinessName>_<unix>.pdf" names written before keys were randomised.
+func isGeneratedStorageKey(key string) bool {
+	return storageKeyPattern.MatchString(key)
+}
+
+// DownloadFilename turns a business name into a filename that is safe to put in a
+// Content-Disposition header on any OS, e.g. "Mama Mboga / Shop" -> "Mama_Mboga_Shop.pdf".
+func DownloadFilename(businessName, extension string) string {
+	name := unsafeFilenameChars.ReplaceAllString(businessName, "_")
+	name = strings.Trim(name, "._-")
+	if len(name) > maxDownloadNameLength {
+		name = strings.TrimRight(name[:maxDownloadNameLength], "._-")
+	}
+	if name == "" {
+		name = "poster"
+	}
+	return name + "." + strings.TrimPrefix(extension, ".")
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	stdErrors "errors"
+	"fmt"
+	"html/template"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/codetheuri/poster-gen/internal/app/posters/handlers/dto"
+	"github.com/codetheuri/poster-gen/internal/app/posters/models"
+	"github.com/codetheuri/poster-gen/internal/app/posters/repositories"
+	"github.com/codetheuri/poster-gen/pkg/errors"
+	"github.com/codetheuri/poster-gen/pkg/logger"
+	"github.com/codetheuri/poster-gen/pkg/storage"
+	"gorm.io/gorm"
+)
+
+// MaxFontSize is the largest font file accepted for upload.
+const MaxFontSize = 5 << 20
+
+// FontFamilyField is the customization key layouts use to pick an uploaded font.
+const FontFamilyField = "font_family_name"
+
+// FontFaceField is the template data key holding the inlined @font-face rules.
+const FontFaceField = "font_face_css"
+
+const fontKeyPrefix = "fonts"
+
+// Family names end up inside CSS strings, so only plain names are allowed.
+var fontFamilyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 _-]*$`)
+
+// fontFormat describes a supported font file type.
+type fontFormat struct {
+	name        string // value of the CSS format() hint
+	extension   string
+	contentType string
+}
+
+var (
+	fontFormatTrueType = fontFormat{name: "truetype", extension: "ttf", contentType: "font/ttf"}
+	fontFormatWOFF2    = fontFormat{name: "woff2", extension: "woff2", contentType: "font/woff2"}
+)
+
+// sniffFontFormat identifies TTF and WOFF2 files by their signature rather than trusting the filename.
+func sniffFontFormat(data []byte) (fontFormat, bool) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0x00, 0x01, 0x00, 0x00}), bytes.HasPrefix(data, []byte("true")):
+		return fontFormatTrueType, true
+	case bytes.HasPrefix(data, []byte("wOF2")):
+		return fontFormatWOFF2, true
+	default:
+		return fontFormat{}, false
+	}
+}
+
+// FontSubService manages uploaded fonts and turns them into inline @font-face rules,
+// so rendering never depends on fetching fonts from the internet.
+type FontSubService interface {
+	UploadFont(ctx context.Context, input *dto.FontInput, file io.Reader) (*dto.FontResponse, error)
+	ListFonts(ctx context.Context) ([]*dto.FontResponse, error)
+	DeleteFont(ctx context.Context, id uint) error
+	// FontFaceCSS returns @font-face rules with data-URI sources for every uploaded font of family.
+	// An empty or unknown family yields no rules, leaving the layout's fallback fonts in charge.
+	FontFaceCSS(ctx context.Context, family string) (template.CSS, error)
+}
+
+type fontSubService struct {
+	repo  repositories.AssetRepository
+	files storage.Storage
+	log   logger.Logger
+
+	// Font keys are random and never overwritten, so encoded files can be kept for the process lifetime.
+	mu      sync.RWMutex
+	encoded map[string]string
+}
+
+func NewFontSubService(repo repositories.AssetRepository, files storage.Storage, log logger.Logger) FontSubService {
+	return &fontSubService{repo: repo, files: files, log: log, encoded: make(map[string]string)}
+}
+
+func (s *fontSubService) UploadFont(ctx context.Context, input *dto.FontInput, file io.Reader) (*dto.FontResponse, error) {
+	s.log.Info("Uploading font", "family", input.Family, "weight", input.Weight, "style", input.Style)
+
+	family := strings.TrimSpace(input.Family)
+	if !fontFamilyPattern.MatchString(family) {
+		return nil, errors.ValidationError("invalid font family", nil, map[string]string{"family": "Family may only contain letters, digits, spaces, hyphens and underscores"})
+	}
+	weight := input.Weight
+	if weight == 0 {
+		weight = 400
+	}
+	style := input.Style
+	if style == "" {
+		style = "normal"
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, MaxFontSize+1))
+	if err != nil {
+		return nil, errors.ValidationError("failed to read font file", err, nil)
+	}
+	if len(data) > MaxFontSize {
+		return nil, errors.ValidationError("font file is too large", nil, map[string]string{"file": fmt.Sprintf("Font files may be at most %d MB", MaxFontSize>>20)})
+	}
+	format, ok := sniffFontFormat(data)
+	if !ok {
+		return nil, errors.ValidationError("unsupported font file", nil, map[string]string{"file": "Only TTF and WOFF2 fonts are supported"})
+	}
+
+	key := path.Join(fontKeyPrefix, newStorageKey(format.extension))
+	if err := s.files.Put(ctx, key, bytes.NewReader(data), int64(len(data)), format.contentType); err != nil {
+		s.log.Error("Failed to store font file", err, "key", key)
+		return nil, errors.InternalServerError("failed to store font file", err)
+	}
+	asset := &models.Asset{
+		Name:       fmt.Sprintf("%s %d %s", family, weight, style),
+		Type:       models.AssetTypeFont,
+		FontFamily: family,
+		FontWeight: weight,
+		FontStyle:  style,
+		FontFormat: format.name,
+		StorageKey: key,
+	}
+	if err := s.repo.CreateAsset(ctx, asset); err != nil {
+		_ = s.files.Delete(ctx, key)
+		return nil, errors.DatabaseError("failed to save font", err)
+	}
+	s.log.Info("Font uploaded successfully", "id", asset.ID, "key", key)
+	return toFontResponse(asset), nil
+}
+
+func (s *fontSubService) ListFonts(ctx context.Context) ([]*dto.FontResponse, error) {
+	assets, err := s.repo.GetAssetsByType(ctx, models.AssetTypeFont)
+	if err != nil {
+		return nil, errors.DatabaseError("failed to retrieve fonts", err)
+	}
+	resp := make([]*dto.FontResponse, len(assets))
+	for i, asset := range assets {
+		resp[i] = toFontResponse(asset)
+	}
+	return resp, nil
+}
+
+func (s *fontSubService) DeleteFont(ctx context.Context, id uint) error {
+	s.log.Info("Deleting font", "id", id)
+	asset, err := s.repo.GetAssetByID(ctx, id)
+	if err != nil {
+		if stdErrors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.NotFoundError("font not found", err)
+		}
+		return errors.DatabaseError("failed to retrieve font", err)
+	}
+	if asset.Type != models.AssetTypeFont {
+		return errors.NotFoundError("font not found", nil)
+	}
+	if err := s.repo.DeleteAsset(ctx, id); err != nil {
+		return errors.DatabaseError("failed to delete font", err)
+	}
+	s.mu.Lock()
+	delete(s.encoded, asset.StorageKey)
+	s.mu.Unlock()
+	// The row is gone, so a leftover file is harmless; log it and move on.
+	if err := s.files.Delete(ctx, asset.StorageKey); err != nil {
+		s.log.Warn("Failed to delete font file", "id", id, "key", asset.StorageKey, "error", err)
+	}
+	s.log.Info("Font deleted successfully", "id", id)
+	return nil
+}
+
+func (s *fontSubService) FontFaceCSS(ctx context.Context, family string) (template.CSS, error) {
+	family = strings.TrimSpace(family)
+	if family == "" || !fontFamilyPattern.MatchString(family) {
+		return "", nil
+	}
+	fonts, err := s.repo.GetFontsByFamily(ctx, family)
+	if err != nil {
+		return "", errors.DatabaseError("failed to load fonts", err)
+	}
+	if len(fonts) == 0 {
+		s.log.Warn("No uploaded font for family, falling back to the layout's fonts", "family", family)
+		return "", nil
+	}
+
+	var css strings.Builder
+	for _, font := range fonts {
+		encoded, err := s.encodedFont(ctx, font.StorageKey)
+		if err != nil {
+			s.log.Error("Failed to load font file", err, "font_id", font.ID, "key", font.StorageKey)
+			return "", errors.InternalServerError("failed to load font file", err)
+		}
+		format := fontFormatTrueType
+		if font.FontFormat == fontFormatWOFF2.name {
+			format = fontFormatWOFF2
+		}
+		fmt.Fprintf(&css, "@font-face{font-family:'%s';font-weight:%d;font-style:%s;src:url(data:%s;base64,%s) format('%s');}\n",
+			family, font.FontWeight, font.FontStyle, format.contentType, encoded, format.name)
+	}
+	return template.CSS(css.String()), nil
+}
+
+// encodedFont returns the base64 contents of a stored font file.
+func (s *fontSubService) encodedFont(ctx context.Context, key string) (string, error) {
+	s.mu.RLock()
+	encoded, ok := s.encoded[key]
+	s.mu.RUnlock()
+	if ok {
+		return encoded, nil
+	}
+
+	obj, err := s.files.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	defer obj.Body.Close()
+	data, err := io.ReadAll(obj.Body)
+	if err != nil {
+		return "", err
+	}
+	encoded = base64.StdEncoding.EncodeToString(data)
+
+	s.mu.Lock()
+	s.encoded[key] = encoded
+	s.mu.Unlock()
+	return encoded, nil
+}
+
+func toFontResponse(asset *models.Asset) *dto.FontResponse {
+	return &dto.FontResponse{
+		ID:        asset.ID,
+		Family:    asset.FontFamily,
+		Weight:    asset.FontWeight,
+		Style:     asset.FontStyle,
+		Format:    asset.FontFormat,
+		CreatedAt: asset.CreatedAt,
+	}
+}
@@ -0,0 +1,455 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template/parse"
+
+	"github.com/codetheuri/poster-gen/internal/app/posters/handlers/dto"
+	"github.com/codetheuri/poster-gen/internal/app/posters/models"
+	"github.com/codetheuri/poster-gen/pkg/errors"
+)
+
+// Layout lint problems.
+const (
+	LayoutIssueFile    = "file"    // the layout file cannot be read
+	LayoutIssueSyntax  = "syntax"  // the layout does not parse
+	LayoutIssueMissing = "missing" // the layout uses a key derived from a field the template does not declare
+	LayoutIssueUnknown = "unknown" // the layout uses a key nothing provides, usually a misspelling
+	LayoutIssueUnused  = "unused"  // the template declares a key the layout never shows
+)
+
+// systemDataKeys are set by the poster service whatever the template declares.
+var systemDataKeys = []string{"business_name", "header_logo_svg", "primary_color", FontFamilyField, FontFaceField}
+
+// consumedDataKeys are read by the poster service itself, so declaring them without a
+// placeholder is fine.
+var consumedDataKeys = []string{"header_logo_asset_id", FontFamilyField, "primary_color"}
+
+// funcDataKeys lists the data keys layout functions read on their own.
+var funcDataKeys = map[string][]string{
+	"qrcode": {"primary_color", "header_logo_svg"},
+	"keqr":   {"business_name", "city", "amount"},
+}
+
+// layoutRef is a data key used by a layout.
+type layoutRef struct {
+	location string // first use
+	// optional is set when every use tolerates a missing value: an if/with condition or a
+	// value passed to default.
+	optional bool
+}
+
+// layoutLinter parses layout files and checks the keys they use against template fields.
+type layoutLinter struct {
+	templatesDir string
+}
+
+func newLayoutLinter(templatesDir string) *layoutLinter {
+	return &layoutLinter{templatesDir: templatesDir}
+}
+
+// readLayoutSource returns the HTML of a layout: its uploaded content, or the file it names,
+// which must stay inside templatesDir.
+func readLayoutSource(templatesDir string, layout *models.Layout) ([]byte, error) {
+	if layout.Content != "" {
+		return []byte(layout.Content), nil
+	}
+	if layout.FilePath == "" {
+		return nil, fmt.Errorf("layout %q has neither uploaded content nor a file path", layout.Name)
+	}
+	if !filepath.IsLocal(layout.FilePath) {
+		return nil, fmt.Errorf("layout path %q must be relative to the templates directory", layout.FilePath)
+	}
+	// OpenInRoot also refuses symlinks that lead out of the templates directory.
+	f, err := os.OpenInRoot(templatesDir, layout.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// layoutSourceName names a layout in parse errors and lint locations.
+func layoutSourceName(layout *models.Layout) string {
+	if layout.Content == "" && layout.FilePath != "" {
+		return layout.FilePath
+	}
+	return layout.Name + ".html"
+}
+
+// parse parses layout source with the layout functions, returning a syntax issue when it fails.
+func (l *layoutLinter) parse(name string, src []byte) (*template.Template, *dto.LayoutIssue) {
+	// Parsing only checks that the functions exist; the render closures are never called.
+	var posters *posterSubService
+	tmpl, err := template.New(filepath.Base(name)).Funcs(posters.layoutFuncs(context.Background(), nil)).Parse(string(src))
+	if err != nil {
+		return nil, &dto.LayoutIssue{Problem: LayoutIssueSyntax, Message: strings.TrimPrefix(err.Error(), "template: ")}
+	}
+	return tmpl, nil
+}
+
+// Lint checks the layout on its own, or against the fields of templateRecord when it is set.
+// Issues are sorted by problem and key.
+func (l *layoutLinter) Lint(layout *models.Layout, templateRecord *models.PosterTemplate) ([]dto.LayoutIssue, error) {
+	src, err := readLayoutSource(l.templatesDir, layout)
+	if err != nil {
+		return []dto.LayoutIssue{{Problem: LayoutIssueFile, Message: err.Error()}}, nil
+	}
+	return l.check(layoutSourceName(layout), src, templateRecord)
+}
+
+// check lints layout source. The error is only set when the template's field JSON is invalid.
+func (l *layoutLinter) check(name string, src []byte, templateRecord *models.PosterTemplate) ([]dto.LayoutIssue, error) {
+	tmpl, issue := l.parse(name, src)
+	if issue != nil {
+		return []dto.LayoutIssue{*issue}, nil
+	}
+	if templateRecord == nil {
+		return []dto.LayoutIssue{}, nil
+	}
+	refs, funcs := layoutRefs(tmpl)
+	return crossCheckLayout(refs, funcs, templateRecord)
+}
+
+// validate lints a layout and turns any issue into a validation error listing them.
+func (l *layoutLinter) validate(layout *models.Layout, templateRecord *models.PosterTemplate) error {
+	src, err := readLayoutSource(l.templatesDir, layout)
+	if err != nil {
+		return errors.ValidationError("layout file cannot be read", err, map[string]string{"file_path": "Layout file does not exist in the templates directory"})
+	}
+	issues, err := l.check(layoutSourceName(layout), src, templateRecord)
+	if err != nil {
+		return errors.ValidationError("invalid template fields", err, map[string]string{"required_fields": err.Error()})
+	}
+	if len(issues) > 0 {
+		return errors.ValidationError("layout does not match the template fields", nil, issues)
+	}
+	return nil
+}
+
+// crossCheckLayout compares the keys a layout uses with the keys a template provides.
+func crossCheckLayout(refs map[string]*layoutRef, funcs map[string]bool, templateRecord *models.PosterTemplate) ([]dto.LayoutIssue, error) {
+	var fields []RequiredFieldConfig
+	if len(templateRecord.RequiredFields) > 0 {
+		if err := json.Unmarshal(templateRecord.RequiredFields, &fields); err != nil {
+			return nil, fmt.Errorf("invalid required fields: %w", err)
+		}
+	}
+	customization := map[string]interface{}{}
+	if len(templateRecord.DefaultCustomization) > 0 && string(templateRecord.DefaultCustomization) != "null" {
+		if err := json.Unmarshal(templateRecord.DefaultCustomization, &customization); err != nil {
+			return nil, fmt.Errorf("invalid default customization: %w", err)
+		}
+	}
+
+	options, err := decodeCustomizationOptions(templateRecord.CustomizationOptions)
+	if err != nil {
+		return nil, fmt.Errorf("invalid customization options: %w", err)
+	}
+
+	// provided maps every key the layout receives to the declared key it comes from.
+	provided := map[string]string{}
+	for _, key := range systemDataKeys {
+		provided[key] = key
+	}
+	for key := range customization {
+		provided[key] = key
+	}
+	for _, option := range options {
+		provided[option.Key] = option.Key
+	}
+	for _, field := range fields {
+		provided[field.Name] = field.Name
+		if strings.HasSuffix(field.Name, "_number") {
+			provided[field.Name+"Split"] = field.Name
+		}
+		switch field.Type {
+		case FieldTypeQR:
+			provided[field.Name+qrDataSuffix] = field.Name
+		case FieldTypeBarcode:
+			provided[field.Name+barcodeDataSuffix] = field.Name
+		}
+	}
+
+	used := map[string]bool{}
+	for _, key := range consumedDataKeys {
+		used[key] = true
+	}
+	for name := range funcs {
+		for _, key := range funcDataKeys[name] {
+			used[key] = true
+		}
+	}
+
+	issues := []dto.LayoutIssue{}
+	for key, ref := range refs {
+		if declared, ok := provided[key]; ok {
+			used[declared] = true
+			continue
+		}
+		if field, derived := derivedFieldKey(key); derived {
+			issues = append(issues, dto.LayoutIssue{
+				Key:      key,
+				Problem:  LayoutIssueMissing,
+				Message:  fmt.Sprintf("%s needs a required field named %s%s", key, field, derivedFieldHint(key)),
+				Location: ref.location,
+			})
+			continue
+		}
+		if ref.optional {
+			continue
+		}
+		issues = append(issues, dto.LayoutIssue{
+			Key:      key,
+			Problem:  LayoutIssueUnknown,
+			Message:  fmt.Sprintf("%s is not a required field or customization key of the template", key),
+			Location: ref.location,
+		})
+	}
+	for _, field := range fields {
+		if !used[field.Name] {
+			issues = append(issues, dto.LayoutIssue{Key: field.Name, Problem: LayoutIssueUnused, Message: fmt.Sprintf("required field %s is not shown by the layout", field.Name)})
+		}
+	}
+	for key := range customization {
+		if !used[key] {
+			issues = append(issues, dto.LayoutIssue{Key: key, Problem: LayoutIssueUnused, Message: fmt.Sprintf("customization key %s is not used by the layout", key)})
+		}
+	}
+	for _, option := range options {
+		if _, hasDefault := customization[option.Key]; !hasDefault && !used[option.Key] {
+			issues = append(issues, dto.LayoutIssue{Key: option.Key, Problem: LayoutIssueUnused, Message: fmt.Sprintf("customization option %s is not used by the layout", option.Key)})
+		}
+	}
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].Problem != issues[j].Problem {
+			return issues[i].Problem < issues[j].Problem
+		}
+		return issues[i].Key < issues[j].Key
+	})
+	return issues, nil
+}
+
+// derivedFieldKey returns the field a generated key such as "till_numberSplit" or "menu_qr" comes from.
+func derivedFieldKey(key string) (string, bool) {
+	for _, suffix := range []string{"Split", qrDataSuffix, barcodeDataSuffix} {
+		if field := strings.TrimSuffix(key, suffix); field != key && field != "" {
+			return field, true
+		}
+	}
+	return "", false
+}
+
+func derivedFieldHint(key string) string {
+	switch {
+	case strings.HasSuffix(key, "Split"):
+		return " ending in _number"
+	case strings.HasSuffix(key, qrDataSuffix):
+		return " of type " + FieldTypeQR
+	default:
+		return " of type " + FieldTypeBarcode
+	}
+}
+
+// layoutRefs collects the top-level data keys a parsed layout uses, and the functions it calls.
+func layoutRefs(tmpl *template.Template) (map[string]*layoutRef, map[string]bool) {
+	w := &refWalker{refs: map[string]*layoutRef{}, funcs: map[string]bool{}}
+	for _, t := range tmpl.Templates() {
+		if t.Tree == nil || t.Tree.Root == nil {
+			continue
+		}
+		w.tree = t.Tree
+		w.walk(t.Tree.Root, true)
+	}
+	return w.refs, w.funcs
+}
+
+// refWalker walks a template tree. root tracks whether dot is still the template data:
+// inside range and with blocks it is not, and only $ reaches the data.
+type refWalker struct {
+	tree  *parse.Tree
+	refs  map[string]*layoutRef
+	funcs map[string]bool
+}
+
+func (w *refWalker) walk(node parse.Node, root bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			w.walk(child, root)
+		}
+	case *parse.ActionNode:
+		w.pipe(n.Pipe, root, false)
+	case *parse.IfNode:
+		w.pipe(n.Pipe, root, true)
+		w.walk(n.List, root)
+		w.walk(n.ElseList, root)
+	case *parse.WithNode:
+		w.pipe(n.Pipe, root, true)
+		w.walk(n.List, false)
+		w.walk(n.ElseList, root)
+	case *parse.RangeNode:
+		w.pipe(n.Pipe, root, false)
+		w.walk(n.List, false)
+		w.walk(n.ElseList, root)
+	case *parse.TemplateNode:
+		w.pipe(n.Pipe, root, false)
+	}
+}
+
+// pipe records the keys of a pipeline. condition is set for if/with pipelines, where a
+// lone key only tests whether a value is present.
+func (w *refWalker) pipe(p *parse.PipeNode, root, condition bool) {
+	if p == nil {
+		return
+	}
+	// Everything up to a default command may be missing: {{.color | default "#000"}}.
+	lastDefault := -1
+	for i, cmd := range p.Cmds {
+		if ident, ok := firstArg(cmd).(*parse.IdentifierNode); ok && ident.Ident == "default" {
+			lastDefault = i
+		}
+	}
+	for i, cmd := range p.Cmds {
+		optional := i <= lastDefault || (condition && len(p.Cmds) == 1 && len(cmd.Args) == 1)
+		w.command(cmd, root, optional)
+	}
+}
+
+func (w *refWalker) command(cmd *parse.CommandNode, root, optional bool) {
+	// {{index . "key"}} and {{index $ "key"}} read a key by name.
+	if ident, ok := firstArg(cmd).(*parse.IdentifierNode); ok && ident.Ident == "index" && len(cmd.Args) >= 3 {
+		if key, ok := cmd.Args[2].(*parse.StringNode); ok && w.isData(cmd.Args[1], root) {
+			w.add(key.Text, key, optional)
+		}
+	}
+	for _, arg := range cmd.Args {
+		w.arg(arg, root, optional)
+	}
+}
+
+func (w *refWalker) arg(node parse.Node, root, optional bool) {
+	switch n := node.(type) {
+	case *parse.IdentifierNode:
+		w.funcs[n.Ident] = true
+	case *parse.FieldNode:
+		if root {
+			w.add(n.Ident[0], n, optional)
+		}
+	case *parse.VariableNode:
+		if n.Ident[0] == "$" && len(n.Ident) > 1 {
+			w.add(n.Ident[1], n, optional)
+		}
+	case *parse.ChainNode:
+		w.arg(n.Node, root, optional)
+	case *parse.PipeNode:
+		w.pipe(n, root, false)
+	}
+}
+
+// isData reports whether node evaluates to the template data.
+func (w *refWalker) isData(node parse.Node, root bool) bool {
+	switch n := node.(type) {
+	case *parse.DotNode:
+		return root
+	case *parse.VariableNode:
+		return len(n.Ident) == 1 && n.Ident[0] == "$"
+	}
+	return false
+}
+
+func (w *refWalker) add(key string, node parse.Node, optional bool) {
+	if ref, ok := w.refs[key]; ok {
+		ref.optional = ref.optional && optional
+		return
+	}
+	location, _ := w.tree.ErrorContext(node)
+	w.refs[key] = &layoutRef{location: location, optional: optional}
+}
+
+func firstArg(cmd *parse.CommandNode) parse.Node {
+	if len(cmd.Args) == 0 {
+		return nil
+	}
+	return cmd.Args[0]
+}
+
+func (s *layoutSubService) LintLayouts(ctx context.Context) ([]dto.LayoutLintReport, error) {
+	layouts, err := s.repo.ListLayouts(ctx)
+	if err != nil {
+		return nil, errors.DatabaseError("failed to retrieve layouts", err)
+	}
+	templates, err := s.templateRepo.ListTemplates(ctx)
+	if err != nil {
+		return nil, errors.DatabaseError("failed to retrieve templates", err)
+	}
+	files, err := filepath.Glob(filepath.Join(s.linter.templatesDir, "*.html"))
+	if err != nil {
+		return nil, errors.InternalServerError("failed to list layout files", err)
+	}
+
+	// Layout rows may name files that are gone; those are linted too and report the missing file.
+	paths := map[string]bool{}
+	for _, file := range files {
+		paths[filepath.Base(file)] = true
+	}
+	var uploaded []*models.Layout
+	for _, layout := range layouts {
+		if layout.Content != "" {
+			uploaded = append(uploaded, layout)
+			continue
+		}
+		paths[filepath.Clean(layout.FilePath)] = true
+	}
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	reports := []dto.LayoutLintReport{}
+	for _, path := range sorted {
+		reports = append(reports, s.lintLayout(&models.Layout{FilePath: path}, dto.LayoutLintReport{FilePath: path}, templates, func(t *models.PosterTemplate) bool {
+			return t.Layout.Content == "" && filepath.Clean(t.Layout.FilePath) == path
+		})...)
+	}
+	for _, layout := range uploaded {
+		reports = append(reports, s.lintLayout(layout, dto.LayoutLintReport{LayoutID: layout.ID, LayoutName: layout.Name}, templates, func(t *models.PosterTemplate) bool {
+			return t.LayoutID == layout.ID
+		})...)
+	}
+	return reports, nil
+}
+
+// lintLayout checks a layout against each template selected by uses, or on its own when no template uses it.
+func (s *layoutSubService) lintLayout(layout *models.Layout, report dto.LayoutLintReport, templates []*models.PosterTemplate, uses func(*models.PosterTemplate) bool) []dto.LayoutLintReport {
+	var reports []dto.LayoutLintReport
+	for _, t := range templates {
+		if !uses(t) {
+			continue
+		}
+		issues, err := s.linter.Lint(layout, t)
+		if err != nil {
+			issues = []dto.LayoutIssue{{Key: "required_fields", Problem: LayoutIssueSyntax, Message: err.Error()}}
+		}
+		linked := report
+		linked.TemplateID, linked.Issues = t.ID, issues
+		reports = append(reports, linked)
+	}
+	if len(reports) == 0 {
+		report.Issues, _ = s.linter.Lint(layout, nil)
+		reports = append(reports, report)
+	}
+	return reports
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+
+	"github.com/codetheuri/poster-gen/internal/app/posters/handlers/dto"
+	"github.com/codetheuri/poster-gen/pkg/errors"
+	"github.com/codetheuri/poster-gen/pkg/renderer"
+)
+
+// PreviewFormatHTML returns the rendered layout instead of an image.
+const PreviewFormatHTML = "html"
+
+// previewWidth is the pixel width of preview images: enough to judge the design
+// without paying for a print-resolution render.
+const previewWidth = 600
+
+func (s *posterSubService) PreviewPoster(ctx context.Context, templateID uint, input *dto.PosterInput, format string) (*dto.PosterPreview, error) {
+	s.log.Info("Previewing poster", "template_id", templateID, "format", format)
+
+	if format == "" {
+		format = PreviewFormatHTML
+	}
+	imageFormat := renderer.Format(format)
+	if format != PreviewFormatHTML && !imageFormat.IsRaster() {
+		return nil, errors.ValidationError("unsupported preview format", nil, map[string]string{"format": "format must be one of html, png, jpeg or webp"})
+	}
+
+	templateRecord, finalTemplateData, err := s.prepareTemplateData(ctx, templateID, input)
+	if err != nil {
+		return nil, err
+	}
+	renderData, err := s.withInlineAssets(ctx, templateRecord, finalTemplateData, 0)
+	if err != nil {
+		return nil, err
+	}
+	htmlContent, err := s.renderHTMLTemplate(ctx, renderData, &templateRecord.Layout)
+	if err != nil {
+		return nil, errors.InternalServerError("failed to render template", err)
+	}
+	if format == PreviewFormatHTML {
+		return &dto.PosterPreview{ContentType: "text/html; charset=utf-8", Body: []byte(htmlContent)}, nil
+	}
+
+	// The height follows the page aspect ratio; DPI and size options of the real poster are ignored.
+	buf, err := s.renderBytes(ctx, renderer.Request{
+		HTML:      htmlContent,
+		Format:    imageFormat,
+		Width:     previewWidth,
+		OnBlocked: s.logBlockedRequest(0, templateRecord.ID), // previews have no poster yet
+	}, templateRecord.Layout.Renderer)
+	if err != nil {
+		return nil, renderError(err, imageFormat)
+	}
+	return &dto.PosterPreview{ContentType: imageFormat.ContentType(), Body: buf}, nil
+}
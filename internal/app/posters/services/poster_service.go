@@ -4,38 +4,69 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	stdErrors "errors"
 	"fmt"
 	"html/template"
+	"mime"
+	"net/url"
+	"path"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strconv" // Added for robust asset ID parsing
 	"strings"
 	"time"
-	"unicode/utf8"
 
-	"github.com/chromedp/cdproto/page"
-	"github.com/chromedp/chromedp"
 	"github.com/codetheuri/poster-gen/internal/app/posters/handlers/dto"
 	"github.com/codetheuri/poster-gen/internal/app/posters/models"
 	"github.com/codetheuri/poster-gen/internal/app/posters/repositories"
 	"github.com/codetheuri/poster-gen/pkg/errors"
 	"github.com/codetheuri/poster-gen/pkg/logger"
+	"github.com/codetheuri/poster-gen/pkg/renderer"
+	"github.com/codetheuri/poster-gen/pkg/storage"
+	"github.com/codetheuri/poster-gen/pkg/tmplfuncs"
+	"github.com/codetheuri/poster-gen/pkg/urlsign"
 	"github.com/codetheuri/poster-gen/pkg/validators"
 	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 type RequiredFieldConfig struct {
-	Name         string `json:"name"`
-	Label        string `json:"label"`
-	Type         string `json:"type"`
-	Pattern      string `json:"pattern,omitempty"`
-	MaxLength    int    `json:"maxLength,omitempty"`
-	PatternTitle string `json:"patternTitle,omitempty"`
+	Name         string              `json:"name"`
+	Label        string              `json:"label"`
+	Type         string              `json:"type"`
+	Pattern      string              `json:"pattern,omitempty"`
+	MaxLength    int                 `json:"maxLength,omitempty"`
+	PatternTitle string              `json:"patternTitle,omitempty"`
+	QR           *QRFieldConfig      `json:"qr,omitempty"`       // drawing options for fields of type "qr"
+	Barcode      *BarcodeFieldConfig `json:"barcode,omitempty"`  // drawing options for fields of type "barcode"
+	Required     *bool               `json:"required,omitempty"` // false makes the field optional; fields are required by default
+	Default      interface{}         `json:"default,omitempty"`  // used when the field is left empty
+	Min          interface{}         `json:"min,omitempty"`      // smallest number, or earliest date as YYYY-MM-DD
+	Max          interface{}         `json:"max,omitempty"`      // largest number, or latest date as YYYY-MM-DD
+	Options      []FieldOption       `json:"options,omitempty"`  // choices of enum and select fields
 }
+// DownloadConfig controls the signed links handed out for rendered posters.
+type DownloadConfig struct {
+	Signer  *urlsign.Signer
+	BaseURL string        // prefix of the file route, e.g. "/posters"
+	TTL     time.Duration // how long a link stays valid
+}
+
 type PosterSubService interface {
 	GeneratePoster(ctx context.Context, templateID uint, input *dto.PosterInput) (*dto.PosterResponse, error)
-	GetPosterByID(ctx context.Context, id uint) (*dto.PosterResponse, error)
+	// GetPosterByID returns a poster to whoever holds its access token; IDs are sequential, so the ID alone is not enough.
+	GetPosterByID(ctx context.Context, id uint, token string) (*dto.PosterResponse, error)
+	// RenderPoster renders a pending poster from its stored request; called by render workers.
+	RenderPoster(ctx context.Context, posterID uint) error
+	// RekeyLegacyFiles moves files stored under old name-based keys to random keys and returns how many
+	// posters were (or, with dryRun, would be) updated.
+	RekeyLegacyFiles(ctx context.Context, dryRun bool) (int, error)
+	// PreviewPoster renders a poster as HTML or a low-resolution image without storing a file or a poster row.
+	PreviewPoster(ctx context.Context, templateID uint, input *dto.PosterInput, format string) (*dto.PosterPreview, error)
+	// OpenPosterFile verifies a signed download link and opens the file it points to. The caller closes the body.
+	OpenPosterFile(ctx context.Context, key string, params url.Values) (*storage.Object, error)
+	// RegeneratePoster renders a stored poster's request again as a new poster, with the template and
+	// layout revisions of the original unless input picks another template revision.
+	RegeneratePoster(ctx context.Context, posterID uint, input *dto.RegenerateInput) (*dto.PosterResponse, error)
 }
 
 type posterSubService struct {
@@ -45,8 +76,16 @@ type posterSubService struct {
 	assetRepo    repositories.AssetRepository
 	validator    *validators.Validator
 	log          logger.Logger
-	templatesDir string
-	outputDir    string
+	renderers     *renderer.Registry
+	renderTimeout time.Duration
+	renderQueue   RenderQueue
+	templatesDir  string
+	files         storage.Storage
+	downloads     DownloadConfig
+	cache         *renderCache
+	fonts         FontSubService
+	links         repositories.ShortLinkRepository
+	scans         ScanLinkConfig
 }
 
 func NewPosterSubService(
@@ -56,11 +95,42 @@ func NewPosterSubService(
 	assetRepo repositories.AssetRepository,
 	validator *validators.Validator,
 	log logger.Logger,
+	renderers *renderer.Registry,
+	renderTimeout time.Duration,
+	renderQueue RenderQueue,
 	templatesDir string,
-	outputDir string,
+	files storage.Storage,
+	downloads DownloadConfig,
+	cacheRepo repositories.RenderCacheRepository,
+	cacheCfg RenderCacheConfig,
+	fonts FontSubService,
+	links repositories.ShortLinkRepository,
+	scans ScanLinkConfig,
 ) PosterSubService {
+	return newPosterSubService(repo, templateRepo, layoutRepo, assetRepo, validator, log, renderers, renderTimeout, renderQueue, templatesDir, files, downloads, cacheRepo, cacheCfg, fonts, links, scans)
+}
+
+// newPosterSubService returns the concrete service so other sub-services in this package can share its rendering helpers.
+func newPosterSubService(
+	repo repositories.PosterSubRepository,
+	templateRepo repositories.PosterTemplateRepository,
+	layoutRepo repositories.LayoutRepository,
+	assetRepo repositories.AssetRepository,
+	validator *validators.Validator,
+	log logger.Logger,
+	renderers *renderer.Registry,
+	renderTimeout time.Duration,
+	renderQueue RenderQueue,
+	templatesDir string,
+	files storage.Storage,
+	downloads DownloadConfig,
+	cacheRepo repositories.RenderCacheRepository,
+	cacheCfg RenderCacheConfig,
+	fonts FontSubService,
+	links repositories.ShortLinkRepository,
+	scans ScanLinkConfig,
+) *posterSubService {
 	os.MkdirAll(templatesDir, 0755)
-	os.MkdirAll(outputDir, 0755)
 
 	return &posterSubService{
 		repo:         repo,
@@ -69,65 +139,190 @@ func NewPosterSubService(
 		assetRepo:    assetRepo,
 		validator:    validator,
 		log:          log,
+		renderers:     renderers,
+		renderTimeout: renderTimeout,
+		renderQueue:   renderQueue,
 		templatesDir: templatesDir,
-		outputDir:    outputDir,
+		files:         files,
+		downloads:     downloads,
+		cache:         newRenderCache(cacheRepo, cacheCfg, log),
+		fonts:         fonts,
+		links:         links,
+		scans:         scans,
 	}
 }
 
 func (s *posterSubService) GeneratePoster(ctx context.Context, templateID uint, input *dto.PosterInput) (*dto.PosterResponse, error) {
 	s.log.Info("Generating poster with dynamic template", "template_id", templateID)
 
+	templateRecord, finalTemplateData, err := s.prepareTemplateData(ctx, templateID, input)
+	if err != nil {
+		return nil, err
+	}
+	return s.createPoster(ctx, templateRecord, input, finalTemplateData)
+}
+
+// createPoster saves a pending poster and renders it, or queues it when input.Async is set.
+func (s *posterSubService) createPoster(ctx context.Context, templateRecord *models.PosterTemplate, input *dto.PosterInput, finalTemplateData map[string]interface{}) (*dto.PosterResponse, error) {
+	poster, err := s.newPendingPoster(templateRecord, input, finalTemplateData)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreatePoster(ctx, poster); err != nil {
+		s.log.Error("Failed to save poster to database", err)
+		return nil, errors.DatabaseError("failed to save poster", err)
+	}
+
+	if input.Async {
+		if err := s.renderQueue.Enqueue(ctx, poster.ID); err != nil {
+			s.log.Error("Failed to queue poster for rendering", err, "poster_id", poster.ID)
+			return nil, s.failPoster(ctx, poster, enqueueError(err))
+		}
+		s.log.Info("Poster queued for rendering", "poster_id", poster.ID)
+		return s.toPosterResponse(ctx, poster), nil
+	}
+
+	if err := s.renderPoster(ctx, poster, templateRecord, input, finalTemplateData); err != nil {
+		return nil, err
+	}
+	return s.toPosterResponse(ctx, poster), nil
+}
+
+// enqueueError reports a full queue as back-pressure the client may retry, and anything else as
+// a failure of the queue itself.
+func enqueueError(err error) error {
+	var appErr errors.AppError
+	switch {
+	case stdErrors.Is(err, ErrRenderQueueFull):
+		return errors.ServiceUnavailableError("render queue is busy, please retry shortly", err)
+	case stdErrors.As(err, &appErr):
+		return err
+	default:
+		return errors.InternalServerError("failed to queue poster for rendering", err)
+	}
+}
+
+// RenderPoster loads a pending or interrupted poster, rebuilds its template data from the stored request and renders it.
+func (s *posterSubService) RenderPoster(ctx context.Context, posterID uint) error {
+	poster, err := s.repo.GetPosterByID(ctx, posterID) // Preloads PosterTemplate.Layout
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			s.log.Warn("Queued poster no longer exists", "poster_id", posterID)
+			return errors.NotFoundError("poster not found", err)
+		}
+		return errors.DatabaseError("failed to retrieve poster", err)
+	}
+	// A poster left processing by a worker that died is rendered again; finished ones are not.
+	if poster.Status == models.PosterStatusCompleted || poster.Status == models.PosterStatusFailed {
+		s.log.Warn("Skipping render of poster that already finished", "poster_id", posterID, "status", poster.Status)
+		return nil
+	}
+
+	var input dto.PosterInput
+	if err := json.Unmarshal(poster.RequestPayload, &input); err != nil {
+		return s.failPoster(ctx, poster, errors.InternalServerError("stored poster request is invalid", err))
+	}
+	// Render with the revisions recorded when the poster was created, even if the template changed since.
+	templateRecord := &poster.PosterTemplate
+	if poster.TemplateRevision > 0 {
+		templateRecord, err = s.templateAtRevision(ctx, poster.PosterTemplateID, poster.TemplateRevision, poster.LayoutRevision)
+		if err != nil {
+			return s.failPoster(ctx, poster, err)
+		}
+	}
+	finalTemplateData, err := s.buildTemplateData(ctx, templateRecord, &input)
+	if err != nil {
+		return s.failPoster(ctx, poster, err)
+	}
+	return s.renderPoster(ctx, poster, templateRecord, &input, finalTemplateData)
+}
+
+// prepareTemplateData validates the request, loads the template and builds the layout data.
+// Generation and previews both go through it so they accept and reject the same input.
+func (s *posterSubService) prepareTemplateData(ctx context.Context, templateID uint, input *dto.PosterInput) (*models.PosterTemplate, map[string]interface{}, error) {
 	if validationErrors := s.validator.Struct(input); validationErrors != nil {
-		return nil, errors.ValidationError("invalid poster input", nil, validationErrors)
+		return nil, nil, errors.ValidationError("invalid poster input", nil, validationErrors)
 	}
 	templateRecord, err := s.templateRepo.GetTemplateByID(ctx, templateID)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return nil, errors.NotFoundError("template not found", err)
+			return nil, nil, errors.NotFoundError("template not found", err)
 		}
 		s.log.Error("Failed to retrieve template", err, "template_id", templateID)
-		return nil, errors.DatabaseError("failed to retrieve template", err)
+		return nil, nil, errors.DatabaseError("failed to retrieve template", err)
 	}
-	if templateRecord.Layout.FilePath == "" {
+	if templateRecord.Layout.FilePath == "" && templateRecord.Layout.Content == "" {
 		s.log.Error("Layout information missing or invalid for template", nil, "template_id", templateID, "layout_id", templateRecord.LayoutID)
-		return nil, errors.InternalServerError("template configuration incomplete: layout file path missing", nil)
+		return nil, nil, errors.InternalServerError("template configuration incomplete: layout missing", nil)
 	}
-	var requiredFields []RequiredFieldConfig
-	if err := json.Unmarshal(templateRecord.RequiredFields, &requiredFields); err != nil {
-		s.log.Error("Failed to parse required_fields JSON from template", err, "template_id", templateID)
-		return nil, errors.InternalServerError("template configuration error: invalid required fields", err)
+	finalTemplateData, err := s.buildTemplateData(ctx, templateRecord, input)
+	if err != nil {
+		return nil, nil, err
+	}
+	return templateRecord, finalTemplateData, nil
+}
+
+// buildTemplateData validates the user data against the template's fields and merges it with the
+// template defaults, customization overrides and resolved assets into the data passed to the layout.
+func (s *posterSubService) buildTemplateData(ctx context.Context, templateRecord *models.PosterTemplate, input *dto.PosterInput) (map[string]interface{}, error) {
+	requiredFields, err := s.requiredFields(templateRecord)
+	if err != nil {
+		return nil, err
 	}
 
+	schema, err := templateSchema(templateRecord)
+	if err != nil {
+		s.log.Error("Failed to build input schema for template", err, "template_id", templateRecord.ID)
+		return nil, errors.InternalServerError("template configuration error: invalid input schema", err)
+	}
+
+	// The data and customization are checked against the same schema the template's form is
+	// built from (see GetTemplateSchema) and coerced to their types, with field defaults filled
+	// in. Keys the schema does not describe pass through unchanged, unless they would override a
+	// customization key or one the service sets: the layout gets data last.
 	validationErrors := make(map[string]string)
+	values := applyProperties(schema.Properties.Get("data"), input.Data, "", validationErrors)
+	reserved := reservedDataKeys(templateRecord)
+	for key := range input.Data {
+		if schema.Properties.Get("data").Properties.Get(key) != nil {
+			continue
+		}
+		switch reserved[key] {
+		case reservedForCustomization:
+			validationErrors[key] = fmt.Sprintf("%s is a customization setting; send it in customization_data.", key)
+		case reservedForService:
+			validationErrors[key] = fmt.Sprintf("%s is set by the server and cannot be sent in data.", key)
+		}
+	}
 	for _, fieldConfig := range requiredFields {
-		fieldName := fieldConfig.Name
-		userValue, exists := input.Data[fieldName]
-
-		if !exists || userValue == nil || fmt.Sprintf("%v", userValue) == "" {
-			// Basic required check (can be enhanced if templates define optional fields)
-			validationErrors[fieldName] = fmt.Sprintf("%s is required.", fieldConfig.Label)
-			continue // Skip further checks if missing
+		value, ok := values[fieldConfig.Name]
+		if !ok {
+			continue
 		}
-
-		// Ensure value is treated as a string for validation checks
-		userValueStr := fmt.Sprintf("%v", userValue)
-
-		// MaxLength Check (using rune count for UTF-8 safety)
-		if fieldConfig.MaxLength > 0 && utf8.RuneCountInString(userValueStr) > fieldConfig.MaxLength {
-			validationErrors[fieldName] = fmt.Sprintf("%s cannot exceed %d characters.", fieldConfig.Label, fieldConfig.MaxLength)
+		finished, message := finishField(fieldConfig, value)
+		if message != "" {
+			validationErrors[fieldConfig.Name] = message
+			continue
 		}
-
-		// Pattern Check
-		if fieldConfig.Pattern != "" {
-			matched, _ := regexp.MatchString(fieldConfig.Pattern, userValueStr)
-			if !matched {
-				errorMsg := "Invalid format."
-				if fieldConfig.PatternTitle != "" {
-					errorMsg = fieldConfig.PatternTitle
-				}
-				validationErrors[fieldName] = fmt.Sprintf("%s: %s", fieldConfig.Label, errorMsg)
+		values[fieldConfig.Name] = finished
+	}
+	var customization map[string]interface{}
+	if input.CustomizationData != nil {
+		customization = applyProperties(schema.Properties.Get("customization_data"), input.CustomizationData, "customization_data", validationErrors)
+		for key := range customization {
+			// Defaults stay out: the layout gets them anyway, and a key counts as set by the user
+			// when choosing the logo's default colour.
+			if _, sent := input.CustomizationData[key]; !sent {
+				delete(customization, key)
 			}
 		}
+		options, err := decodeCustomizationOptions(templateRecord.CustomizationOptions)
+		if err != nil {
+			return nil, errors.InternalServerError("template configuration error: invalid customization options", err)
+		}
+		if err := s.finishCustomization(ctx, options, customization, validationErrors); err != nil {
+			return nil, err
+		}
 	}
 
 	// If any validation errors occurred, return them immediately
@@ -140,6 +335,25 @@ func (s *posterSubService) GeneratePoster(ctx context.Context, templateID uint,
 		}
 		return nil, errors.ValidationError("invalid input data provided", nil, errorDetails)
 	}
+	coerced := *input
+	coerced.Data = values
+	coerced.CustomizationData = customization
+	return s.mergeTemplateData(ctx, templateRecord, &coerced)
+}
+
+// requiredFields parses the field definitions stored on a template.
+func (s *posterSubService) requiredFields(templateRecord *models.PosterTemplate) ([]RequiredFieldConfig, error) {
+	var requiredFields []RequiredFieldConfig
+	if err := json.Unmarshal(templateRecord.RequiredFields, &requiredFields); err != nil {
+		s.log.Error("Failed to parse required_fields JSON from template", err, "template_id", templateRecord.ID)
+		return nil, errors.InternalServerError("template configuration error: invalid required fields", err)
+	}
+	return requiredFields, nil
+}
+
+// mergeTemplateData combines template defaults, customization overrides, the resolved logo and
+// the user data into the data passed to the layout. input.Data must already be validated.
+func (s *posterSubService) mergeTemplateData(ctx context.Context, templateRecord *models.PosterTemplate, input *dto.PosterInput) (map[string]interface{}, error) {
 	finalTemplateData := make(map[string]interface{})
 
 	var baseCustomization map[string]interface{}
@@ -197,7 +411,11 @@ func (s *posterSubService) GeneratePoster(ctx context.Context, templateID uint,
 		for key, value := range input.Data {
 			finalTemplateData[key] = value
 			if strings.HasSuffix(key, "_number") {
-				if strValue, ok := value.(string); ok && strValue != "" {
+				strValue, ok := value.(string)
+				if number, isNumber := value.(float64); isNumber {
+					strValue, ok = formatNumber(number), true
+				}
+				if ok && strValue != "" {
 					finalTemplateData[key+"Split"] = strings.Split(strValue, "")
 				} else {
 					finalTemplateData[key+"Split"] = []string{}
@@ -208,16 +426,11 @@ func (s *posterSubService) GeneratePoster(ctx context.Context, templateID uint,
 
 	finalTemplateData["business_name"] = input.BusinessName
 
-	htmlContent, err := s.renderHTMLTemplate(finalTemplateData, templateRecord.Layout.FilePath)
-	if err != nil {
-		return nil, errors.InternalServerError("failed to render template", err)
-	}
-
-	pdfPath, err := s.renderToPDF(ctx, htmlContent, input.BusinessName, make(map[string]interface{}))
-	if err != nil {
-		return nil, errors.InternalServerError("failed to generate PDF", err)
-	}
+	return finalTemplateData, nil
+}
 
+// newPendingPoster builds the poster row stored before rendering starts.
+func (s *posterSubService) newPendingPoster(templateRecord *models.PosterTemplate, input *dto.PosterInput, finalTemplateData map[string]interface{}) (*models.Poster, error) {
 	userInputDataJSON, err := json.Marshal(input.Data)
 	if err != nil {
 		return nil, errors.InternalServerError("failed to marshal user input data", err)
@@ -227,99 +440,253 @@ func (s *posterSubService) GeneratePoster(ctx context.Context, templateID uint,
 		s.log.Error("Failed to marshal final customization data", err, "data", finalTemplateData)
 		return nil, errors.InternalServerError("failed to marshal final customization data", err)
 	}
+	requestJSON, err := json.Marshal(input)
+	if err != nil {
+		return nil, errors.InternalServerError("failed to marshal poster request", err)
+	}
 
-	poster := &models.Poster{
-		PosterTemplateID:   templateID,
+	outputFormat := renderer.FormatPDF
+	if input.OutputFormat != "" {
+		outputFormat = renderer.Format(input.OutputFormat)
+	}
+	accessToken, err := newAccessToken()
+	if err != nil {
+		return nil, errors.InternalServerError("failed to create poster access token", err)
+	}
+	return &models.Poster{
+		PosterTemplateID:   templateRecord.ID,
 		BusinessName:       input.BusinessName,
 		UserInputData:      datatypes.JSON(userInputDataJSON),
 		FinalCustomization: datatypes.JSON(finalCustomizationJSON),
-		PDFURL:             pdfPath,
-		Status:             "completed",
+		RequestPayload:     datatypes.JSON(requestJSON),
+		OutputFormat:       string(outputFormat),
+		Status:             models.PosterStatusPending,
+		TemplateRevision:   templateRecord.Revision,
+		LayoutRevision:     templateRecord.Layout.Revision,
+		AccessToken:        accessToken,
+	}, nil
+}
+
+// renderPoster moves a saved poster through processing to completed or failed.
+func (s *posterSubService) renderPoster(ctx context.Context, poster *models.Poster, templateRecord *models.PosterTemplate, input *dto.PosterInput, finalTemplateData map[string]interface{}) error {
+	poster.Status = models.PosterStatusProcessing
+	if err := s.repo.UpdatePoster(ctx, poster); err != nil {
+		s.log.Error("Failed to mark poster as processing", err, "poster_id", poster.ID)
+		return errors.DatabaseError("failed to update poster", err)
 	}
 
-	if err := s.repo.CreatePoster(ctx, poster); err != nil {
-		s.log.Error("Failed to save poster to database", err)
-		return nil, errors.DatabaseError("failed to save poster", err)
+	layoutContent, err := s.readLayout(&templateRecord.Layout)
+	if err != nil {
+		return s.failPoster(ctx, poster, errors.InternalServerError("failed to render template", err))
 	}
 
-	return &dto.PosterResponse{
-		ID:           poster.ID,
-		TemplateID:   poster.PosterTemplateID,
-		BusinessName: poster.BusinessName,
-		PDFURL:       poster.PDFURL,
-		Status:       poster.Status,
-	}, nil
+	renderData, err := s.withInlineAssets(ctx, templateRecord, finalTemplateData, poster.ID)
+	if err != nil {
+		return s.failPoster(ctx, poster, err)
+	}
+
+	outputFormat := renderer.Format(poster.OutputFormat)
+	renderRequest := renderer.Request{
+		Format:    outputFormat,
+		DPI:       input.DPI,
+		Width:     input.Width,
+		Height:    input.Height,
+		OnBlocked: s.logBlockedRequest(poster.ID, templateRecord.ID),
+	}
+
+	// Identical layout, data and output options produce identical bytes, so reuse an earlier file.
+	var cacheKey string
+	if s.cache.enabled() {
+		cacheKey, err = s.cache.fingerprint(layoutContent, renderData, renderRequest, templateRecord.Layout.Renderer)
+		if err != nil {
+			s.log.Warn("Could not fingerprint poster for the render cache", "poster_id", poster.ID, "error", err)
+		} else if !input.BypassCache {
+			if storageKey, ok := s.cache.lookup(ctx, cacheKey); ok {
+				s.log.Info("Reusing cached render", "poster_id", poster.ID, "key", storageKey)
+				return s.completePoster(ctx, poster, storageKey, true)
+			}
+		}
+	}
+
+	renderRequest.HTML, err = s.executeLayout(ctx, &templateRecord.Layout, layoutContent, renderData)
+	if err != nil {
+		return s.failPoster(ctx, poster, errors.InternalServerError("failed to render template", err))
+	}
+	storageKey, err := s.renderDocument(ctx, renderRequest, templateRecord.Layout.Renderer)
+	if err != nil {
+		return s.failPoster(ctx, poster, renderError(err, outputFormat))
+	}
+	if cacheKey != "" {
+		s.cache.store(ctx, cacheKey, storageKey, outputFormat)
+	}
+	return s.completePoster(ctx, poster, storageKey, false)
 }
 
-func (s *posterSubService) renderHTMLTemplate(data map[string]interface{}, layoutFilePath string) (string, error) {
-	templatePath := filepath.Join(s.templatesDir, layoutFilePath)
-	templateBytes, err := os.ReadFile(templatePath)
+// withInlineAssets returns a copy of data with the @font-face rules for the selected font family and
+// the codes of qr and barcode fields inlined. They are kept out of the stored poster data because they
+// are large and can always be derived again. posterID is 0 for previews and thumbnails, which are never tracked.
+func (s *posterSubService) withInlineAssets(ctx context.Context, templateRecord *models.PosterTemplate, data map[string]interface{}, posterID uint) (map[string]interface{}, error) {
+	family, _ := data[FontFamilyField].(string)
+	css, err := s.fonts.FontFaceCSS(ctx, family)
+	if err != nil {
+		return nil, err
+	}
+	renderData := make(map[string]interface{}, len(data)+1)
+	for key, value := range data {
+		renderData[key] = value
+	}
+	renderData[FontFaceField] = css
+
+	requiredFields, err := s.requiredFields(templateRecord)
 	if err != nil {
-		s.log.Error("Failed to read template file", err, "path", templatePath)
-		return "", fmt.Errorf("failed to read template file %s: %w", templatePath, err)
+		return nil, err
+	}
+	if err := s.withQRCodes(ctx, requiredFields, renderData, posterID); err != nil {
+		return nil, errors.InternalServerError("failed to render template", err)
+	}
+	if err := withBarcodes(requiredFields, renderData); err != nil {
+		return nil, errors.InternalServerError("failed to render template", err)
 	}
-	tmpl, err := template.New(filepath.Base(layoutFilePath)).Funcs(template.FuncMap{
-		"safeHTML": func(s string) template.HTML { return template.HTML(s) },
-	}).Parse(string(templateBytes))
+	return renderData, nil
+}
+
+// logBlockedRequest reports URLs the renderer refused to load. They usually mean a layout or
+// asset references an external resource, and occasionally that someone probes internal URLs.
+func (s *posterSubService) logBlockedRequest(posterID, templateID uint) func(string) {
+	return func(url string) {
+		s.log.Warn("Blocked network request during render", "poster_id", posterID, "template_id", templateID, "url", url)
+	}
+}
+
+// renderError maps a renderer failure to the error reported to clients.
+func renderError(err error, format renderer.Format) error {
+	if stdErrors.Is(err, renderer.ErrTimeout) {
+		return errors.TimeoutError("poster rendering timed out", err)
+	}
+	if stdErrors.Is(err, renderer.ErrUnsupportedFormat) {
+		return errors.ValidationError("output format not supported for this template", err, map[string]string{"output_format": fmt.Sprintf("%s output is not available for this template", format)})
+	}
+	return errors.InternalServerError("failed to generate poster", err)
+}
+
+// completePoster points the poster at its rendered file and marks it completed.
+func (s *posterSubService) completePoster(ctx context.Context, poster *models.Poster, storageKey string, fromCache bool) error {
+	poster.PDFURL = storageKey
+	poster.RenderCacheHit = fromCache
+	poster.Status = models.PosterStatusCompleted
+	poster.ErrorReason = ""
+	if err := s.repo.UpdatePoster(ctx, poster); err != nil {
+		s.log.Error("Failed to save rendered poster", err, "poster_id", poster.ID)
+		return errors.DatabaseError("failed to save poster", err)
+	}
+	s.log.Info("Poster rendered", "poster_id", poster.ID, "key", storageKey, "cached", fromCache)
+	return nil
+}
+
+// failPoster records why a poster could not be rendered and returns cause for the caller.
+// The status write ignores ctx cancellation so a client disconnect still leaves a final state.
+func (s *posterSubService) failPoster(ctx context.Context, poster *models.Poster, cause error) error {
+	reason := cause.Error()
+	var appErr errors.AppError
+	if stdErrors.As(cause, &appErr) {
+		reason = appErr.Message()
+	}
+	poster.Status = models.PosterStatusFailed
+	poster.ErrorReason = reason
+	if err := s.repo.UpdatePoster(context.WithoutCancel(ctx), poster); err != nil {
+		s.log.Error("Failed to mark poster as failed", err, "poster_id", poster.ID)
+	}
+	s.log.Warn("Poster rendering failed", "poster_id", poster.ID, "reason", reason)
+	return cause
+}
+
+func (s *posterSubService) renderHTMLTemplate(ctx context.Context, data map[string]interface{}, layout *models.Layout) (string, error) {
+	templateBytes, err := s.readLayout(layout)
+	if err != nil {
+		return "", err
+	}
+	return s.executeLayout(ctx, layout, templateBytes, data)
+}
+
+// readLayout loads the uploaded HTML of a layout, or its file from the templates directory.
+func (s *posterSubService) readLayout(layout *models.Layout) ([]byte, error) {
+	templateBytes, err := readLayoutSource(s.templatesDir, layout)
+	if err != nil {
+		s.log.Error("Failed to read layout", err, "layout_id", layout.ID, "path", layout.FilePath)
+		return nil, fmt.Errorf("failed to read layout %s: %w", layoutSourceName(layout), err)
+	}
+	return templateBytes, nil
+}
+
+// executeLayout parses the layout source and executes it with data.
+func (s *posterSubService) executeLayout(ctx context.Context, layout *models.Layout, templateBytes []byte, data map[string]interface{}) (string, error) {
+	name := layoutSourceName(layout)
+	tmpl, err := template.New(filepath.Base(name)).Funcs(s.layoutFuncs(ctx, data)).Parse(string(templateBytes))
 	if err != nil {
-		s.log.Error("Failed to parse HTML template", err, "path", templatePath)
-		return "", fmt.Errorf("failed to parse template %s: %w", layoutFilePath, err)
+		s.log.Error("Failed to parse HTML template", err, "layout", name)
+		return "", fmt.Errorf("failed to parse template %s: %w", name, err)
 	}
 	var buf bytes.Buffer
 	if err := tmpl.Execute(&buf, data); err != nil {
-		s.log.Error("Failed to execute HTML template", err, "path", templatePath)
-		return "", fmt.Errorf("failed to execute template %s: %w", layoutFilePath, err)
+		s.log.Error("Failed to execute HTML template", err, "layout", name)
+		return "", fmt.Errorf("failed to execute template %s: %w", name, err)
 	}
 	return buf.String(), nil
 }
 
-func (s *posterSubService) renderToPDF(ctx context.Context, htmlContent string, businessName string, templateData map[string]interface{}) (string, error) {
-	ctx, cancel := chromedp.NewContext(context.Background())
-	defer cancel()
-	var pdfBuffer []byte
-	safeBusinessName := strings.ReplaceAll(businessName, " ", "_")
-	pdfPath := filepath.Join(s.outputDir, fmt.Sprintf("%s_%d.pdf", safeBusinessName, time.Now().Unix()))
-	err := chromedp.Run(ctx,
-		chromedp.Navigate("about:blank"),
-		chromedp.ActionFunc(func(ctx context.Context) error {
-			frameTree, err := page.GetFrameTree().Do(ctx)
-			if err != nil {
-				return err
-			}
-			return page.SetDocumentContent(frameTree.Frame.ID, htmlContent).Do(ctx)
-		}),
-		chromedp.Evaluate(`new Promise(resolve => {
-            if (document.readyState === 'complete') { resolve(); }
-            else { window.addEventListener('load', resolve); }
-        })`, nil),
-		chromedp.ActionFunc(func(ctx context.Context) error {
-			printParams := page.PrintToPDF().
-				WithPreferCSSPageSize(true).
-				WithPrintBackground(true).
-				WithMarginTop(0).WithMarginBottom(0).WithMarginLeft(0).WithMarginRight(0)
-
-			buf, _, err := printParams.Do(ctx)
-			if err != nil {
-				return err
-			}
-			pdfBuffer = buf
-			return nil
-		}),
-	)
+// layoutFuncs returns the functions available to layouts: the tmplfuncs library plus the
+// ones below. Some of them read the render data, such as qrcode picking up primary_color,
+// so the map is built for each execution.
+func (s *posterSubService) layoutFuncs(ctx context.Context, data map[string]interface{}) template.FuncMap {
+	funcs := tmplfuncs.Map()
+	funcs["safeHTML"] = func(s string) template.HTML { return template.HTML(s) }
+	funcs["qrcode"] = s.qrcodeFunc(ctx, data)
+	funcs["keqr"] = s.keqrFunc(data)
+	funcs["barcode"] = barcodeFunc
+	return funcs
+}
+
+// renderDocument renders the HTML with the layout's backend and stores the result under a new random key.
+// Keys never contain user input; the readable name is only added to the download link.
+func (s *posterSubService) renderDocument(ctx context.Context, req renderer.Request, backend string) (string, error) {
+	buf, err := s.renderBytes(ctx, req, backend)
 	if err != nil {
-		s.log.Error("Chromedp PDF generation failed", err)
-		return "", fmt.Errorf("chromedp failed: %w", err)
+		return "", err
 	}
-	if err := os.WriteFile(pdfPath, pdfBuffer, 0644); err != nil {
-		s.log.Error("Failed to write PDF file", err, "path", pdfPath)
-		return "", fmt.Errorf("failed to write PDF file: %w", err)
+	key := newStorageKey(req.Format.Extension())
+	if err := s.files.Put(ctx, key, bytes.NewReader(buf), int64(len(buf)), req.Format.ContentType()); err != nil {
+		s.log.Error("Failed to store poster file", err, "key", key)
+		return "", fmt.Errorf("failed to store poster file: %w", err)
 	}
-	s.log.Info("PDF generated successfully using CSS @page", "path", pdfPath)
-	return pdfPath, nil
+	s.log.Info("Poster generated successfully", "key", key, "backend", backend, "format", req.Format)
+	return key, nil
+}
+
+// renderBytes renders the HTML with the layout's backend without storing the result.
+func (s *posterSubService) renderBytes(ctx context.Context, req renderer.Request, backend string) ([]byte, error) {
+	r, err := s.renderers.Get(backend)
+	if err != nil {
+		s.log.Error("No renderer available for layout", err, "backend", backend)
+		return nil, err
+	}
+
+	// Bound the render by the request context and the configured timeout; when either ends
+	// the renderer abandons the browser tab instead of keeping Chrome busy.
+	renderCtx, cancel := ctx, context.CancelFunc(func() {})
+	if s.renderTimeout > 0 {
+		renderCtx, cancel = context.WithTimeout(ctx, s.renderTimeout)
+	}
+	defer cancel()
+	buf, err := r.Render(renderCtx, req)
+	if err != nil {
+		s.log.Error("Poster rendering failed", err, "renderer", r.Name(), "format", req.Format)
+		return nil, err
+	}
+	return buf, nil
 }
 
 // GetPosterByID uses the correct PosterRepository interface.
-func (s *posterSubService) GetPosterByID(ctx context.Context, id uint) (*dto.PosterResponse, error) {
+func (s *posterSubService) GetPosterByID(ctx context.Context, id uint, token string) (*dto.PosterResponse, error) {
 	s.log.Info("Getting poster by ID", "poster_id", id)
 	poster, err := s.repo.GetPosterByID(ctx, id) // Use s.repo (PosterRepository)
 	if err != nil {
@@ -330,11 +697,124 @@ func (s *posterSubService) GetPosterByID(ctx context.Context, id uint) (*dto.Pos
 		s.log.Error("Failed to get poster by ID", err, "poster_id", id)
 		return nil, errors.DatabaseError("failed to retrieve poster", err)
 	}
+	// A wrong token looks the same as a missing poster, so IDs cannot be probed.
+	if !validAccessToken(poster, token) {
+		s.log.Warn("Rejected poster lookup with invalid access token", "poster_id", id)
+		return nil, errors.NotFoundError("poster not found", nil)
+	}
+	return s.toPosterResponse(ctx, poster), nil
+}
+
+func (s *posterSubService) OpenPosterFile(ctx context.Context, key string, params url.Values) (*storage.Object, error) {
+	if err := s.downloads.Signer.Verify(key, params); err != nil {
+		s.log.Warn("Rejected poster download", "key", key, "reason", err.Error())
+		if stdErrors.Is(err, urlsign.ErrExpired) {
+			return nil, errors.AuthorizationError("download link has expired, request a new one", err)
+		}
+		return nil, errors.AuthorizationError("a valid signed download link is required", err)
+	}
+
+	obj, err := s.files.Get(ctx, key)
+	if err != nil {
+		if stdErrors.Is(err, storage.ErrNotFound) || stdErrors.Is(err, storage.ErrInvalidKey) {
+			return nil, errors.NotFoundError("poster file not found", err)
+		}
+		s.log.Error("Failed to open poster file", err, "key", key)
+		return nil, errors.InternalServerError("failed to open poster file", err)
+	}
+	return obj, nil
+}
+
+func (s *posterSubService) RekeyLegacyFiles(ctx context.Context, dryRun bool) (int, error) {
+	posters, err := s.repo.ListPostersWithFiles(ctx)
+	if err != nil {
+		return 0, errors.DatabaseError("failed to list posters", err)
+	}
+
+	moved := 0
+	for _, poster := range posters {
+		oldKey := poster.PDFURL
+		if isGeneratedStorageKey(oldKey) {
+			continue
+		}
+		ext := strings.TrimPrefix(path.Ext(oldKey), ".")
+		if ext == "" {
+			ext = renderer.Format(poster.OutputFormat).Extension()
+		}
+		newKey := newStorageKey(ext)
+		s.log.Info("Rekeying poster file", "poster_id", poster.ID, "from", oldKey, "to", newKey, "dry_run", dryRun)
+		if dryRun {
+			moved++
+			continue
+		}
+
+		// Copy, point the row at the copy, then delete the original, so a failure at any
+		// step leaves the poster pointing at a file that exists.
+		if err := s.copyFile(ctx, oldKey, newKey); err != nil {
+			s.log.Error("Failed to copy poster file, leaving it under its old key", err, "poster_id", poster.ID, "key", oldKey)
+			continue
+		}
+		poster.PDFURL = newKey
+		if err := s.repo.UpdatePoster(ctx, poster); err != nil {
+			_ = s.files.Delete(ctx, newKey)
+			return moved, errors.DatabaseError("failed to update poster file key", err)
+		}
+		if err := s.files.Delete(ctx, oldKey); err != nil {
+			s.log.Warn("Failed to delete old poster file", "key", oldKey, "error", err)
+		}
+		moved++
+	}
+	return moved, nil
+}
+
+func (s *posterSubService) copyFile(ctx context.Context, fromKey, toKey string) error {
+	obj, err := s.files.Get(ctx, fromKey)
+	if err != nil {
+		return err
+	}
+	defer obj.Body.Close()
+	contentType := obj.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(toKey))
+	}
+	return s.files.Put(ctx, toKey, obj.Body, obj.Size, contentType)
+}
+
+// signedDownloadURL returns a link to the file route for key that expires after the configured TTL.
+// The suggested filename travels in the signed "name" parameter, so it cannot be swapped by the client.
+func (s *posterSubService) signedDownloadURL(key, filename string) (string, time.Time) {
+	expiresAt := time.Now().Add(s.downloads.TTL)
+	params := s.downloads.Signer.Sign(key, url.Values{DownloadNameParam: {filename}}, s.downloads.TTL)
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.TrimRight(s.downloads.BaseURL, "/") + "/" + strings.Join(segments, "/") + "?" + params.Encode(), expiresAt
+}
+
+// toPosterResponse turns the stored key into a signed download URL; pending posters have neither.
+func (s *posterSubService) toPosterResponse(ctx context.Context, poster *models.Poster) *dto.PosterResponse {
+	var downloadURL string
+	var expiresAt *time.Time
+	if poster.PDFURL != "" {
+		link, expiry := s.signedDownloadURL(poster.PDFURL, DownloadFilename(poster.BusinessName, path.Ext(poster.PDFURL)))
+		downloadURL, expiresAt = link, &expiry
+	}
 	return &dto.PosterResponse{
 		ID:           poster.ID,
+		AccessToken:  poster.AccessToken,
 		TemplateID:   poster.PosterTemplateID,
 		BusinessName: poster.BusinessName,
-		PDFURL:       poster.PDFURL,
+		PDFURL:       downloadURL,
+		URLExpiresAt: expiresAt,
+		StorageKey:   poster.PDFURL,
+		Cached:       poster.RenderCacheHit,
+		OutputFormat: poster.OutputFormat,
 		Status:       poster.Status,
-	}, nil
+		ErrorReason:  poster.ErrorReason,
+		TemplateRevision: poster.TemplateRevision,
+		LayoutRevision:   poster.LayoutRevision,
+		CreatedAt:    poster.CreatedAt,
+		UpdatedAt:    poster.UpdatedAt,
+	}
 }
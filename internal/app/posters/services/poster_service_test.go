@@ -0,0 +1,267 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	stdErrors "errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codetheuri/poster-gen/internal/app/posters/handlers/dto"
+	"github.com/codetheuri/poster-gen/internal/app/posters/models"
+	"github.com/codetheuri/poster-gen/internal/app/posters/repositories"
+	"github.com/codetheuri/poster-gen/pkg/errors"
+	"github.com/codetheuri/poster-gen/pkg/logger"
+	"github.com/codetheuri/poster-gen/pkg/renderer"
+	"github.com/codetheuri/poster-gen/pkg/storage"
+	"github.com/codetheuri/poster-gen/pkg/urlsign"
+	"github.com/codetheuri/poster-gen/pkg/validators"
+	"gorm.io/datatypes"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// testPosters is a poster service backed by SQLite, local storage and the fake renderer.
+type testPosters struct {
+	*posterSubService
+	db       *gorm.DB
+	renderer *renderer.FakeRenderer
+	files    storage.Storage
+}
+
+func newTestPosters(t *testing.T) *testPosters {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "posters.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Layout{}, &models.PosterTemplate{}, &models.Poster{}, &models.Asset{}, &models.RenderCacheEntry{}, &models.ShortLink{}, &models.ScanEvent{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	files, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatalf("storage: %v", err)
+	}
+	signer, err := urlsign.NewSigner(map[string][]byte{"test": []byte("0123456789abcdef0123456789abcdef")}, "test")
+	if err != nil {
+		t.Fatalf("signer: %v", err)
+	}
+	fake := renderer.NewFakeRenderer()
+	renderers := renderer.NewRegistry(renderer.BackendFake)
+	renderers.Register(fake)
+
+	log := logger.NewConsoleLogger()
+	repos := repositories.NewPosterRepository(db, log)
+	noop := func(ctx context.Context, id uint) error { return nil }
+	queue := NewInProcessRenderQueue(1, 1, noop, noop, log)
+	s := newPosterSubService(repos.PosterRepo, repos.PosterTemplateRepo, repos.LayoutRepo, repos.AssetRepo, validators.NewValidator(), log,
+		renderers, time.Minute, queue, t.TempDir(), files, DownloadConfig{Signer: signer, BaseURL: "/posters", TTL: time.Hour},
+		repos.RenderCacheRepo, RenderCacheConfig{}, NewFontSubService(repos.AssetRepo, files, log), repos.ShortLinkRepo, ScanLinkConfig{})
+	return &testPosters{posterSubService: s, db: db, renderer: fake, files: files}
+}
+
+// createTemplate saves a template with an uploaded layout.
+func (p *testPosters) createTemplate(t *testing.T, layout, fields, defaults, options string) *models.PosterTemplate {
+	t.Helper()
+	tmpl := &models.PosterTemplate{
+		Name:                 t.Name(),
+		Type:                 "test",
+		RequiredFields:       datatypes.JSON(fields),
+		DefaultCustomization: datatypes.JSON(defaults),
+		CustomizationOptions: datatypes.JSON(options),
+		IsActive:             true,
+		Layout:               models.Layout{Name: t.Name(), Content: layout},
+	}
+	if err := p.db.Create(tmpl).Error; err != nil {
+		t.Fatalf("create template: %v", err)
+	}
+	return tmpl
+}
+
+func appErrorCode(err error) string {
+	var appErr errors.AppError
+	if stdErrors.As(err, &appErr) {
+		return appErr.Code()
+	}
+	return ""
+}
+
+const titleFields = `[{"name": "title", "label": "Title"}, {"name": "count", "label": "Count", "type": "number", "required": false}]`
+
+func TestGeneratePosterRendersLayout(t *testing.T) {
+	p := newTestPosters(t)
+	tmpl := p.createTemplate(t, `<h1>{{.title}}</h1><p>{{.business_name}}</p>{{if .count}}<p>{{.count}} items</p>{{end}}`, titleFields, `{}`, `[]`)
+
+	resp, err := p.GeneratePoster(context.Background(), tmpl.ID, &dto.PosterInput{
+		BusinessName: "Mama Mboga",
+		Data:         map[string]interface{}{"title": "Fresh Sukuma", "count": "12"},
+	})
+	if err != nil {
+		t.Fatalf("GeneratePoster: %v", err)
+	}
+	if resp.Status != models.PosterStatusCompleted || resp.OutputFormat != "pdf" {
+		t.Fatalf("poster has status %q and format %q", resp.Status, resp.OutputFormat)
+	}
+	if !strings.HasPrefix(resp.PDFURL, "/posters/") || resp.URLExpiresAt == nil || resp.AccessToken == "" {
+		t.Fatalf("poster has download URL %q, expiry %v and access token %q", resp.PDFURL, resp.URLExpiresAt, resp.AccessToken)
+	}
+
+	requests := p.renderer.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("renderer got %d requests, want 1", len(requests))
+	}
+	html := requests[0].HTML
+	for _, want := range []string{"<h1>Fresh Sukuma</h1>", "<p>Mama Mboga</p>", "<p>12 items</p>"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("rendered HTML lacks %q:\n%s", want, html)
+		}
+	}
+
+	obj, err := p.files.Get(context.Background(), resp.StorageKey)
+	if err != nil {
+		t.Fatalf("stored file: %v", err)
+	}
+	defer obj.Body.Close()
+	body, _ := io.ReadAll(obj.Body)
+	if !bytes.HasPrefix(body, []byte("%PDF")) {
+		t.Fatalf("stored file is not a PDF: %.20q", body)
+	}
+}
+
+func TestGeneratePosterImageFormat(t *testing.T) {
+	p := newTestPosters(t)
+	tmpl := p.createTemplate(t, `<h1>{{.title}}</h1>`, titleFields, `{}`, `[]`)
+
+	resp, err := p.GeneratePoster(context.Background(), tmpl.ID, &dto.PosterInput{
+		BusinessName: "Shop",
+		Data:         map[string]interface{}{"title": "Hi"},
+		OutputFormat: "png",
+		DPI:          96,
+	})
+	if err != nil {
+		t.Fatalf("GeneratePoster: %v", err)
+	}
+	if resp.OutputFormat != "png" || !strings.HasSuffix(resp.StorageKey, ".png") {
+		t.Fatalf("poster has format %q and key %q", resp.OutputFormat, resp.StorageKey)
+	}
+	if req := p.renderer.Requests()[0]; req.Format != renderer.FormatPNG || req.DPI != 96 {
+		t.Fatalf("renderer got format %q at %d DPI", req.Format, req.DPI)
+	}
+}
+
+func TestGeneratePosterRejectsInvalidData(t *testing.T) {
+	p := newTestPosters(t)
+	tmpl := p.createTemplate(t, `<h1>{{.title}}</h1>`, titleFields, `{}`, `[]`)
+
+	_, err := p.GeneratePoster(context.Background(), tmpl.ID, &dto.PosterInput{
+		BusinessName: "Shop",
+		Data:         map[string]interface{}{"count": "many"},
+	})
+	if appErrorCode(err) != "VALIDATION_ERROR" {
+		t.Fatalf("GeneratePoster = %v, want a validation error", err)
+	}
+	var appErr errors.AppError
+	stdErrors.As(err, &appErr)
+	problems, _ := appErr.GetValidationErrors().(map[string]interface{})
+	if problems["title"] == nil || problems["count"] == nil {
+		t.Fatalf("validation errors = %v, want errors for title and count", problems)
+	}
+	if n := len(p.renderer.Requests()); n != 0 {
+		t.Fatalf("renderer got %d requests for invalid data", n)
+	}
+}
+
+func TestGeneratePosterKeepsCustomizationOutOfData(t *testing.T) {
+	p := newTestPosters(t)
+	tmpl := p.createTemplate(t,
+		`<style>h1 { color: {{.primary_color}}; font-size: {{.font_size_large}}; font-family: {{.font_family_name}} }</style><h1>{{.title}}</h1>`,
+		titleFields, `{"primary_color": "#0369a1", "font_size_large": "20px"}`, `[{"key": "primary_color", "type": "color"}]`)
+
+	_, err := p.GeneratePoster(context.Background(), tmpl.ID, &dto.PosterInput{
+		BusinessName: "Shop",
+		Data: map[string]interface{}{
+			"title":            "Hi",
+			"primary_color":    "red;}body{display:none",
+			"font_size_large":  "999px",
+			"font_family_name": "Comic Sans",
+		},
+	})
+	if appErrorCode(err) != "VALIDATION_ERROR" {
+		t.Fatalf("GeneratePoster = %v, want a validation error", err)
+	}
+	var appErr errors.AppError
+	stdErrors.As(err, &appErr)
+	problems, _ := appErr.GetValidationErrors().(map[string]interface{})
+	for _, key := range []string{"primary_color", "font_size_large", "font_family_name"} {
+		if problems[key] == nil {
+			t.Errorf("validation errors = %v, want one for %s", problems, key)
+		}
+	}
+	if n := len(p.renderer.Requests()); n != 0 {
+		t.Fatalf("renderer got %d requests for data overriding customization", n)
+	}
+
+	// The same colour goes through customization_data, where its option checks it.
+	_, err = p.GeneratePoster(context.Background(), tmpl.ID, &dto.PosterInput{
+		BusinessName:      "Shop",
+		Data:              map[string]interface{}{"title": "Hi", "subtitle": "extra keys still pass"},
+		CustomizationData: map[string]interface{}{"primary_color": "#ff0000"},
+	})
+	if err != nil {
+		t.Fatalf("GeneratePoster with customization: %v", err)
+	}
+	if html := p.renderer.Requests()[0].HTML; !strings.Contains(html, "color: #ff0000; font-size: 20px") {
+		t.Fatalf("rendered HTML lacks the customized colour and default size:\n%s", html)
+	}
+}
+
+func TestGeneratePosterRecordsRenderFailure(t *testing.T) {
+	p := newTestPosters(t)
+	tmpl := p.createTemplate(t, `<h1>{{.title}}</h1>`, titleFields, `{}`, `[]`)
+	p.renderer.Err = stdErrors.New("browser crashed")
+
+	_, err := p.GeneratePoster(context.Background(), tmpl.ID, &dto.PosterInput{
+		BusinessName: "Shop",
+		Data:         map[string]interface{}{"title": "Hi"},
+	})
+	if err == nil {
+		t.Fatal("GeneratePoster succeeded although the renderer failed")
+	}
+	var poster models.Poster
+	if err := p.db.Last(&poster).Error; err != nil {
+		t.Fatalf("load poster: %v", err)
+	}
+	if poster.Status != models.PosterStatusFailed || poster.ErrorReason == "" {
+		t.Fatalf("poster has status %q and reason %q, want failed with a reason", poster.Status, poster.ErrorReason)
+	}
+}
+
+func TestGetPosterByIDRequiresAccessToken(t *testing.T) {
+	p := newTestPosters(t)
+	tmpl := p.createTemplate(t, `<h1>{{.title}}</h1>`, titleFields, `{}`, `[]`)
+	resp, err := p.GeneratePoster(context.Background(), tmpl.ID, &dto.PosterInput{BusinessName: "Shop", Data: map[string]interface{}{"title": "Hi"}})
+	if err != nil {
+		t.Fatalf("GeneratePoster: %v", err)
+	}
+
+	for _, token := range []string{"", "wrong", strings.ToUpper(resp.AccessToken)} {
+		if _, err := p.GetPosterByID(context.Background(), resp.ID, token); appErrorCode(err) != "NOT_FOUND" {
+			t.Errorf("GetPosterByID with token %q = %v, want not found", token, err)
+		}
+	}
+	got, err := p.GetPosterByID(context.Background(), resp.ID, resp.AccessToken)
+	if err != nil || got.ID != resp.ID {
+		t.Fatalf("GetPosterByID with the access token = %+v, %v", got, err)
+	}
+
+	// Posters from before access tokens have none, and their clients never got one.
+	if err := p.db.Model(&models.Poster{}).Where("id = ?", resp.ID).Update("access_token", "").Error; err != nil {
+		t.Fatalf("clear access token: %v", err)
+	}
+	if _, err := p.GetPosterByID(context.Background(), resp.ID, ""); err != nil {
+		t.Fatalf("GetPosterByID of a poster without a token = %v, want it found", err)
+	}
+}
@@ -20,23 +20,35 @@ import (
 type PosterTemplateSubService interface {
 	CreateTemplate(ctx context.Context, input *dto.TemplateInput) (*dto.TemplateResponse, error)
 	GetTemplateByID(ctx context.Context, id uint) (*dto.TemplateResponse, error)
+	// GetTemplateSchema returns the JSON Schema of the poster input the template accepts.
+	GetTemplateSchema(ctx context.Context, id uint) (*dto.JSONSchema, error)
 	GetActiveTemplates(ctx context.Context) ([]*dto.TemplateResponse, error)
 	UpdateTemplate(ctx context.Context, id uint, input *dto.TemplateInput) error
 	DeleteTemplate(ctx context.Context, id uint) error
+	// ListRevisions returns every revision of a template, newest first.
+	ListRevisions(ctx context.Context, id uint) ([]*models.PosterTemplateRevision, error)
+	// DiffRevisions compares two revisions of a template; a to of 0 means the current revision.
+	DiffRevisions(ctx context.Context, id uint, from, to int) (*dto.RevisionDiff, error)
+	// RollbackTemplate restores an earlier revision by saving it again as the newest one.
+	RollbackTemplate(ctx context.Context, id uint, revision int) (*dto.TemplateResponse, error)
 }
 
 type posterTemplateSubService struct {
 	repo      repositories.PosterTemplateRepository // Uses the specific repo interface
 	layoutRepo repositories.LayoutRepository       // Added Layout Repo dependency
+	thumbnails ThumbnailSubService
+	linter     *layoutLinter
 	validator *validators.Validator
 	log       logger.Logger
 }
 
 // NewPosterTemplateSubService constructor accepts necessary repositories.
-func NewPosterTemplateSubService(repo repositories.PosterTemplateRepository, layoutRepo repositories.LayoutRepository, validator *validators.Validator, log logger.Logger) PosterTemplateSubService {
+func NewPosterTemplateSubService(repo repositories.PosterTemplateRepository, layoutRepo repositories.LayoutRepository, thumbnails ThumbnailSubService, templatesDir string, validator *validators.Validator, log logger.Logger) PosterTemplateSubService {
 	return &posterTemplateSubService{
 		repo:       repo,
 		layoutRepo: layoutRepo, // Store layout repo
+		thumbnails: thumbnails,
+		linter:     newLayoutLinter(templatesDir),
 		validator:  validator,
 		log:        log,
 	}
@@ -52,7 +64,7 @@ func (s *posterTemplateSubService) CreateTemplate(ctx context.Context, input *dt
 	}
 
 	// Optional: Validate that the referenced LayoutID exists
-	_, err := s.layoutRepo.GetLayoutByID(ctx, input.LayoutID)
+	layout, err := s.layoutRepo.GetLayoutByID(ctx, input.LayoutID)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			s.log.Warn("Referenced layout not found", "layout_id", input.LayoutID)
@@ -74,6 +86,20 @@ func (s *posterTemplateSubService) CreateTemplate(ctx context.Context, input *dt
 		IsActive:             input.IsActive,
 		RequiredFields:       datatypes.JSON(input.RequiredFields),
 		DefaultCustomization: datatypes.JSON(input.DefaultCustomization), // Use correct field name
+		SampleData:           datatypes.JSON(input.SampleData),
+		CustomizationOptions: datatypes.JSON(input.CustomizationOptions),
+	}
+	if err := validateFieldConfigs(template.RequiredFields); err != nil {
+		s.log.Warn("Invalid template field definitions", "error", err)
+		return nil, err
+	}
+	if err := validateCustomizationOptions(template.CustomizationOptions, template.DefaultCustomization); err != nil {
+		s.log.Warn("Invalid template customization options", "error", err)
+		return nil, err
+	}
+	if err := s.linter.validate(layout, template); err != nil {
+		s.log.Warn("Layout does not match template fields", "layout_id", layout.ID, "error", err)
+		return nil, err
 	}
 
 	if err := s.repo.CreateTemplate(ctx, template); err != nil {
@@ -82,6 +108,7 @@ func (s *posterTemplateSubService) CreateTemplate(ctx context.Context, input *dt
 		// if strings.Contains(err.Error(), "UNIQUE constraint failed") { ... return ConflictError ... }
 		return nil, errors.DatabaseError("failed to save template", err)
 	}
+	s.refreshThumbnail(ctx, template.ID)
 
 	// Fetch again to ensure Layout info is populated for the response
 	createdTemplate, err := s.repo.GetTemplateByID(ctx, template.ID)
@@ -99,13 +126,35 @@ func (s *posterTemplateSubService) CreateTemplate(ctx context.Context, input *dt
 		LayoutID:             createdTemplate.LayoutID,
 		LayoutFilePath:       createdTemplate.Layout.FilePath, // Get path from loaded Layout
 		Price:                createdTemplate.Price,
-		ThumbnailURL:         createdTemplate.ThumbnailURL,
+		ThumbnailURL:         s.thumbnails.ThumbnailURL(createdTemplate),
 		IsActive:             createdTemplate.IsActive,
 		RequiredFields:       json.RawMessage(createdTemplate.RequiredFields),
 		DefaultCustomization: json.RawMessage(createdTemplate.DefaultCustomization),
+		SampleData:           json.RawMessage(createdTemplate.SampleData),
+		CustomizationOptions: json.RawMessage(createdTemplate.CustomizationOptions),
+		Revision:             createdTemplate.Revision,
 	}, nil
 }
 
+// GetTemplateSchema returns the JSON Schema of the poster input a template accepts. Poster
+// generation validates against the same schema.
+func (s *posterTemplateSubService) GetTemplateSchema(ctx context.Context, id uint) (*dto.JSONSchema, error) {
+	template, err := s.repo.GetTemplateByID(ctx, id)
+	if err != nil {
+		if stdErrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.NotFoundError("template not found", err)
+		}
+		s.log.Error("Failed to get template for schema", err, "id", id)
+		return nil, errors.DatabaseError("failed to retrieve template", err)
+	}
+	schema, err := templateSchema(template)
+	if err != nil {
+		s.log.Error("Failed to build input schema for template", err, "template_id", id)
+		return nil, errors.InternalServerError("template configuration error: invalid input schema", err)
+	}
+	return schema, nil
+}
+
 // GetTemplateByID retrieves a template including its layout file path.
 func (s *posterTemplateSubService) GetTemplateByID(ctx context.Context, id uint) (*dto.TemplateResponse, error) {
 	s.log.Info("Getting template by ID", "id", id)
@@ -136,10 +185,13 @@ func (s *posterTemplateSubService) GetTemplateByID(ctx context.Context, id uint)
 		LayoutID:             template.LayoutID,
 		LayoutFilePath:       layoutFilePath,
 		Price:                template.Price,
-		ThumbnailURL:         template.ThumbnailURL,
+		ThumbnailURL:         s.thumbnails.ThumbnailURL(template),
 		IsActive:             template.IsActive,
 		RequiredFields:       json.RawMessage(template.RequiredFields),
 		DefaultCustomization: json.RawMessage(template.DefaultCustomization),
+		SampleData:           json.RawMessage(template.SampleData),
+		CustomizationOptions: json.RawMessage(template.CustomizationOptions),
+		Revision:             template.Revision,
 	}, nil
 }
 
@@ -166,10 +218,13 @@ func (s *posterTemplateSubService) GetActiveTemplates(ctx context.Context) ([]*d
 			LayoutID:             t.LayoutID,
 			LayoutFilePath:       layoutFilePath,
 			Price:                t.Price,
-			ThumbnailURL:         t.ThumbnailURL,
+			ThumbnailURL:         s.thumbnails.ThumbnailURL(t),
 			IsActive:             t.IsActive,
 			RequiredFields:       json.RawMessage(t.RequiredFields),
 			DefaultCustomization: json.RawMessage(t.DefaultCustomization),
+			SampleData:           json.RawMessage(t.SampleData),
+			CustomizationOptions: json.RawMessage(t.CustomizationOptions),
+			Revision:             t.Revision,
 		}
 	}
 	return resp, nil
@@ -196,13 +251,15 @@ func (s *posterTemplateSubService) UpdateTemplate(ctx context.Context, id uint,
 	}
 
 	// Optional: Validate LayoutID exists if it's being changed
+	layout := &template.Layout
 	if input.LayoutID != 0 && input.LayoutID != template.LayoutID {
-        _, err := s.layoutRepo.GetLayoutByID(ctx, input.LayoutID)
+        newLayout, err := s.layoutRepo.GetLayoutByID(ctx, input.LayoutID)
         if err != nil {
             // Handle layout not found error similar to CreateTemplate
              return errors.ValidationError("invalid layout_id: layout not found", nil, map[string]string{"layout_id": "Referenced layout does not exist"})
         }
         template.LayoutID = input.LayoutID
+        layout = newLayout
 	}
 
 
@@ -220,15 +277,43 @@ func (s *posterTemplateSubService) UpdateTemplate(ctx context.Context, id uint,
 	if len(input.DefaultCustomization) > 0 && string(input.DefaultCustomization) != "null" {
 		template.DefaultCustomization = datatypes.JSON(input.DefaultCustomization)
 	}
+	if len(input.SampleData) > 0 && string(input.SampleData) != "null" {
+		template.SampleData = datatypes.JSON(input.SampleData)
+	}
+	if len(input.CustomizationOptions) > 0 && string(input.CustomizationOptions) != "null" {
+		template.CustomizationOptions = datatypes.JSON(input.CustomizationOptions)
+	}
+	if err := validateFieldConfigs(template.RequiredFields); err != nil {
+		s.log.Warn("Invalid template field definitions", "template_id", id, "error", err)
+		return err
+	}
+	if err := validateCustomizationOptions(template.CustomizationOptions, template.DefaultCustomization); err != nil {
+		s.log.Warn("Invalid template customization options", "template_id", id, "error", err)
+		return err
+	}
+	if err := s.linter.validate(layout, template); err != nil {
+		s.log.Warn("Layout does not match template fields", "template_id", id, "error", err)
+		return err
+	}
 
 	if err := s.repo.UpdateTemplate(ctx, template); err != nil {
 		s.log.Error("Failed to update template in database", err, "id", id)
 		return errors.DatabaseError("failed to update template", err)
 	}
 	s.log.Info("Template updated successfully", "id", id)
+	s.refreshThumbnail(ctx, id)
 	return nil
 }
 
+// refreshThumbnail queues a fresh thumbnail after a change; the request does not wait for the
+// render. A failure is logged rather than returned: the template itself was saved and an admin
+// can regenerate later.
+func (s *posterTemplateSubService) refreshThumbnail(ctx context.Context, id uint) {
+	if err := s.thumbnails.QueueTemplateThumbnail(ctx, id); err != nil {
+		s.log.Warn("Failed to queue template thumbnail", "template_id", id, "error", err)
+	}
+}
+
 // DeleteTemplate deletes a poster template.
 func (s *posterTemplateSubService) DeleteTemplate(ctx context.Context, id uint) error {
 	s.log.Info("Deleting template", "id", id)
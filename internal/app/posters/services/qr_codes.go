@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"strconv"
+	"strings"
+
+	"github.com/codetheuri/poster-gen/pkg/keqr"
+	"github.com/codetheuri/poster-gen/pkg/qr"
+)
+
+// FieldTypeQR marks a required field whose value is also rendered as a QR code.
+// The layout receives the code as a data URI under "<name>_qr".
+const FieldTypeQR = "qr"
+
+// qrDataSuffix is appended to a qr field's name to form the data key of its code.
+const qrDataSuffix = "_qr"
+
+// QRFieldConfig holds the drawing options of a qr field. Every option is optional.
+type QRFieldConfig struct {
+	Size        int    `json:"size,omitempty"`
+	Level       string `json:"level,omitempty"`  // L, M, Q or H
+	Format      string `json:"format,omitempty"` // svg or png
+	Color       string `json:"color,omitempty"`  // defaults to primary_color when it scans well, else black
+	Background  string `json:"background,omitempty"`
+	Logo        bool   `json:"logo,omitempty"` // centre the header logo
+	LogoAssetID uint   `json:"logoAssetId,omitempty"`
+	// Track encodes a short link that counts scans and redirects to the field's value,
+	// which must then be a web address.
+	Track bool `json:"track,omitempty"`
+}
+
+// qrOptions resolves a field's options against the render data.
+func (s *posterSubService) qrOptions(ctx context.Context, cfg *QRFieldConfig, data map[string]interface{}) (qr.Options, error) {
+	if cfg == nil {
+		cfg = &QRFieldConfig{}
+	}
+	opts := qr.Options{
+		Size:       cfg.Size,
+		Level:      cfg.Level,
+		Format:     cfg.Format,
+		Foreground: cfg.Color,
+		Background: cfg.Background,
+	}
+	// primary_color may be any CSS colour, or too light to scan; the code is black then.
+	if brand, _ := data["primary_color"].(string); opts.Foreground == "" && qr.Readable(brand, opts.Background) {
+		opts.Foreground = brand
+	}
+	logo, err := s.qrLogo(ctx, cfg.Logo, cfg.LogoAssetID, data)
+	if err != nil {
+		return opts, err
+	}
+	opts.Logo = logo
+	return opts, nil
+}
+
+// qrLogo returns the SVG drawn in the centre of a code: a logo asset by ID, or the
+// template's header logo when useHeader is set.
+func (s *posterSubService) qrLogo(ctx context.Context, useHeader bool, assetID uint, data map[string]interface{}) (string, error) {
+	if assetID > 0 {
+		asset, err := s.assetRepo.GetAssetByID(ctx, assetID)
+		if err != nil || asset.Type != "logo" {
+			return "", fmt.Errorf("logo asset %d not found", assetID)
+		}
+		return asset.Data, nil
+	}
+	if useHeader {
+		switch logo := data["header_logo_svg"].(type) {
+		case template.HTML:
+			return string(logo), nil
+		case string:
+			return logo, nil
+		}
+	}
+	return "", nil
+}
+
+// withQRCodes adds the code of every qr field to data. Tracked fields of a saved poster encode
+// its short link instead of the value itself.
+func (s *posterSubService) withQRCodes(ctx context.Context, fields []RequiredFieldConfig, data map[string]interface{}, posterID uint) error {
+	for _, field := range fields {
+		if field.Type != FieldTypeQR {
+			continue
+		}
+		content := fmt.Sprintf("%v", data[field.Name])
+		if data[field.Name] == nil || content == "" {
+			continue
+		}
+		if field.QR != nil && field.QR.Track && posterID != 0 && s.scans.BaseURL != "" {
+			shortURL, err := s.trackedURL(ctx, posterID, field.Name, content)
+			if err != nil {
+				return fmt.Errorf("failed to create short link for %s: %w", field.Name, err)
+			}
+			content = shortURL
+		}
+		opts, err := s.qrOptions(ctx, field.QR, data)
+		if err != nil {
+			return err
+		}
+		uri, err := qr.DataURI(content, opts)
+		if err != nil {
+			return fmt.Errorf("failed to draw QR code for %s: %w", field.Name, err)
+		}
+		data[field.Name+qrDataSuffix] = template.URL(uri)
+	}
+	return nil
+}
+
+// qrcodeFunc backs the "qrcode" layout function:
+//
+//	{{qrcode .till_number}}
+//	{{qrcode (printf "tel:%s" .phone_number) "size" 300 "level" "H" "logo" true}}
+//
+// Options come as name/value pairs: size, level, format, color, background and logo,
+// which is a logo asset ID or true for the header logo. The colour defaults to primary_color
+// when that is a hex colour dark enough to scan on the background, and to black otherwise.
+func (s *posterSubService) qrcodeFunc(ctx context.Context, data map[string]interface{}) func(interface{}, ...interface{}) (template.URL, error) {
+	return func(content interface{}, options ...interface{}) (template.URL, error) {
+		if len(options)%2 != 0 {
+			return "", fmt.Errorf("qrcode options must be name/value pairs")
+		}
+		cfg := &QRFieldConfig{}
+		for i := 0; i < len(options); i += 2 {
+			name, ok := options[i].(string)
+			if !ok {
+				return "", fmt.Errorf("qrcode option name %v is not a string", options[i])
+			}
+			value := options[i+1]
+			switch name {
+			case "size":
+				size, err := strconv.Atoi(fmt.Sprintf("%v", value))
+				if err != nil {
+					return "", fmt.Errorf("qrcode size %v is not a number", value)
+				}
+				cfg.Size = size
+			case "level":
+				cfg.Level = fmt.Sprintf("%v", value)
+			case "format":
+				cfg.Format = fmt.Sprintf("%v", value)
+			case "color":
+				cfg.Color = fmt.Sprintf("%v", value)
+			case "background":
+				cfg.Background = fmt.Sprintf("%v", value)
+			case "logo":
+				if useHeader, ok := value.(bool); ok {
+					cfg.Logo = useHeader
+					break
+				}
+				id, err := strconv.ParseUint(fmt.Sprintf("%v", value), 10, 32)
+				if err != nil {
+					return "", fmt.Errorf("qrcode logo %v is neither true nor an asset ID", value)
+				}
+				cfg.LogoAssetID = uint(id)
+			default:
+				return "", fmt.Errorf("unknown qrcode option %q", name)
+			}
+		}
+
+		opts, err := s.qrOptions(ctx, cfg, data)
+		if err != nil {
+			return "", err
+		}
+		uri, err := qr.DataURI(fmt.Sprintf("%v", content), opts)
+		if err != nil {
+			return "", err
+		}
+		return template.URL(uri), nil
+	}
+}
+
+// keqrFunc backs the "keqr" layout function, which returns a KE-QR payment payload for qrcode:
+//
+//	{{with keqr "buy_goods" .till_number}}<img src="{{qrcode .}}">{{end}}
+//	{{with keqr "paybill" .paybill_number .account_number}}<img src="{{qrcode .}}">{{end}}
+//	{{with keqr "bank" .paybill_number .account_number}}<img src="{{qrcode .}}">{{end}}
+//
+// The merchant name is the poster's business_name; city and amount are read from the data when set.
+// An optional field left empty is missing from the data, so a nil account counts as no account.
+// Details that cannot form a valid payload, such as the placeholder values of a thumbnail or a
+// paybill without an account number, yield an empty string so the layout can leave the code out
+// instead of printing one apps would reject.
+func (s *posterSubService) keqrFunc(data map[string]interface{}) func(string, interface{}, ...interface{}) string {
+	return func(kind string, merchantID interface{}, account ...interface{}) string {
+		payment := keqr.Payment{
+			Kind:       keqr.Kind(kind),
+			MerchantID: keqrValue(merchantID),
+		}
+		if len(account) > 0 {
+			payment.Account = keqrValue(account[0])
+		}
+		payment.MerchantName, _ = data["business_name"].(string)
+		payment.City, _ = data["city"].(string)
+		payment.Amount = keqrValue(data["amount"])
+		payload, err := keqr.Build(payment)
+		if err != nil {
+			s.log.Warn("Leaving out payment QR code", "kind", kind, "reason", err.Error())
+			return ""
+		}
+		return payload
+	}
+}
+
+// keqrValue writes a layout value as payload text. Numbers keep all their digits, and nil, which
+// fmt would print as "<nil>", is empty.
+func keqrValue(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	if s, ok := scalarString(v); ok {
+		return strings.TrimSpace(s)
+	}
+	return fmt.Sprintf("%v", v)
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/codetheuri/poster-gen/pkg/keqr"
+	"github.com/codetheuri/poster-gen/pkg/logger"
+	"github.com/codetheuri/poster-gen/pkg/qr"
+)
+
+func TestKeqrFuncLeavesOutPaybillWithoutAccount(t *testing.T) {
+	s := &posterSubService{log: logger.NewConsoleLogger()}
+	// An optional account_number left empty is missing from the data, so the layout passes nil.
+	data := map[string]interface{}{"business_name": "Shop", "paybill_number": "247247"}
+	build := s.keqrFunc(data)
+
+	for _, account := range []interface{}{data["account_number"], "", "  "} {
+		for _, kind := range []string{"paybill", "bank"} {
+			if payload := build(kind, "247247", account); payload != "" {
+				t.Errorf("keqr %q with account %#v = %q, want no payload", kind, account, payload)
+			}
+		}
+	}
+}
+
+func TestKeqrFuncWritesNumbersInFull(t *testing.T) {
+	s := &posterSubService{log: logger.NewConsoleLogger()}
+	// Number fields reach the layout as float64, which %v would print as 5.123456e+06.
+	build := s.keqrFunc(map[string]interface{}{"business_name": "Shop", "amount": float64(1500)})
+
+	p, err := keqr.Parse(build("paybill", float64(5123456), float64(1234567)))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if p.MerchantID != "5123456" || p.Account != "1234567" || p.Amount != "1500" {
+		t.Fatalf("payload has merchant %q, account %q and amount %q", p.MerchantID, p.Account, p.Amount)
+	}
+
+	payload := build("buy_goods", "5123456")
+	if payload == "" || strings.Contains(payload, "<nil>") {
+		t.Fatalf("buy goods payload = %q", payload)
+	}
+}
+
+func TestQROptionsUsePrimaryColorOnlyWhenItScans(t *testing.T) {
+	s := &posterSubService{log: logger.NewConsoleLogger()}
+	for primary, want := range map[string]string{
+		"#0369a1":          "#0369a1",
+		"#ffd700":          "", // too light to scan
+		"rebeccapurple":    "",
+		"rgb(3, 105, 161)": "",
+	} {
+		data := map[string]interface{}{"primary_color": primary}
+		opts, err := s.qrOptions(context.Background(), nil, data)
+		if err != nil {
+			t.Fatalf("qrOptions with primary_color %q: %v", primary, err)
+		}
+		if opts.Foreground != want {
+			t.Errorf("primary_color %q gives foreground %q, want %q", primary, opts.Foreground, want)
+		}
+		if _, err := qr.DataURI("https://example.com", opts); err != nil {
+			t.Errorf("primary_color %q: drawing the code failed: %v", primary, err)
+		}
+	}
+
+	// A colour the template sets on the field is its author's choice.
+	opts, _ := s.qrOptions(context.Background(), &QRFieldConfig{Color: "#ffd700"}, map[string]interface{}{"primary_color": "#0369a1"})
+	if opts.Foreground != "#ffd700" {
+		t.Errorf("field colour gives foreground %q, want #ffd700", opts.Foreground)
+	}
+}
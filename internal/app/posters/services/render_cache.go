@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	"github.com/codetheuri/poster-gen/internal/app/posters/models"
+	"github.com/codetheuri/poster-gen/internal/app/posters/repositories"
+	"github.com/codetheuri/poster-gen/pkg/logger"
+	"github.com/codetheuri/poster-gen/pkg/renderer"
+)
+
+// RenderCacheConfig controls reuse of previously rendered files.
+type RenderCacheConfig struct {
+	TTL        time.Duration // how long a rendered file is reused; 0 disables the cache
+	MaxEntries int           // least recently used entries beyond this are evicted; 0 = unbounded
+}
+
+// evictEvery is how many stores happen between eviction sweeps.
+const evictEvery = 50
+
+// renderCache reuses rendered files for posters whose layout, data and output options
+// are byte-for-byte identical. Lookups and stores never fail a render: on any cache
+// error the poster is simply rendered again.
+type renderCache struct {
+	repo   repositories.RenderCacheRepository
+	cfg    RenderCacheConfig
+	log    logger.Logger
+	stores atomic.Int64
+}
+
+func newRenderCache(repo repositories.RenderCacheRepository, cfg RenderCacheConfig, log logger.Logger) *renderCache {
+	return &renderCache{repo: repo, cfg: cfg, log: log}
+}
+
+func (c *renderCache) enabled() bool {
+	return c.cfg.TTL > 0
+}
+
+// fingerprint hashes everything that affects the rendered bytes. json.Marshal sorts map
+// keys, so equal data always produces the same hash.
+func (c *renderCache) fingerprint(layoutContent []byte, data map[string]interface{}, req renderer.Request, backend string) (string, error) {
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	optionsJSON, err := json.Marshal(struct {
+		Backend string          `json:"backend"`
+		Format  renderer.Format `json:"format"`
+		DPI     int             `json:"dpi"`
+		Width   int             `json:"width"`
+		Height  int             `json:"height"`
+		Quality int             `json:"quality"`
+	}{backend, req.Format, req.DPI, req.Width, req.Height, req.Quality})
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	for _, part := range [][]byte{layoutContent, dataJSON, optionsJSON} {
+		h.Write(part)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// lookup returns the storage key of a fresh render for cacheKey.
+func (c *renderCache) lookup(ctx context.Context, cacheKey string) (string, bool) {
+	now := time.Now()
+	entry, err := c.repo.GetFreshEntry(ctx, cacheKey, now)
+	if err != nil {
+		return "", false
+	}
+	_ = c.repo.RecordHit(ctx, entry, now)
+	return entry.StorageKey, true
+}
+
+// store remembers storageKey as the render for cacheKey and occasionally evicts old entries.
+func (c *renderCache) store(ctx context.Context, cacheKey, storageKey string, format renderer.Format) {
+	now := time.Now()
+	err := c.repo.PutEntry(ctx, &models.RenderCacheEntry{
+		CacheKey:     cacheKey,
+		StorageKey:   storageKey,
+		OutputFormat: string(format),
+		LastUsedAt:   now,
+		ExpiresAt:    now.Add(c.cfg.TTL),
+	})
+	if err != nil {
+		return
+	}
+	if c.stores.Add(1)%evictEvery == 1 {
+		if evicted, err := c.repo.Evict(ctx, now, c.cfg.MaxEntries); err == nil && evicted > 0 {
+			c.log.Info("Evicted render cache entries", "count", evicted)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	stdErrors "errors"
+	"time"
+
+	"github.com/codetheuri/poster-gen/internal/app/posters/models"
+	"github.com/codetheuri/poster-gen/internal/app/posters/repositories"
+	"github.com/codetheuri/poster-gen/pkg/errors"
+	"github.com/codetheuri/poster-gen/pkg/logger"
+	"github.com/codetheuri/poster-gen/pkg/workerpool"
+)
+
+// Render queue drivers.
+const (
+	RenderQueueMemory   = "memory"   // renders run inside the API process
+	RenderQueueDatabase = "database" // renders are stored as jobs and run by cmd/worker
+)
+
+// RenderQueueConfig selects and sizes the queue used for asynchronous renders.
+type RenderQueueConfig struct {
+	Driver      string
+	Workers     int // memory driver only
+	QueueSize   int // memory driver only
+	MaxAttempts int // database driver only
+}
+
+// RenderFunc renders a single pending poster, or the thumbnail of a template.
+type RenderFunc func(ctx context.Context, id uint) error
+
+// RenderQueue hands pending posters and template thumbnails to background render workers.
+type RenderQueue interface {
+	// Enqueue schedules a pending poster for rendering. It must not block on the render itself.
+	// It returns ErrRenderQueueFull when the queue cannot take more work right now.
+	Enqueue(ctx context.Context, posterID uint) error
+	// EnqueueThumbnail schedules a fresh thumbnail of a template, like Enqueue.
+	EnqueueThumbnail(ctx context.Context, templateID uint) error
+	// Shutdown stops accepting work and waits for queued renders to finish or ctx to end.
+	Shutdown(ctx context.Context) error
+}
+
+// ErrRenderQueueFull is returned by Enqueue when no more renders can be accepted right now.
+var ErrRenderQueueFull = stdErrors.New("render queue is full")
+
+type inProcessRenderQueue struct {
+	pool      *workerpool.Pool
+	render    RenderFunc
+	thumbnail RenderFunc
+	log       logger.Logger
+}
+
+// NewInProcessRenderQueue runs renders on a bounded pool of goroutines inside this process.
+// Queued renders are lost if the process dies; see the poster status for what completed.
+func NewInProcessRenderQueue(workers, queueSize int, render, thumbnail RenderFunc, log logger.Logger) RenderQueue {
+	return &inProcessRenderQueue{
+		pool:      workerpool.New(workers, queueSize, log),
+		render:    render,
+		thumbnail: thumbnail,
+		log:       log,
+	}
+}
+
+func (q *inProcessRenderQueue) Enqueue(ctx context.Context, posterID uint) error {
+	return q.submit(func(ctx context.Context) {
+		if err := q.render(ctx, posterID); err != nil {
+			q.log.Error("Background poster render failed", err, "poster_id", posterID)
+		}
+	})
+}
+
+func (q *inProcessRenderQueue) EnqueueThumbnail(ctx context.Context, templateID uint) error {
+	return q.submit(func(ctx context.Context) {
+		if err := q.thumbnail(ctx, templateID); err != nil {
+			q.log.Error("Background thumbnail render failed", err, "template_id", templateID)
+		}
+	})
+}
+
+func (q *inProcessRenderQueue) submit(task func(ctx context.Context)) error {
+	err := q.pool.Submit(task)
+	if stdErrors.Is(err, workerpool.ErrQueueFull) {
+		return ErrRenderQueueFull
+	}
+	return err
+}
+
+func (q *inProcessRenderQueue) Shutdown(ctx context.Context) error {
+	q.log.Info("Draining background render queue...")
+	return q.pool.Shutdown(ctx)
+}
+
+type databaseRenderQueue struct {
+	jobs        repositories.RenderJobRepository
+	maxAttempts int
+	log         logger.Logger
+}
+
+// NewDatabaseRenderQueue stores renders in the render_jobs table, where any worker process can claim them.
+func NewDatabaseRenderQueue(jobs repositories.RenderJobRepository, maxAttempts int, log logger.Logger) RenderQueue {
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+	return &databaseRenderQueue{jobs: jobs, maxAttempts: maxAttempts, log: log}
+}
+
+func (q *databaseRenderQueue) Enqueue(ctx context.Context, posterID uint) error {
+	return q.create(ctx, &models.RenderJob{Kind: models.RenderJobPoster, PosterID: posterID})
+}
+
+func (q *databaseRenderQueue) EnqueueThumbnail(ctx context.Context, templateID uint) error {
+	return q.create(ctx, &models.RenderJob{Kind: models.RenderJobThumbnail, TemplateID: templateID})
+}
+
+func (q *databaseRenderQueue) create(ctx context.Context, job *models.RenderJob) error {
+	job.Status = models.RenderJobQueued
+	job.RunAt = time.Now()
+	job.MaxAttempts = q.maxAttempts
+	if err := q.jobs.CreateJob(ctx, job); err != nil {
+		return errors.DatabaseError("failed to store render job", err)
+	}
+	return nil
+}
+
+// Shutdown is a no-op: queued jobs stay in the database for the workers.
+func (q *databaseRenderQueue) Shutdown(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/codetheuri/poster-gen/internal/app/posters/models"
+	"github.com/codetheuri/poster-gen/internal/app/posters/repositories"
+	"github.com/codetheuri/poster-gen/pkg/errors"
+	"github.com/codetheuri/poster-gen/pkg/logger"
+)
+
+// RenderWorkerConfig controls how a worker process claims and retries render jobs.
+type RenderWorkerConfig struct {
+	WorkerID          string        // identifies the claim holder; defaults to hostname-pid
+	Concurrency       int           // jobs rendered at the same time
+	PollInterval      time.Duration // wait between claims when the queue is empty
+	VisibilityTimeout time.Duration // a claimed job becomes claimable again after this long without a heartbeat
+	BaseBackoff       time.Duration // delay before the first retry, doubled on every attempt
+	MaxBackoff        time.Duration // upper bound for the retry delay
+}
+
+// RenderWorker claims jobs from the render_jobs table and renders their posters or thumbnails.
+type RenderWorker struct {
+	jobs      repositories.RenderJobRepository
+	posters   repositories.PosterSubRepository
+	render    RenderFunc
+	thumbnail RenderFunc
+	cfg       RenderWorkerConfig
+	log       logger.Logger
+}
+
+// NewRenderWorker creates a worker; call Run to start processing.
+func NewRenderWorker(jobs repositories.RenderJobRepository, posters repositories.PosterSubRepository, render, thumbnail RenderFunc, cfg RenderWorkerConfig, log logger.Logger) *RenderWorker {
+	if cfg.WorkerID == "" {
+		host, _ := os.Hostname()
+		cfg.WorkerID = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 2 * time.Second
+	}
+	if cfg.VisibilityTimeout <= 0 {
+		cfg.VisibilityTimeout = 5 * time.Minute
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = 10 * time.Second
+	}
+	if cfg.MaxBackoff < cfg.BaseBackoff {
+		cfg.MaxBackoff = time.Hour
+	}
+	return &RenderWorker{jobs: jobs, posters: posters, render: render, thumbnail: thumbnail, cfg: cfg, log: log}
+}
+
+// Run processes jobs until ctx is cancelled, then waits for in-flight renders to finish.
+// Renders are not cut short by ctx; they are bounded by the render timeout instead, and a
+// worker killed mid-render leaves its job to be reclaimed after the visibility timeout.
+func (w *RenderWorker) Run(ctx context.Context) {
+	w.log.Info("Render worker started", "worker_id", w.cfg.WorkerID, "concurrency", w.cfg.Concurrency)
+	var wg sync.WaitGroup
+	wg.Add(w.cfg.Concurrency)
+	for i := 0; i < w.cfg.Concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			w.loop(ctx)
+		}()
+	}
+	wg.Wait()
+	w.log.Info("Render worker stopped", "worker_id", w.cfg.WorkerID)
+}
+
+func (w *RenderWorker) loop(ctx context.Context) {
+	jobCtx := context.WithoutCancel(ctx)
+	for ctx.Err() == nil {
+		job, err := w.jobs.ClaimJob(ctx, w.cfg.WorkerID, w.cfg.VisibilityTimeout)
+		if err != nil || job == nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(w.cfg.PollInterval):
+			}
+			continue
+		}
+		w.process(jobCtx, job)
+	}
+}
+
+func (w *RenderWorker) process(ctx context.Context, job *models.RenderJob) {
+	// Thumbnail jobs only render a file; the poster status bookkeeping below is for posters.
+	isPoster := job.Kind != models.RenderJobThumbnail
+	render, id, log := w.render, job.PosterID, []interface{}{"job_id", job.ID, "poster_id", job.PosterID, "attempt", job.Attempts}
+	if !isPoster {
+		render, id, log = w.thumbnail, job.TemplateID, []interface{}{"job_id", job.ID, "template_id", job.TemplateID, "attempt", job.Attempts}
+	}
+
+	// The job was claimed again after its visibility timeout ran out on the final attempt.
+	if job.Attempts > job.MaxAttempts {
+		reason := "render did not finish within the allowed attempts"
+		w.log.Warn("Render job exhausted its attempts", log...)
+		if isPoster {
+			w.failPoster(ctx, job.PosterID, reason)
+		}
+		_ = w.jobs.KillJob(ctx, job, reason)
+		return
+	}
+
+	w.log.Info("Rendering from job", append(log, "kind", job.Kind)...)
+	stop := w.heartbeat(ctx, job)
+	err := render(ctx, id)
+	stop()
+	if err == nil {
+		_ = w.jobs.CompleteJob(ctx, job)
+		return
+	}
+
+	if !isRetryable(err) || job.Attempts >= job.MaxAttempts {
+		w.log.Error("Render job moved to dead-letter", err, log...)
+		_ = w.jobs.KillJob(ctx, job, err.Error())
+		return
+	}
+
+	delay := backoff(w.cfg.BaseBackoff, w.cfg.MaxBackoff, job.Attempts)
+	w.log.Warn("Render job failed, retrying", append(log, "retry_in", delay.String(), "error", err.Error())...)
+	if !isPoster {
+		_ = w.jobs.RetryJob(ctx, job, time.Now().Add(delay), err.Error())
+		return
+	}
+	// RenderPoster marked the poster failed; it is pending again until the retry settles it.
+	// A retry of a poster still marked failed would be skipped as finished, so if the reset
+	// fails the job is dead-lettered and the poster keeps its failure.
+	if resetErr := w.resetPoster(ctx, job.PosterID); resetErr != nil {
+		w.log.Error("Failed to reset poster for retry, moving render job to dead-letter", resetErr, log...)
+		_ = w.jobs.KillJob(ctx, job, fmt.Sprintf("%s (poster could not be reset for a retry: %v)", err.Error(), resetErr))
+		return
+	}
+	_ = w.jobs.RetryJob(ctx, job, time.Now().Add(delay), err.Error())
+}
+
+// heartbeat keeps extending the claim on job while it renders, so a render that runs close to
+// the visibility timeout is not handed to a second worker. Call the returned func when done.
+func (w *RenderWorker) heartbeat(ctx context.Context, job *models.RenderJob) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(w.cfg.VisibilityTimeout / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				held, err := w.jobs.ExtendJob(ctx, job, time.Now().Add(w.cfg.VisibilityTimeout))
+				if err != nil {
+					continue // try again on the next beat; the claim is still valid for a while
+				}
+				if !held {
+					w.log.Warn("Render job was reclaimed by another worker while rendering", "job_id", job.ID, "poster_id", job.PosterID)
+					return
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+func (w *RenderWorker) resetPoster(ctx context.Context, posterID uint) error {
+	poster, err := w.posters.GetPosterByID(ctx, posterID)
+	if err != nil {
+		return err
+	}
+	poster.Status = models.PosterStatusPending
+	return w.posters.UpdatePoster(ctx, poster)
+}
+
+func (w *RenderWorker) failPoster(ctx context.Context, posterID uint, reason string) {
+	poster, err := w.posters.GetPosterByID(ctx, posterID)
+	if err != nil {
+		w.log.Error("Failed to load poster to mark it failed", err, "poster_id", posterID)
+		return
+	}
+	poster.Status = models.PosterStatusFailed
+	poster.ErrorReason = reason
+	if err := w.posters.UpdatePoster(ctx, poster); err != nil {
+		w.log.Error("Failed to mark poster as failed", err, "poster_id", posterID)
+	}
+}
+
+// isRetryable reports whether another attempt could succeed. Bad input and missing
+// records fail the same way every time, so they go straight to the dead-letter state.
+func isRetryable(err error) bool {
+	var appErr errors.AppError
+	if stdErrors.As(err, &appErr) {
+		switch appErr.Code() {
+		case "VALIDATION_ERROR", "NOT_FOUND":
+			return false
+		}
+	}
+	return true
+}
+
+// backoff returns base * 2^(attempt-1), capped at max.
+func backoff(base, max time.Duration, attempt int) time.Duration {
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	return delay
+}
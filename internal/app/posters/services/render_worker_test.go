@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	stdErrors "errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codetheuri/poster-gen/internal/app/posters/models"
+	"github.com/codetheuri/poster-gen/internal/app/posters/repositories"
+	"github.com/codetheuri/poster-gen/pkg/logger"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestRenderWorkerRendersQueuedThumbnails(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "jobs.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.RenderJob{}, &models.Poster{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	log := logger.NewConsoleLogger()
+	repos := repositories.NewPosterRepository(db, log)
+
+	var rendered []uint
+	thumbnailErr := stdErrors.New("browser crashed")
+	thumbnail := func(ctx context.Context, templateID uint) error {
+		rendered = append(rendered, templateID)
+		return thumbnailErr
+	}
+	poster := func(ctx context.Context, posterID uint) error {
+		t.Fatalf("poster %d rendered for a thumbnail job", posterID)
+		return nil
+	}
+	worker := NewRenderWorker(repos.RenderJobRepo, repos.PosterRepo, poster, thumbnail, RenderWorkerConfig{WorkerID: "test"}, log)
+	queue := NewDatabaseRenderQueue(repos.RenderJobRepo, 2, log)
+	if err := queue.EnqueueThumbnail(context.Background(), 7); err != nil {
+		t.Fatalf("EnqueueThumbnail: %v", err)
+	}
+
+	// A failed thumbnail is retried without looking for a poster to reset; the next attempt succeeds.
+	for attempt := 1; attempt <= 2; attempt++ {
+		if err := db.Model(&models.RenderJob{}).Where("1 = 1").Update("run_at", time.Now().Add(-time.Second)).Error; err != nil {
+			t.Fatalf("make job due: %v", err)
+		}
+		job, err := repos.RenderJobRepo.ClaimJob(context.Background(), "test", time.Minute)
+		if err != nil || job == nil {
+			t.Fatalf("attempt %d: ClaimJob = %v, %v", attempt, job, err)
+		}
+		worker.process(context.Background(), job)
+		thumbnailErr = nil
+	}
+
+	var job models.RenderJob
+	if err := db.First(&job).Error; err != nil {
+		t.Fatalf("load job: %v", err)
+	}
+	if job.Kind != models.RenderJobThumbnail || job.Status != models.RenderJobCompleted || len(rendered) != 2 || rendered[0] != 7 {
+		t.Fatalf("job %s is %s after rendering templates %v, want a completed thumbnail of template 7", job.Kind, job.Status, rendered)
+	}
+}
@@ -0,0 +1,423 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/codetheuri/poster-gen/internal/app/posters/handlers/dto"
+	"github.com/codetheuri/poster-gen/internal/app/posters/models"
+	"github.com/codetheuri/poster-gen/pkg/errors"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Revision change kinds.
+const (
+	RevisionAdded   = "added"
+	RevisionRemoved = "removed"
+	RevisionChanged = "changed"
+)
+
+// maxDiffCells bounds the table the line diff of layout HTML fills, about 4 MB: the lines that
+// differ once common leading and trailing lines are set aside, multiplied. Bigger changes only
+// report that the content changed.
+const maxDiffCells = 1_000_000
+
+// templateFromRevision rebuilds a template as it was at a revision. The layout is not set.
+func templateFromRevision(rev *models.PosterTemplateRevision) *models.PosterTemplate {
+	return &models.PosterTemplate{
+		Model:                gorm.Model{ID: rev.PosterTemplateID},
+		Name:                 rev.Name,
+		Type:                 rev.Type,
+		LayoutID:             rev.LayoutID,
+		Price:                rev.Price,
+		ThumbnailURL:         rev.ThumbnailURL,
+		IsActive:             rev.IsActive,
+		RequiredFields:       rev.RequiredFields,
+		DefaultCustomization: rev.DefaultCustomization,
+		SampleData:           rev.SampleData,
+		CustomizationOptions: rev.CustomizationOptions,
+		Revision:             rev.Revision,
+	}
+}
+
+// layoutFromRevision rebuilds a layout as it was at a revision.
+func layoutFromRevision(rev *models.LayoutRevision) models.Layout {
+	return models.Layout{
+		Model:    gorm.Model{ID: rev.LayoutID},
+		Name:     rev.Name,
+		FilePath: rev.FilePath,
+		Content:  rev.Content,
+		Renderer: rev.Renderer,
+		Revision: rev.Revision,
+	}
+}
+
+// revisionError maps a failed revision lookup to the error reported to clients.
+func revisionError(err error, what string) error {
+	if stdErrors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.NotFoundError(what+" not found", err)
+	}
+	return errors.DatabaseError("failed to retrieve "+what, err)
+}
+
+// templateAtRevision loads a template at templateRevision with its layout at layoutRevision.
+// A revision of 0 stands for the current row, which is what posters generated before
+// revisions were recorded are rendered with.
+func (s *posterSubService) templateAtRevision(ctx context.Context, templateID uint, templateRevision, layoutRevision int) (*models.PosterTemplate, error) {
+	var templateRecord *models.PosterTemplate
+	if templateRevision == 0 {
+		current, err := s.templateRepo.GetTemplateByID(ctx, templateID) // Preloads the current layout
+		if err != nil {
+			return nil, revisionError(err, "template")
+		}
+		if layoutRevision == 0 {
+			return current, nil
+		}
+		templateRecord = current
+	} else {
+		rev, err := s.templateRepo.GetRevision(ctx, templateID, templateRevision)
+		if err != nil {
+			return nil, revisionError(err, "template revision")
+		}
+		templateRecord = templateFromRevision(rev)
+	}
+
+	if layoutRevision == 0 {
+		layout, err := s.layoutRepo.GetLayoutByID(ctx, templateRecord.LayoutID)
+		if err != nil {
+			return nil, revisionError(err, "layout")
+		}
+		templateRecord.Layout = *layout
+		return templateRecord, nil
+	}
+	rev, err := s.layoutRepo.GetRevision(ctx, templateRecord.LayoutID, layoutRevision)
+	if err != nil {
+		return nil, revisionError(err, "layout revision")
+	}
+	templateRecord.Layout = layoutFromRevision(rev)
+	return templateRecord, nil
+}
+
+func (s *posterSubService) RegeneratePoster(ctx context.Context, posterID uint, input *dto.RegenerateInput) (*dto.PosterResponse, error) {
+	s.log.Info("Regenerating poster", "poster_id", posterID, "template_revision", input.TemplateRevision, "latest", input.Latest)
+	original, err := s.repo.GetPosterByID(ctx, posterID)
+	if err != nil {
+		if stdErrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.NotFoundError("poster not found", err)
+		}
+		return nil, errors.DatabaseError("failed to retrieve poster", err)
+	}
+
+	var request dto.PosterInput
+	if len(original.RequestPayload) > 0 {
+		if err := json.Unmarshal(original.RequestPayload, &request); err != nil {
+			return nil, errors.InternalServerError("stored poster request is invalid", err)
+		}
+	} else {
+		// Posters saved before requests were stored only kept the user data; customization overrides are lost.
+		request = dto.PosterInput{BusinessName: original.BusinessName, OutputFormat: original.OutputFormat}
+		if err := json.Unmarshal(original.UserInputData, &request.Data); err != nil {
+			return nil, errors.InternalServerError("stored poster data is invalid", err)
+		}
+	}
+	request.Async = input.Async
+	request.BypassCache = false
+
+	templateRevision, layoutRevision := original.TemplateRevision, original.LayoutRevision
+	switch {
+	case input.Latest:
+		templateRevision, layoutRevision = 0, 0
+	case input.TemplateRevision > 0 && input.TemplateRevision != original.TemplateRevision:
+		// Another template revision is rendered with the current revision of its layout.
+		templateRevision, layoutRevision = input.TemplateRevision, 0
+	}
+	templateRecord, err := s.templateAtRevision(ctx, original.PosterTemplateID, templateRevision, layoutRevision)
+	if err != nil {
+		return nil, err
+	}
+
+	// The stored request is checked again: the chosen revision may require different fields.
+	finalTemplateData, err := s.buildTemplateData(ctx, templateRecord, &request)
+	if err != nil {
+		return nil, err
+	}
+	return s.createPoster(ctx, templateRecord, &request, finalTemplateData)
+}
+
+func (s *posterTemplateSubService) ListRevisions(ctx context.Context, id uint) ([]*models.PosterTemplateRevision, error) {
+	if _, err := s.repo.GetTemplateByID(ctx, id); err != nil {
+		return nil, revisionError(err, "template")
+	}
+	revisions, err := s.repo.ListRevisions(ctx, id)
+	if err != nil {
+		return nil, errors.DatabaseError("failed to retrieve template revisions", err)
+	}
+	return revisions, nil
+}
+
+func (s *posterTemplateSubService) DiffRevisions(ctx context.Context, id uint, from, to int) (*dto.RevisionDiff, error) {
+	if to == 0 {
+		template, err := s.repo.GetTemplateByID(ctx, id)
+		if err != nil {
+			return nil, revisionError(err, "template")
+		}
+		to = template.Revision
+	}
+	a, err := s.repo.GetRevision(ctx, id, from)
+	if err != nil {
+		return nil, revisionError(err, "template revision")
+	}
+	b, err := s.repo.GetRevision(ctx, id, to)
+	if err != nil {
+		return nil, revisionError(err, "template revision")
+	}
+
+	d := &revisionDiffer{changes: []dto.RevisionChange{}}
+	d.value("name", a.Name, b.Name)
+	d.value("type", a.Type, b.Type)
+	d.value("layout_id", a.LayoutID, b.LayoutID)
+	d.value("price", a.Price, b.Price)
+	d.value("thumbnail_url", a.ThumbnailURL, b.ThumbnailURL)
+	d.value("is_active", a.IsActive, b.IsActive)
+	d.fields("required_fields", "name", a.RequiredFields, b.RequiredFields)
+	d.object("default_customization", a.DefaultCustomization, b.DefaultCustomization)
+	d.object("sample_data", a.SampleData, b.SampleData)
+	d.fields("customization_options", "key", a.CustomizationOptions, b.CustomizationOptions)
+	return &dto.RevisionDiff{From: from, To: to, Changes: d.changes}, nil
+}
+
+func (s *posterTemplateSubService) RollbackTemplate(ctx context.Context, id uint, revision int) (*dto.TemplateResponse, error) {
+	s.log.Info("Rolling back template", "id", id, "revision", revision)
+	template, err := s.repo.GetTemplateByID(ctx, id)
+	if err != nil {
+		return nil, revisionError(err, "template")
+	}
+	rev, err := s.repo.GetRevision(ctx, id, revision)
+	if err != nil {
+		return nil, revisionError(err, "template revision")
+	}
+	layout, err := s.layoutRepo.GetLayoutByID(ctx, rev.LayoutID)
+	if err != nil {
+		if stdErrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.ValidationError("the layout of this revision no longer exists", nil, map[string]string{"layout_id": "Referenced layout does not exist"})
+		}
+		return nil, errors.DatabaseError("failed to verify layout", err)
+	}
+
+	// The thumbnail key and revision counter are kept; every other setting comes from the revision.
+	restored := templateFromRevision(rev)
+	restored.Model = template.Model
+	restored.ThumbnailKey = template.ThumbnailKey
+	restored.Revision = template.Revision
+	if err := s.linter.validate(layout, restored); err != nil {
+		s.log.Warn("Revision does not match the current layout", "template_id", id, "revision", revision, "error", err)
+		return nil, err
+	}
+	if err := s.repo.UpdateTemplate(ctx, restored); err != nil {
+		return nil, errors.DatabaseError("failed to roll back template", err)
+	}
+	s.log.Info("Template rolled back", "id", id, "from_revision", revision, "new_revision", restored.Revision)
+	s.refreshThumbnail(ctx, id)
+	return s.GetTemplateByID(ctx, id)
+}
+
+func (s *layoutSubService) ListLayoutRevisions(ctx context.Context, id uint) ([]*models.LayoutRevision, error) {
+	if _, err := s.getLayout(ctx, id); err != nil {
+		return nil, err
+	}
+	revisions, err := s.repo.ListRevisions(ctx, id)
+	if err != nil {
+		return nil, errors.DatabaseError("failed to retrieve layout revisions", err)
+	}
+	return revisions, nil
+}
+
+func (s *layoutSubService) DiffLayoutRevisions(ctx context.Context, id uint, from, to int) (*dto.RevisionDiff, error) {
+	if to == 0 {
+		layout, err := s.getLayout(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		to = layout.Revision
+	}
+	a, err := s.repo.GetRevision(ctx, id, from)
+	if err != nil {
+		return nil, revisionError(err, "layout revision")
+	}
+	b, err := s.repo.GetRevision(ctx, id, to)
+	if err != nil {
+		return nil, revisionError(err, "layout revision")
+	}
+
+	d := &revisionDiffer{changes: []dto.RevisionChange{}}
+	d.value("name", a.Name, b.Name)
+	d.value("file_path", a.FilePath, b.FilePath)
+	d.value("renderer", a.Renderer, b.Renderer)
+	d.text("content", a.Content, b.Content)
+	return &dto.RevisionDiff{From: from, To: to, Changes: d.changes}, nil
+}
+
+// RollbackLayout saves an earlier revision again as the newest one. It goes through UpdateLayout,
+// so the restored HTML must still match every template using the layout.
+func (s *layoutSubService) RollbackLayout(ctx context.Context, id uint, revision int) (*models.Layout, error) {
+	s.log.Info("Rolling back layout", "id", id, "revision", revision)
+	rev, err := s.repo.GetRevision(ctx, id, revision)
+	if err != nil {
+		return nil, revisionError(err, "layout revision")
+	}
+	return s.UpdateLayout(ctx, id, &dto.LayoutInput{Name: rev.Name, FilePath: rev.FilePath, Content: rev.Content, Renderer: rev.Renderer})
+}
+
+// revisionDiffer collects the changes between two revisions.
+type revisionDiffer struct {
+	changes []dto.RevisionChange
+}
+
+func (d *revisionDiffer) value(field string, from, to interface{}) {
+	if !reflect.DeepEqual(from, to) {
+		d.changes = append(d.changes, dto.RevisionChange{Field: field, Change: RevisionChanged, From: from, To: to})
+	}
+}
+
+// object compares two JSON objects key by key. Values that are not objects are compared whole.
+func (d *revisionDiffer) object(field string, from, to datatypes.JSON) {
+	a, okA := decodeJSONObject(from)
+	b, okB := decodeJSONObject(to)
+	if !okA || !okB {
+		d.value(field, string(from), string(to))
+		return
+	}
+	d.keys(field, a, b)
+}
+
+// fields compares two lists of definitions, such as required_fields, by the given key.
+func (d *revisionDiffer) fields(field, key string, from, to datatypes.JSON) {
+	a, okA := decodeFieldList(from, key)
+	b, okB := decodeFieldList(to, key)
+	if !okA || !okB {
+		d.value(field, string(from), string(to))
+		return
+	}
+	d.keys(field, a, b)
+}
+
+func (d *revisionDiffer) keys(field string, from, to map[string]interface{}) {
+	keys := make([]string, 0, len(from)+len(to))
+	for key := range from {
+		keys = append(keys, key)
+	}
+	for key := range to {
+		if _, ok := from[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		a, inFrom := from[key]
+		b, inTo := to[key]
+		switch {
+		case !inFrom:
+			d.changes = append(d.changes, dto.RevisionChange{Field: field + "." + key, Change: RevisionAdded, To: b})
+		case !inTo:
+			d.changes = append(d.changes, dto.RevisionChange{Field: field + "." + key, Change: RevisionRemoved, From: a})
+		default:
+			d.value(field+"."+key, a, b)
+		}
+	}
+}
+
+// text reports the changed lines of two texts. It compares them line by line and keeps only
+// the lines that were removed or added.
+func (d *revisionDiffer) text(field, from, to string) {
+	if from == to {
+		return
+	}
+	change := dto.RevisionChange{Field: field, Change: RevisionChanged}
+	change.Lines = diffLines(strings.Split(from, "\n"), strings.Split(to, "\n"))
+	d.changes = append(d.changes, change)
+}
+
+// diffLines returns the lines only in a as "-<n>: line" and those only in b as "+<n>: line",
+// using the longest common subsequence of both. It returns nil when the changed region is too
+// big to compare within maxDiffCells.
+func diffLines(a, b []string) []string {
+	// Lines shared at the start and end are common to every subsequence; only the middle is compared.
+	start := 0
+	for start < len(a) && start < len(b) && a[start] == b[start] {
+		start++
+	}
+	endA, endB := len(a), len(b)
+	for endA > start && endB > start && a[endA-1] == b[endB-1] {
+		endA, endB = endA-1, endB-1
+	}
+	a, b = a[start:endA], b[start:endB]
+	if (len(a)+1)*(len(b)+1) > maxDiffCells {
+		return nil
+	}
+
+	// lcs[i*width+j] is the length of the longest common subsequence of a[i:] and b[j:].
+	width := len(b) + 1
+	lcs := make([]int32, (len(a)+1)*width)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i*width+j] = lcs[(i+1)*width+j+1] + 1
+			} else {
+				lcs[i*width+j] = max(lcs[(i+1)*width+j], lcs[i*width+j+1])
+			}
+		}
+	}
+	var lines []string
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			i, j = i+1, j+1
+		case i < len(a) && (j == len(b) || lcs[(i+1)*width+j] >= lcs[i*width+j+1]):
+			lines = append(lines, fmt.Sprintf("-%d: %s", start+i+1, a[i]))
+			i++
+		default:
+			lines = append(lines, fmt.Sprintf("+%d: %s", start+j+1, b[j]))
+			j++
+		}
+	}
+	return lines
+}
+
+// decodeJSONObject decodes a JSON object; empty and null values are an empty object.
+func decodeJSONObject(raw datatypes.JSON) (map[string]interface{}, bool) {
+	object := map[string]interface{}{}
+	if len(raw) == 0 || string(raw) == "null" {
+		return object, true
+	}
+	if err := json.Unmarshal(raw, &object); err != nil {
+		return nil, false
+	}
+	return object, true
+}
+
+// decodeFieldList decodes a list of definitions into a map from each one's key to the definition.
+func decodeFieldList(raw datatypes.JSON, key string) (map[string]interface{}, bool) {
+	fields := map[string]interface{}{}
+	if len(raw) == 0 || string(raw) == "null" {
+		return fields, true
+	}
+	var list []map[string]interface{}
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, false
+	}
+	for i, field := range list {
+		name, _ := field[key].(string)
+		if name == "" {
+			name = fmt.Sprintf("#%d", i)
+		}
+		fields[name] = field
+	}
+	return fields, true
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	a := strings.Split("<html>\n<h1>{{.title}}</h1>\n<p>old</p>\n</html>", "\n")
+	b := strings.Split("<html>\n<h1>{{.title}}</h1>\n<p>new</p>\n<p>{{.count}}</p>\n</html>", "\n")
+	want := []string{"-3: <p>old</p>", "+3: <p>new</p>", "+4: <p>{{.count}}</p>"}
+	if got := diffLines(a, b); !slices.Equal(got, want) {
+		t.Fatalf("diffLines = %q, want %q", got, want)
+	}
+}
+
+func TestDiffLinesOfLongLayouts(t *testing.T) {
+	long := make([]string, 20000)
+	for i := range long {
+		long[i] = fmt.Sprintf("<p>line %d</p>", i)
+	}
+
+	// A small edit in a long layout is still reported line by line.
+	edited := slices.Clone(long)
+	edited[15000] = "<p>edited</p>"
+	want := []string{"-15001: <p>line 15000</p>", "+15001: <p>edited</p>"}
+	if got := diffLines(long, edited); !slices.Equal(got, want) {
+		t.Fatalf("diffLines of one edited line = %q, want %q", got, want)
+	}
+
+	// Rewriting most of it is too big to compare.
+	rewritten := make([]string, len(long))
+	for i := range rewritten {
+		rewritten[i] = fmt.Sprintf("<div>%d</div>", i)
+	}
+	if got := diffLines(long, rewritten); got != nil {
+		t.Fatalf("diffLines of a rewritten layout returned %d lines, want none", len(got))
+	}
+}
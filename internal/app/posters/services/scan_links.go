@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	stdErrors "errors"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/codetheuri/poster-gen/internal/app/posters/handlers/dto"
+	"github.com/codetheuri/poster-gen/internal/app/posters/models"
+	"github.com/codetheuri/poster-gen/internal/app/posters/repositories"
+	"github.com/codetheuri/poster-gen/pkg/errors"
+	"github.com/codetheuri/poster-gen/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// ScanLinkConfig controls the short links encoded in tracked QR codes.
+type ScanLinkConfig struct {
+	// BaseURL is the absolute prefix of the redirect route, e.g. "https://api.example.com/s".
+	// Without it tracked codes point straight at their target and scans are not counted.
+	BaseURL string
+}
+
+const (
+	shortCodeAlphabet = "23456789abcdefghjkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ" // no 0/O, 1/l/I
+	shortCodeLength   = 8
+	// scanStatsDays is how many recent days the daily breakdown covers.
+	scanStatsDays = 30
+	// maxUserAgentLength matches the scan_events.user_agent column.
+	maxUserAgentLength = 512
+)
+
+// ScanSubService follows short links and reports how often posters were scanned.
+type ScanSubService interface {
+	// ResolveScan records a scan of the short link code and returns the URL to redirect to.
+	ResolveScan(ctx context.Context, code, userAgent string) (string, error)
+	GetPosterScanStats(ctx context.Context, posterID uint, token string) (*dto.PosterScanStats, error)
+}
+
+type scanSubService struct {
+	repo       repositories.ShortLinkRepository
+	posterRepo repositories.PosterSubRepository
+	cfg        ScanLinkConfig
+	log        logger.Logger
+}
+
+func NewScanSubService(repo repositories.ShortLinkRepository, posterRepo repositories.PosterSubRepository, cfg ScanLinkConfig, log logger.Logger) ScanSubService {
+	return &scanSubService{repo: repo, posterRepo: posterRepo, cfg: cfg, log: log}
+}
+
+func (s *scanSubService) ResolveScan(ctx context.Context, code, userAgent string) (string, error) {
+	link, err := s.repo.GetShortLinkByCode(ctx, code)
+	if err != nil {
+		if stdErrors.Is(err, gorm.ErrRecordNotFound) {
+			return "", errors.NotFoundError("link not found", err)
+		}
+		return "", errors.DatabaseError("failed to retrieve link", err)
+	}
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+	// Whoever scanned the poster still gets where they were going if the scan cannot be saved.
+	_ = s.repo.RecordScan(ctx, &models.ScanEvent{
+		ShortLinkID: link.ID,
+		PosterID:    link.PosterID,
+		UserAgent:   userAgent,
+		ScannedAt:   time.Now().UTC(),
+	})
+	return link.TargetURL, nil
+}
+
+func (s *scanSubService) GetPosterScanStats(ctx context.Context, posterID uint, token string) (*dto.PosterScanStats, error) {
+	poster, err := s.posterRepo.GetPosterByID(ctx, posterID)
+	if err != nil {
+		if stdErrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.NotFoundError("poster not found", err)
+		}
+		return nil, errors.DatabaseError("failed to retrieve poster", err)
+	}
+	if !validAccessToken(poster, token) {
+		return nil, errors.NotFoundError("poster not found", nil)
+	}
+	links, err := s.repo.ListPosterLinks(ctx, posterID)
+	if err != nil {
+		return nil, errors.DatabaseError("failed to retrieve poster links", err)
+	}
+	counts, err := s.repo.ScanCountsByLink(ctx, posterID)
+	if err != nil {
+		return nil, errors.DatabaseError("failed to count poster scans", err)
+	}
+	first, last, err := s.repo.ScanRange(ctx, posterID)
+	if err != nil {
+		return nil, errors.DatabaseError("failed to retrieve poster scans", err)
+	}
+	since := time.Now().UTC().AddDate(0, 0, -scanStatsDays+1).Truncate(24 * time.Hour)
+	times, err := s.repo.ScanTimes(ctx, posterID, since)
+	if err != nil {
+		return nil, errors.DatabaseError("failed to retrieve poster scans", err)
+	}
+
+	stats := &dto.PosterScanStats{
+		PosterID:    posterID,
+		FirstScanAt: first,
+		LastScanAt:  last,
+		Links:       make([]dto.ShortLinkStats, len(links)),
+		Daily:       []dto.DailyScans{},
+	}
+	for i, link := range links {
+		stats.Links[i] = dto.ShortLinkStats{
+			Field:     link.Field,
+			ShortURL:  shortLinkURL(s.cfg, link.Code),
+			TargetURL: link.TargetURL,
+			Scans:     counts[link.ID],
+		}
+		stats.TotalScans += counts[link.ID]
+	}
+	// Times arrive in order, so each day is either the last one seen or a new one.
+	for _, t := range times {
+		day := t.UTC().Format("2006-01-02")
+		if n := len(stats.Daily); n > 0 && stats.Daily[n-1].Date == day {
+			stats.Daily[n-1].Scans++
+			continue
+		}
+		stats.Daily = append(stats.Daily, dto.DailyScans{Date: day, Scans: 1})
+	}
+	return stats, nil
+}
+
+// trackedURL returns the short link that records scans of a poster's qr field before redirecting
+// to target. Links are created on the first render and reused when the poster is rendered again.
+func (s *posterSubService) trackedURL(ctx context.Context, posterID uint, field, target string) (string, error) {
+	link, err := s.links.GetPosterLink(ctx, posterID, field)
+	if err == nil {
+		return shortLinkURL(s.scans, link.Code), nil
+	}
+	if !stdErrors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	// Codes are random; on the rare clash with an existing one, draw again.
+	var createErr error
+	for attempt := 0; attempt < 3; attempt++ {
+		code, err := newShortCode()
+		if err != nil {
+			return "", err
+		}
+		link = &models.ShortLink{Code: code, PosterID: posterID, Field: field, TargetURL: target}
+		if createErr = s.links.CreateShortLink(ctx, link); createErr == nil {
+			s.log.Info("Short link created", "poster_id", posterID, "field", field, "code", code)
+			return shortLinkURL(s.scans, code), nil
+		}
+	}
+	return "", createErr
+}
+
+// isTrackableTarget reports whether value can be the target of a short link.
+func isTrackableTarget(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func shortLinkURL(cfg ScanLinkConfig, code string) string {
+	return strings.TrimRight(cfg.BaseURL, "/") + "/" + code
+}
+
+func newShortCode() (string, error) {
+	max := big.NewInt(int64(len(shortCodeAlphabet)))
+	code := make([]byte, shortCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = shortCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
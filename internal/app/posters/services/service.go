@@ -2,6 +2,11 @@ package services
 
 import (
 	"context" // Needed for service method signatures
+	stdErrors "errors"
+	"fmt"
+	"path/filepath"
+	"time"
+	"unicode/utf8"
 	// Needed for error formatting
 	// Need DTOs for input parameters
 	dto "github.com/codetheuri/poster-gen/internal/app/posters/handlers/dto"
@@ -13,8 +18,10 @@ import (
 	// Need errors package
 	"github.com/codetheuri/poster-gen/pkg/errors"
 	"github.com/codetheuri/poster-gen/pkg/logger"
+	"github.com/codetheuri/poster-gen/pkg/renderer"
+	"github.com/codetheuri/poster-gen/pkg/storage"
 	"github.com/codetheuri/poster-gen/pkg/validators"
-	// Needed for gorm.ErrRecordNotFound
+	"gorm.io/gorm" // Needed for gorm.ErrRecordNotFound
 )
 
 // PosterService is the main service aggregator for the posters module.
@@ -24,6 +31,11 @@ type PosterService struct {
 	LogoSvc           LogoSubService
 	LayoutSvc         LayoutSubService
 	AssetSvc          AssetSubService
+	ThumbnailSvc      ThumbnailSubService
+	FontSvc           FontSubService
+	ScanSvc           ScanSubService
+
+	renderQueue RenderQueue
 }
 
 // NewPosterService constructor for the main service aggregator.
@@ -31,50 +43,104 @@ func NewPosterService(
 	repos *posterRepositories.PosterRepository,
 	validator *validators.Validator,
 	log logger.Logger,
+	renderers *renderer.Registry,
+	renderTimeout time.Duration,
+	queueCfg RenderQueueConfig,
+	files storage.Storage,
+	downloads DownloadConfig,
+	cacheCfg RenderCacheConfig,
+	scanCfg ScanLinkConfig,
 ) *PosterService {
 	templatesDir := "./templates"
-	outputDir := "./posters"
+
+	var posterSvc PosterSubService
+	var thumbnailSvc ThumbnailSubService
+	var renderQueue RenderQueue
+	switch queueCfg.Driver {
+	case RenderQueueDatabase:
+		renderQueue = NewDatabaseRenderQueue(repos.RenderJobRepo, queueCfg.MaxAttempts, log)
+	default:
+		// The in-process queue calls back into the services it feeds, so it is bound to them lazily.
+		renderQueue = NewInProcessRenderQueue(queueCfg.Workers, queueCfg.QueueSize, func(ctx context.Context, posterID uint) error {
+			return posterSvc.RenderPoster(ctx, posterID)
+		}, func(ctx context.Context, templateID uint) error {
+			return thumbnailSvc.GenerateTemplateThumbnail(ctx, templateID)
+		}, log)
+	}
+	fontSvc := NewFontSubService(repos.AssetRepo, files, log)
+	posters := newPosterSubService(repos.PosterRepo, repos.PosterTemplateRepo, repos.LayoutRepo, repos.AssetRepo, validator, log, renderers, renderTimeout, renderQueue, templatesDir, files, downloads, repos.RenderCacheRepo, cacheCfg, fontSvc, repos.ShortLinkRepo, scanCfg)
+	posterSvc = posters
+	thumbnailSvc = newThumbnailSubService(posters, repos.PosterTemplateRepo, files, log)
 
 	return &PosterService{
-		PosterTemplateSvc: NewPosterTemplateSubService(repos.PosterTemplateRepo, repos.LayoutRepo, validator, log),
-		PosterSvc:         NewPosterSubService(repos.PosterRepo, repos.PosterTemplateRepo, repos.LayoutRepo, repos.AssetRepo, validator, log, templatesDir, outputDir),
+		PosterTemplateSvc: NewPosterTemplateSubService(repos.PosterTemplateRepo, repos.LayoutRepo, thumbnailSvc, templatesDir, validator, log),
+		PosterSvc:         posterSvc,
+		ThumbnailSvc:      thumbnailSvc,
+		FontSvc:           fontSvc,
+		ScanSvc:           NewScanSubService(repos.ShortLinkRepo, repos.PosterRepo, scanCfg, log),
 		LogoSvc:           NewLogoSubService(),
-		LayoutSvc:         NewLayoutSubService(repos.LayoutRepo, log),
+		LayoutSvc:         NewLayoutSubService(repos.LayoutRepo, repos.PosterTemplateRepo, renderers, thumbnailSvc, templatesDir, log),
 		AssetSvc:          NewAssetSubService(repos.AssetRepo, log),
 		// OrderSvc:          NewOrderSubService(repos.OrderRepo, validator, log), // Keep commented if needed
+		renderQueue: renderQueue,
 	}
 }
 
+// Shutdown waits for queued background renders to finish, or for ctx to end.
+func (s *PosterService) Shutdown(ctx context.Context) error {
+	return s.renderQueue.Shutdown(ctx)
+}
+
+// MaxLayoutSize is the largest layout HTML accepted for upload.
+const MaxLayoutSize = 1 << 20
+
 type LayoutSubService interface {
+	// CreateLayout saves a layout that either names a file in the templates directory or carries uploaded HTML.
 	CreateLayout(ctx context.Context, input *dto.LayoutInput) (*models.Layout, error)
 	ListLayouts(ctx context.Context) ([]*models.Layout, error)
-	// Add GetLayoutByID etc. if needed later
+	// UpdateLayout replaces the name, source and renderer of a layout. The new source must still
+	// match the fields of every template using the layout.
+	UpdateLayout(ctx context.Context, id uint, input *dto.LayoutInput) (*models.Layout, error)
+	// DeleteLayout removes a layout, unless templates still use it.
+	DeleteLayout(ctx context.Context, id uint) error
+	// DownloadLayout returns the HTML of a layout, whether it was uploaded or lives in a file.
+	DownloadLayout(ctx context.Context, id uint) (*dto.LayoutSource, error)
+	// ListLayoutRevisions returns every revision of a layout, newest first.
+	ListLayoutRevisions(ctx context.Context, id uint) ([]*models.LayoutRevision, error)
+	// DiffLayoutRevisions compares two revisions of a layout; a to of 0 means the current revision.
+	DiffLayoutRevisions(ctx context.Context, id uint, from, to int) (*dto.RevisionDiff, error)
+	// RollbackLayout restores an earlier revision by saving it again as the newest one.
+	RollbackLayout(ctx context.Context, id uint, revision int) (*models.Layout, error)
+	// LintLayouts checks every layout file in the templates directory and every uploaded layout,
+	// against the fields of each template that uses it, and every layout row whose file is missing.
+	LintLayouts(ctx context.Context) ([]dto.LayoutLintReport, error)
 }
 type layoutSubService struct {
-	repo repositories.LayoutRepository
-	log  logger.Logger
+	repo         repositories.LayoutRepository
+	templateRepo repositories.PosterTemplateRepository
+	renderers    *renderer.Registry
+	thumbnails   ThumbnailSubService
+	linter       *layoutLinter
+	log          logger.Logger
 }
 
-func NewLayoutSubService(repo repositories.LayoutRepository, log logger.Logger) LayoutSubService {
-	return &layoutSubService{repo: repo, log: log}
+func NewLayoutSubService(repo repositories.LayoutRepository, templateRepo repositories.PosterTemplateRepository, renderers *renderer.Registry, thumbnails ThumbnailSubService, templatesDir string, log logger.Logger) LayoutSubService {
+	return &layoutSubService{repo: repo, templateRepo: templateRepo, renderers: renderers, thumbnails: thumbnails, linter: newLayoutLinter(templatesDir), log: log}
 }
 
 // CreateLayout handles the business logic for creating a layout.
 func (s *layoutSubService) CreateLayout(ctx context.Context, input *dto.LayoutInput) (*models.Layout, error) {
-	s.log.Info("Creating layout", "name", input.Name)
-	// Basic validation (can add more complex checks if needed)
-	if input.Name == "" || input.FilePath == "" {
-		return nil, errors.ValidationError("layout name and file path are required", nil, nil)
+	s.log.Info("Creating layout", "name", input.Name, "uploaded", input.Content != "")
+	layout := &models.Layout{}
+	if err := s.applyLayoutInput(layout, input); err != nil {
+		return nil, err
 	}
-
-	layout := &models.Layout{
-		Name:     input.Name,
-		FilePath: input.FilePath,
+	if err := s.linter.validate(layout, nil); err != nil {
+		s.log.Warn("Layout failed linting", "name", input.Name, "error", err)
+		return nil, err
 	}
 
-	// Assuming the LayoutRepository has a CreateLayout method
-	err := s.repo.CreateLayout(ctx, layout) // You need to add CreateLayout to the LayoutRepository interface and implementation
-	if err != nil {
+	if err := s.repo.CreateLayout(ctx, layout); err != nil {
 		s.log.Error("Failed to create layout in repository", err)
 		// Check for specific DB errors like unique constraints if needed
 		return nil, errors.DatabaseError("failed to save layout", err)
@@ -83,11 +149,42 @@ func (s *layoutSubService) CreateLayout(ctx context.Context, input *dto.LayoutIn
 	return layout, nil
 }
 
+// applyLayoutInput checks the input and copies it onto layout. A layout has exactly one source:
+// uploaded HTML, or a file path that must stay inside the templates directory.
+func (s *layoutSubService) applyLayoutInput(layout *models.Layout, input *dto.LayoutInput) error {
+	if input.Name == "" {
+		return errors.ValidationError("layout name is required", nil, map[string]string{"name": "This field is required"})
+	}
+	switch {
+	case input.Content != "" && input.FilePath != "":
+		return errors.ValidationError("layout has two sources", nil, map[string]string{"content": "Upload the layout HTML or give a file_path, not both"})
+	case input.Content == "" && input.FilePath == "":
+		return errors.ValidationError("layout source is required", nil, map[string]string{"content": "Upload the layout HTML or give a file_path"})
+	case input.FilePath != "" && !filepath.IsLocal(input.FilePath):
+		return errors.ValidationError("invalid layout file path", nil, map[string]string{"file_path": "File path must be relative to the templates directory"})
+	case len(input.Content) > MaxLayoutSize:
+		return errors.ValidationError("layout is too large", nil, map[string]string{"content": fmt.Sprintf("Layouts may be at most %d KB", MaxLayoutSize>>10)})
+	case !utf8.ValidString(input.Content):
+		return errors.ValidationError("layout is not valid UTF-8", nil, map[string]string{"content": "Layout HTML must be UTF-8 encoded"})
+	}
+	if input.Renderer != "" && !s.renderers.Has(input.Renderer) {
+		return errors.ValidationError("unsupported renderer", nil, map[string]string{"renderer": "Renderer backend is not available on this server"})
+	}
+
+	layout.Name = input.Name
+	layout.FilePath = ""
+	if input.FilePath != "" {
+		layout.FilePath = filepath.Clean(input.FilePath)
+	}
+	layout.Content = input.Content
+	layout.Renderer = input.Renderer
+	return nil
+}
+
 // ListLayouts handles retrieving all layouts.
 func (s *layoutSubService) ListLayouts(ctx context.Context) ([]*models.Layout, error) {
 	s.log.Info("Listing layouts")
-	// Assuming the LayoutRepository has a ListLayouts method
-	layouts, err := s.repo.ListLayouts(ctx) // You need to add ListLayouts to the LayoutRepository interface and implementation
+	layouts, err := s.repo.ListLayouts(ctx)
 	if err != nil {
 		s.log.Error("Failed to list layouts from repository", err)
 		return nil, errors.DatabaseError("failed to retrieve layouts", err)
@@ -96,6 +193,85 @@ func (s *layoutSubService) ListLayouts(ctx context.Context) ([]*models.Layout, e
 	return layouts, nil
 }
 
+func (s *layoutSubService) UpdateLayout(ctx context.Context, id uint, input *dto.LayoutInput) (*models.Layout, error) {
+	s.log.Info("Updating layout", "id", id, "uploaded", input.Content != "")
+	layout, err := s.getLayout(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyLayoutInput(layout, input); err != nil {
+		return nil, err
+	}
+	templates, err := s.templateRepo.ListTemplatesByLayout(ctx, id)
+	if err != nil {
+		return nil, errors.DatabaseError("failed to retrieve templates using the layout", err)
+	}
+	if err := s.linter.validate(layout, nil); err != nil {
+		return nil, err
+	}
+	for _, t := range templates {
+		if err := s.linter.validate(layout, t); err != nil {
+			s.log.Warn("Updated layout does not match template fields", "layout_id", id, "template_id", t.ID, "error", err)
+			return nil, err
+		}
+	}
+
+	if err := s.repo.UpdateLayout(ctx, layout); err != nil {
+		return nil, errors.DatabaseError("failed to update layout", err)
+	}
+	s.log.Info("Layout updated successfully", "id", id)
+	// Thumbnails show the old design until they are rendered again; a failure only leaves them stale.
+	for _, t := range templates {
+		if err := s.thumbnails.QueueTemplateThumbnail(ctx, t.ID); err != nil {
+			s.log.Warn("Failed to queue template thumbnail", "template_id", t.ID, "error", err)
+		}
+	}
+	return layout, nil
+}
+
+func (s *layoutSubService) DeleteLayout(ctx context.Context, id uint) error {
+	s.log.Info("Deleting layout", "id", id)
+	if _, err := s.getLayout(ctx, id); err != nil {
+		return err
+	}
+	templates, err := s.templateRepo.ListTemplatesByLayout(ctx, id)
+	if err != nil {
+		return errors.DatabaseError("failed to retrieve templates using the layout", err)
+	}
+	if len(templates) > 0 {
+		return errors.ConflictError(fmt.Sprintf("layout is still used by %d template(s); move them to another layout first", len(templates)), nil)
+	}
+	if err := s.repo.DeleteLayout(ctx, id); err != nil {
+		return errors.DatabaseError("failed to delete layout", err)
+	}
+	s.log.Info("Layout deleted successfully", "id", id)
+	return nil
+}
+
+func (s *layoutSubService) DownloadLayout(ctx context.Context, id uint) (*dto.LayoutSource, error) {
+	layout, err := s.getLayout(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	body, err := readLayoutSource(s.linter.templatesDir, layout)
+	if err != nil {
+		s.log.Error("Failed to read layout for download", err, "layout_id", id)
+		return nil, errors.NotFoundError("layout file cannot be read", err)
+	}
+	return &dto.LayoutSource{Filename: DownloadFilename(layout.Name, "html"), Body: body}, nil
+}
+
+func (s *layoutSubService) getLayout(ctx context.Context, id uint) (*models.Layout, error) {
+	layout, err := s.repo.GetLayoutByID(ctx, id)
+	if err != nil {
+		if stdErrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.NotFoundError("layout not found", err)
+		}
+		return nil, errors.DatabaseError("failed to retrieve layout", err)
+	}
+	return layout, nil
+}
+
 // --- Asset Service Implementation ---
 
 // AssetSubService interface defines methods for asset operations.
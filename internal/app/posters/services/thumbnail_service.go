@@ -0,0 +1,192 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stdErrors "errors"
+	"path"
+	"strings"
+
+	"github.com/codetheuri/poster-gen/internal/app/posters/handlers/dto"
+	"github.com/codetheuri/poster-gen/internal/app/posters/models"
+	"github.com/codetheuri/poster-gen/internal/app/posters/repositories"
+	"github.com/codetheuri/poster-gen/pkg/barcode"
+	"github.com/codetheuri/poster-gen/pkg/errors"
+	"github.com/codetheuri/poster-gen/pkg/logger"
+	"github.com/codetheuri/poster-gen/pkg/renderer"
+	"github.com/codetheuri/poster-gen/pkg/storage"
+	"gorm.io/gorm"
+)
+
+// thumbnailWidth is the pixel width of generated template thumbnails.
+const thumbnailWidth = 400
+
+// thumbnailKeyPrefix keeps thumbnails apart from rendered posters in the file storage.
+const thumbnailKeyPrefix = "thumbnails"
+
+// ThumbnailSubService renders template thumbnails from the layout and the template's sample data.
+type ThumbnailSubService interface {
+	// GenerateTemplateThumbnail renders and stores a fresh thumbnail, replacing the previous one.
+	GenerateTemplateThumbnail(ctx context.Context, templateID uint) error
+	// QueueTemplateThumbnail has a fresh thumbnail rendered in the background, on the render queue.
+	QueueTemplateThumbnail(ctx context.Context, templateID uint) error
+	// RegenerateAllThumbnails renders every template again and returns how many succeeded.
+	// A template that fails is logged and skipped so one broken layout does not stop the rest.
+	RegenerateAllThumbnails(ctx context.Context) (int, error)
+	// ThumbnailURL returns a signed link to the generated thumbnail, or the manually set URL when there is none.
+	ThumbnailURL(template *models.PosterTemplate) string
+}
+
+type thumbnailSubService struct {
+	posters      *posterSubService
+	templateRepo repositories.PosterTemplateRepository
+	files        storage.Storage
+	log          logger.Logger
+}
+
+func newThumbnailSubService(posters *posterSubService, templateRepo repositories.PosterTemplateRepository, files storage.Storage, log logger.Logger) *thumbnailSubService {
+	return &thumbnailSubService{posters: posters, templateRepo: templateRepo, files: files, log: log}
+}
+
+func (s *thumbnailSubService) GenerateTemplateThumbnail(ctx context.Context, templateID uint) error {
+	template, err := s.templateRepo.GetTemplateByID(ctx, templateID)
+	if err != nil {
+		if stdErrors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.NotFoundError("template not found", err)
+		}
+		return errors.DatabaseError("failed to retrieve template", err)
+	}
+	return s.generate(ctx, template)
+}
+
+func (s *thumbnailSubService) QueueTemplateThumbnail(ctx context.Context, templateID uint) error {
+	return s.posters.renderQueue.EnqueueThumbnail(ctx, templateID)
+}
+
+func (s *thumbnailSubService) RegenerateAllThumbnails(ctx context.Context) (int, error) {
+	templates, err := s.templateRepo.ListTemplates(ctx)
+	if err != nil {
+		return 0, errors.DatabaseError("failed to list templates", err)
+	}
+	generated := 0
+	for _, template := range templates {
+		if err := ctx.Err(); err != nil {
+			return generated, err
+		}
+		if err := s.generate(ctx, template); err != nil {
+			s.log.Error("Failed to generate template thumbnail", err, "template_id", template.ID)
+			continue
+		}
+		generated++
+	}
+	return generated, nil
+}
+
+func (s *thumbnailSubService) ThumbnailURL(template *models.PosterTemplate) string {
+	if template.ThumbnailKey == "" {
+		return template.ThumbnailURL
+	}
+	link, _ := s.posters.signedDownloadURL(template.ThumbnailKey, DownloadFilename(template.Name, path.Ext(template.ThumbnailKey)))
+	return link
+}
+
+// generate renders the layout with the template's sample data, stores the image under a new key
+// and only then deletes the old one, so the template never points at a missing file.
+func (s *thumbnailSubService) generate(ctx context.Context, template *models.PosterTemplate) error {
+	if template.Layout.FilePath == "" && template.Layout.Content == "" {
+		return errors.InternalServerError("template configuration incomplete: layout missing", nil)
+	}
+	input, err := s.sampleInput(template)
+	if err != nil {
+		return err
+	}
+	data, err := s.posters.mergeTemplateData(ctx, template, input)
+	if err != nil {
+		return err
+	}
+	data, err = s.posters.withInlineAssets(ctx, template, data, 0)
+	if err != nil {
+		return err
+	}
+	htmlContent, err := s.posters.renderHTMLTemplate(ctx, data, &template.Layout)
+	if err != nil {
+		return errors.InternalServerError("failed to render template", err)
+	}
+	buf, err := s.posters.renderBytes(ctx, renderer.Request{
+		HTML:      htmlContent,
+		Format:    renderer.FormatPNG,
+		Width:     thumbnailWidth,
+		OnBlocked: s.posters.logBlockedRequest(0, template.ID),
+	}, template.Layout.Renderer)
+	if err != nil {
+		return renderError(err, renderer.FormatPNG)
+	}
+
+	key := path.Join(thumbnailKeyPrefix, newStorageKey(renderer.FormatPNG.Extension()))
+	if err := s.files.Put(ctx, key, bytes.NewReader(buf), int64(len(buf)), renderer.FormatPNG.ContentType()); err != nil {
+		return errors.InternalServerError("failed to store thumbnail", err)
+	}
+	if err := s.templateRepo.UpdateThumbnailKey(ctx, template.ID, key); err != nil {
+		_ = s.files.Delete(ctx, key)
+		return errors.DatabaseError("failed to save thumbnail", err)
+	}
+	if template.ThumbnailKey != "" {
+		if err := s.files.Delete(ctx, template.ThumbnailKey); err != nil {
+			s.log.Warn("Failed to delete old template thumbnail", "template_id", template.ID, "key", template.ThumbnailKey, "error", err)
+		}
+	}
+	template.ThumbnailKey = key
+	s.log.Info("Template thumbnail generated", "template_id", template.ID, "key", key)
+	return nil
+}
+
+// sampleInput builds the poster input a thumbnail is rendered with: the template's sample data,
+// with each field that has no sample value showing a placeholder instead.
+func (s *thumbnailSubService) sampleInput(template *models.PosterTemplate) (*dto.PosterInput, error) {
+	sample := make(map[string]interface{})
+	if len(template.SampleData) > 0 && string(template.SampleData) != "null" {
+		if err := json.Unmarshal(template.SampleData, &sample); err != nil {
+			return nil, errors.InternalServerError("template configuration error: invalid sample data", err)
+		}
+	}
+	requiredFields, err := s.posters.requiredFields(template)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range requiredFields {
+		if value, message := resolveField(field, sample[field.Name]); message == "" && value != nil {
+			sample[field.Name] = value
+		} else {
+			sample[field.Name] = placeholderValue(field)
+		}
+	}
+
+	businessName := template.Name
+	if name, ok := sample["business_name"].(string); ok && name != "" {
+		businessName = name
+	}
+	return &dto.PosterInput{BusinessName: businessName, Data: sample}, nil
+}
+
+// placeholderValue is what a field without usable sample data shows on a thumbnail: its label,
+// or a valid example value where a label could not be drawn.
+func placeholderValue(field RequiredFieldConfig) interface{} {
+	switch field.kind() {
+	case FieldTypeBarcode:
+		if strings.EqualFold(field.Barcode.symbology(), barcode.EAN13) {
+			return "5901234123457"
+		}
+	case FieldTypeColor:
+		return "#9ca3af"
+	case FieldTypeBoolean:
+		return true
+	case FieldTypeImage:
+		return nil
+	case FieldTypeEnum:
+		if len(field.Options) > 0 {
+			return field.Options[0].Value
+		}
+	}
+	return field.Label
+}
@@ -0,0 +1,42 @@
+package posters
+
+import (
+	"context"
+
+	"github.com/codetheuri/poster-gen/config"
+	postersServices "github.com/codetheuri/poster-gen/internal/app/posters/services"
+	"github.com/codetheuri/poster-gen/pkg/logger"
+	"github.com/codetheuri/poster-gen/pkg/validators"
+	"gorm.io/gorm"
+)
+
+// Worker processes the durable render queue outside the API, so both can be scaled separately.
+type Worker struct {
+	log     logger.Logger
+	runtime *Runtime
+	worker  *postersServices.RenderWorker
+}
+
+// NewWorker wires the render pipeline the same way the API module does, minus the HTTP layer.
+func NewWorker(db *gorm.DB, log logger.Logger, validator *validators.Validator, cfg *config.Config) (*Worker, error) {
+	runtime, err := NewRuntime(db, log, validator, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	worker := postersServices.NewRenderWorker(runtime.Repos.RenderJobRepo, runtime.Repos.PosterRepo, runtime.Services.PosterSvc.RenderPoster, runtime.Services.ThumbnailSvc.GenerateTemplateThumbnail, postersServices.RenderWorkerConfig{
+		Concurrency:       cfg.RenderWorkers,
+		PollInterval:      cfg.RenderWorkerPollInterval,
+		VisibilityTimeout: cfg.RenderJobVisibility,
+		BaseBackoff:       cfg.RenderJobBackoff,
+		MaxBackoff:        cfg.RenderJobMaxBackoff,
+	}, log)
+
+	return &Worker{log: log, runtime: runtime, worker: worker}, nil
+}
+
+// Run processes render jobs until ctx is cancelled and in-flight renders have finished.
+func (w *Worker) Run(ctx context.Context) {
+	defer w.runtime.Close(context.Background())
+	w.worker.Run(ctx)
+}
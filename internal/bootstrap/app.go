@@ -7,7 +7,6 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
-	"path/filepath"
 	"syscall"
 	"time"
 
@@ -25,29 +24,6 @@ import (
 	// "github.com/codetheuri/poster-gen/pkg/validators"
 )
 
-type noListFileSystem struct {
-	fs http.FileSystem
-}
-
-// Open checks if the requested path is a directory. If so, it returns a "not found" error.
-func (nlfs noListFileSystem) Open(path string) (http.File, error) {
-	f, err := nlfs.fs.Open(path)
-	if err != nil {
-		return nil, err
-	}
-
-	s, err := f.Stat()
-	if err != nil {
-		return nil, err
-	}
-	if s.IsDir() {
-		// We return a "not found" error instead of serving the directory.
-		return nil, os.ErrNotExist
-	}
-
-	return f, nil
-}
-
 // initiliazes and start the application
 func Run(cfg *config.Config, log logger.Logger) error {
 	//db
@@ -65,17 +41,17 @@ func Run(cfg *config.Config, log logger.Logger) error {
 	authMod := authModule.NewModule(db, log, appValidator, cfg)
 	// Example of adding a new module))
 	appModules = append(appModules, authModule.NewModule(db, log, appValidator, cfg))                     // Example of adding a new module
-	appModules = append(appModules, postersModule.NewModule(db, log, appValidator, authMod.TokenService)) // Example of adding a new module
+	postersMod, err := postersModule.NewModule(db, log, appValidator, authMod.TokenService, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize posters module: %w", err)
+	}
+	appModules = append(appModules, postersMod) // Example of adding a new module
 
 	//register routes from all modules
 	mainRouter := router.NewRouter(log)
-	workDir, _ := os.Getwd()
-	filesDir := http.Dir(filepath.Join(workDir, "posters"))
-	secureFileServer := http.FileServer(noListFileSystem{fs: filesDir})
-	mainRouter.Get("/posters/*", func(w http.ResponseWriter, r *http.Request) {
-		fs := http.StripPrefix("/posters", secureFileServer)
-		fs.ServeHTTP(w, r)
-	})
+	// Generated posters are served from the configured storage, not straight from disk,
+	// next to the short links printed in tracked QR codes
+	postersMod.RegisterFileRoutes(mainRouter)
 	mainRouter.Route("/api", func(r router.Router) {
 		// Register routes from all modules onto this sub-router.
 		for _, module := range appModules {
@@ -95,11 +71,17 @@ func Run(cfg *config.Config, log logger.Logger) error {
 	//Start Server
 
 	// Setup HTTP Server with Timeouts
+	// The write timeout must leave room for a full render plus the response, otherwise a
+	// slow render is cut off before the handler can report the timeout to the client.
+	writeTimeout := 10 * time.Second
+	if minWrite := cfg.RenderTimeout + 5*time.Second; minWrite > writeTimeout {
+		writeTimeout = minWrite
+	}
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.ServerPort),
 		Handler:      handler,
 		ReadTimeout:  5 * time.Second, // Timeouts prevent slowloris attacks and resource hangs
-		WriteTimeout: 10 * time.Second,
+		WriteTimeout: writeTimeout,
 		IdleTimeout:  60 * time.Second,
 	}
 
@@ -140,6 +122,15 @@ func Run(cfg *config.Config, log logger.Logger) error {
 		return fmt.Errorf("server shutdown failed: %w", err)
 	}
 
+	// 5. Release module resources once no more requests are in flight
+	for _, module := range appModules {
+		if s, ok := module.(modules.Shutdowner); ok {
+			if err := s.Shutdown(ctx); err != nil {
+				log.Error("Module shutdown failed", err)
+			}
+		}
+	}
+
 	log.Info("Server shut down gracefully.")
 	return nil
 
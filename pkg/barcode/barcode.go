@@ -0,0 +1,138 @@
+// Package barcode draws linear barcodes (Code 128 and EAN-13) as inline SVG.
+package barcode
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// Supported symbologies.
+const (
+	Code128 = "code128"
+	EAN13   = "ean13"
+)
+
+const (
+	DefaultHeight      = 80
+	DefaultModuleWidth = 2
+	// MaxModuleWidth and MaxHeight bound the drawing so a template cannot request a huge image.
+	MaxModuleWidth = 10
+	MaxHeight      = 1000
+	textSize       = 14
+	textGap        = 4
+)
+
+// Options control how a barcode is drawn. Zero values pick sensible defaults.
+type Options struct {
+	Height      int     // height of the bars in pixels
+	ModuleWidth float64 // width of the narrowest bar in pixels
+	HideText    bool    // leave out the human-readable value under the bars
+	Foreground  string  // colour of the bars, default #000000
+	Background  string  // colour behind the bars, default #ffffff
+}
+
+// symbol is an encoded barcode: one entry per module, true for a bar, quiet zones included.
+type symbol struct {
+	modules []bool
+	text    string
+}
+
+// Validate reports whether value can be encoded in the given symbology. The error
+// message is suitable for showing next to the form field the value came from.
+func Validate(symbology, value string) error {
+	_, err := encode(symbology, value)
+	return err
+}
+
+// SVG encodes value and returns a standalone <svg> element.
+func SVG(symbology, value string, opts Options) (string, error) {
+	sym, err := encode(symbology, value)
+	if err != nil {
+		return "", err
+	}
+	height := opts.Height
+	if height <= 0 {
+		height = DefaultHeight
+	}
+	moduleWidth := opts.ModuleWidth
+	if moduleWidth <= 0 {
+		moduleWidth = DefaultModuleWidth
+	}
+	if height > MaxHeight || moduleWidth > MaxModuleWidth {
+		return "", fmt.Errorf("barcode size exceeds the maximum of %dpx height and %dpx module width", MaxHeight, MaxModuleWidth)
+	}
+	foreground, background := opts.Foreground, opts.Background
+	if foreground == "" {
+		foreground = "#000000"
+	}
+	if background == "" {
+		background = "#ffffff"
+	}
+
+	width := float64(len(sym.modules)) * moduleWidth
+	total := height
+	if !opts.HideText {
+		total += textGap + textSize
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%d" viewBox="0 0 %s %d" shape-rendering="crispEdges">`, formatFloat(width), total, formatFloat(width), total)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="%s"/>`, html.EscapeString(background))
+	fmt.Fprintf(&b, `<g fill="%s">`, html.EscapeString(foreground))
+	// Adjacent bar modules are merged into one rect.
+	for i := 0; i < len(sym.modules); {
+		if !sym.modules[i] {
+			i++
+			continue
+		}
+		start := i
+		for i < len(sym.modules) && sym.modules[i] {
+			i++
+		}
+		fmt.Fprintf(&b, `<rect x="%s" width="%s" height="%d"/>`, formatFloat(float64(start)*moduleWidth), formatFloat(float64(i-start)*moduleWidth), height)
+	}
+	if !opts.HideText {
+		fmt.Fprintf(&b, `<text x="%s" y="%d" font-family="monospace" font-size="%d" text-anchor="middle">%s</text>`,
+			formatFloat(width/2), height+textGap+textSize-2, textSize, html.EscapeString(sym.text))
+	}
+	b.WriteString(`</g></svg>`)
+	return b.String(), nil
+}
+
+func encode(symbology, value string) (*symbol, error) {
+	switch strings.ToLower(symbology) {
+	case "", Code128:
+		return encodeCode128(value)
+	case EAN13:
+		return encodeEAN13(value)
+	default:
+		return nil, fmt.Errorf("unsupported barcode type %q, use code128 or ean13", symbology)
+	}
+}
+
+// appendPattern adds alternating bars and spaces with the given module widths, starting with a bar.
+func appendPattern(modules []bool, widths string) []bool {
+	for i, w := range widths {
+		for n := 0; n < int(w-'0'); n++ {
+			modules = append(modules, i%2 == 0)
+		}
+	}
+	return modules
+}
+
+// appendBits adds one module per character of bits, "1" being a bar.
+func appendBits(modules []bool, bits string) []bool {
+	for _, bit := range bits {
+		modules = append(modules, bit == '1')
+	}
+	return modules
+}
+
+func quietZone(modules []bool, n int) []bool {
+	return append(modules, make([]bool, n)...)
+}
+
+func formatFloat(f float64) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", f), "0"), ".")
+}
@@ -0,0 +1,189 @@
+package barcode
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEAN13CheckDigit(t *testing.T) {
+	tests := map[string]int{
+		"400638133393": 1, // 4006381333931
+		"590123412345": 7, // 5901234123457
+		"978014300723": 4, // ISBN 978-0-14-300723-4
+		"000000000000": 0,
+	}
+	for digits, want := range tests {
+		got, err := EAN13CheckDigit(digits)
+		if err != nil || got != want {
+			t.Errorf("EAN13CheckDigit(%s) = %d, %v; want %d", digits, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "40063813339", "4006381333931", "40063813339x"} {
+		if _, err := EAN13CheckDigit(bad); err == nil {
+			t.Errorf("EAN13CheckDigit(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestEAN13Validate(t *testing.T) {
+	for _, valid := range []string{"4006381333931", "400638133393", "5901234123457"} {
+		if err := Validate(EAN13, valid); err != nil {
+			t.Errorf("Validate(ean13, %s) = %v", valid, err)
+		}
+	}
+	for _, invalid := range []string{"4006381333932", "40063813339", "400638133393a", ""} {
+		if err := Validate(EAN13, invalid); err == nil {
+			t.Errorf("Validate(ean13, %q) succeeded, want an error", invalid)
+		}
+	}
+}
+
+func TestEAN13Encoding(t *testing.T) {
+	sym, err := encodeEAN13("400638133393")
+	if err != nil {
+		t.Fatalf("encodeEAN13: %v", err)
+	}
+	if sym.text != "4006381333931" {
+		t.Fatalf("text = %q, want the check digit appended", sym.text)
+	}
+	// 11 modules of quiet zone, 95 of symbol and 7 of quiet zone.
+	if len(sym.modules) != 113 {
+		t.Fatalf("got %d modules, want 113", len(sym.modules))
+	}
+	bits := moduleBits(sym.modules[11:106])
+	if bits[:3] != "101" || bits[45:50] != "01010" || bits[92:] != "101" {
+		t.Fatalf("guard patterns are wrong: %s", bits)
+	}
+	// First digit 4 selects LGLLGG for the left half; its second digit 0 is then G-coded.
+	if got := bits[10:17]; got != eanG[0] {
+		t.Fatalf("second left digit = %s, want the G pattern of 0 (%s)", got, eanG[0])
+	}
+	if got := bits[85:92]; got != eanR[1] {
+		t.Fatalf("check digit = %s, want the R pattern of 1 (%s)", got, eanR[1])
+	}
+}
+
+func TestCode128Patterns(t *testing.T) {
+	for i, p := range code128Patterns {
+		want := 11
+		if i == code128Stop {
+			want = 13
+		}
+		sum := 0
+		for _, w := range p {
+			sum += int(w - '0')
+		}
+		if sum != want {
+			t.Errorf("pattern %d (%s) is %d modules wide, want %d", i, p, sum, want)
+		}
+	}
+}
+
+func TestCode128Checksum(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []int
+	}{
+		// Code set B: start, P J J 1 2 3 C, checksum (104 + 48 + 2*42 + 3*42 + 4*17 + 5*18 + 6*19 + 7*35) % 103, stop
+		{"PJJ123C", []int{104, 48, 42, 42, 17, 18, 19, 35, 55, 106}},
+		// Code set C packs digit pairs: (105 + 12 + 2*34 + 3*56) % 103 = 44
+		{"123456", []int{105, 12, 34, 56, 44, 106}},
+		// An odd trailing digit switches to code set B: (105 + 12 + 2*34 + 3*100 + 4*21) % 103 = 54
+		{"12345", []int{105, 12, 34, 100, 21, 54, 106}},
+		// A single digit stays in code set B: (104 + 17) % 103 = 18
+		{"1", []int{104, 17, 18, 106}},
+	}
+	for _, tt := range tests {
+		sym, err := encodeCode128(tt.value)
+		if err != nil {
+			t.Fatalf("encodeCode128(%q): %v", tt.value, err)
+		}
+		got := decodeCode128(t, sym.modules)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("encodeCode128(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestCode128Validate(t *testing.T) {
+	for _, valid := range []string{"A", "Mama Mboga 0712", "~!@#"} {
+		if err := Validate(Code128, valid); err != nil {
+			t.Errorf("Validate(code128, %q) = %v", valid, err)
+		}
+	}
+	for _, invalid := range []string{"", "café", "tab\there", strings.Repeat("1", maxCode128+1)} {
+		if err := Validate(Code128, invalid); err == nil {
+			t.Errorf("Validate(code128, %q) succeeded, want an error", invalid)
+		}
+	}
+	if err := Validate("qr", "123"); err == nil {
+		t.Error("Validate accepted an unknown symbology")
+	}
+}
+
+func TestSVG(t *testing.T) {
+	svg, err := SVG(EAN13, "400638133393", Options{Foreground: `"><script>`})
+	if err != nil {
+		t.Fatalf("SVG: %v", err)
+	}
+	if !strings.HasPrefix(svg, "<svg ") || !strings.Contains(svg, ">4006381333931</text>") {
+		t.Fatalf("SVG lacks the element or the text: %s", svg)
+	}
+	if strings.Contains(svg, "<script>") {
+		t.Fatalf("SVG does not escape the colour: %s", svg)
+	}
+	if svg, _ := SVG(Code128, "A", Options{HideText: true}); strings.Contains(svg, "<text") {
+		t.Fatalf("SVG with HideText has text: %s", svg)
+	}
+	if _, err := SVG(Code128, "A", Options{Height: MaxHeight + 1}); err == nil {
+		t.Fatal("SVG accepted a height above MaxHeight")
+	}
+}
+
+func moduleBits(modules []bool) string {
+	var b strings.Builder
+	for _, m := range modules {
+		if m {
+			b.WriteByte('1')
+		} else {
+			b.WriteByte('0')
+		}
+	}
+	return b.String()
+}
+
+// decodeCode128 reads the symbol values back from the modules, quiet zones excluded.
+func decodeCode128(t *testing.T, modules []bool) []int {
+	t.Helper()
+	bits := moduleBits(modules[10 : len(modules)-10])
+	var values []int
+	for len(bits) > 0 {
+		width := 11
+		if len(bits) == 13 {
+			width = 13
+		}
+		var pattern strings.Builder
+		run := 1
+		for i := 1; i <= width; i++ {
+			if i == width || bits[i] != bits[i-1] {
+				pattern.WriteByte(byte('0' + run))
+				run = 1
+			} else {
+				run++
+			}
+		}
+		value := -1
+		for v, p := range code128Patterns {
+			if p == pattern.String() {
+				value = v
+			}
+		}
+		if value < 0 {
+			t.Fatalf("unknown pattern %s", pattern.String())
+		}
+		values = append(values, value)
+		bits = bits[width:]
+	}
+	return values
+}
@@ -0,0 +1,80 @@
+package barcode
+
+import "fmt"
+
+// code128Patterns holds the bar/space widths of every Code 128 symbol value, 106 being the stop pattern.
+var code128Patterns = [...]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+const (
+	code128CodeB  = 100
+	code128StartB = 104
+	code128StartC = 105
+	code128Stop   = 106
+	maxCode128    = 80 // characters; longer values do not fit on a printed label
+)
+
+// encodeCode128 encodes printable ASCII. Values made only of digits use code set C, which
+// packs two digits per symbol; an odd trailing digit switches to code set B.
+func encodeCode128(value string) (*symbol, error) {
+	if value == "" {
+		return nil, fmt.Errorf("value is empty")
+	}
+	if len(value) > maxCode128 {
+		return nil, fmt.Errorf("value is longer than %d characters", maxCode128)
+	}
+	for _, r := range value {
+		if r < 32 || r > 126 {
+			return nil, fmt.Errorf("only letters, digits, spaces and ASCII punctuation can be encoded")
+		}
+	}
+
+	var values []int
+	if isDigits(value) && len(value) >= 2 {
+		values = append(values, code128StartC)
+		for i := 0; i+1 < len(value); i += 2 {
+			values = append(values, int(value[i]-'0')*10+int(value[i+1]-'0'))
+		}
+		if len(value)%2 == 1 {
+			values = append(values, code128CodeB, int(value[len(value)-1])-32)
+		}
+	} else {
+		values = append(values, code128StartB)
+		for i := 0; i < len(value); i++ {
+			values = append(values, int(value[i])-32)
+		}
+	}
+
+	checksum := values[0]
+	for i, v := range values[1:] {
+		checksum += (i + 1) * v
+	}
+	values = append(values, checksum%103, code128Stop)
+
+	modules := quietZone(nil, 10)
+	for _, v := range values {
+		modules = appendPattern(modules, code128Patterns[v])
+	}
+	modules = quietZone(modules, 10)
+	return &symbol{modules: modules, text: value}, nil
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,62 @@
+package barcode
+
+import "fmt"
+
+var (
+	eanL = [10]string{"0001101", "0011001", "0010011", "0111101", "0100011", "0110001", "0101111", "0111011", "0110111", "0001011"}
+	eanG = [10]string{"0100111", "0110011", "0011011", "0100001", "0011101", "0111001", "0000101", "0010001", "0001001", "0010111"}
+	eanR = [10]string{"1110010", "1100110", "1101100", "1000010", "1011100", "1001110", "1010000", "1000100", "1001000", "1110100"}
+	// eanParity is the L/G pattern of the left half, chosen by the first digit.
+	eanParity = [10]string{"LLLLLL", "LLGLGG", "LLGGLG", "LLGGGL", "LGLLGG", "LGGLLG", "LGGGLL", "LGLGLG", "LGLGGL", "LGGLGL"}
+)
+
+// EAN13CheckDigit computes the check digit for the first 12 digits of an EAN-13 code.
+func EAN13CheckDigit(digits string) (int, error) {
+	if len(digits) != 12 || !isDigits(digits) {
+		return 0, fmt.Errorf("expected 12 digits")
+	}
+	sum := 0
+	for i := 0; i < 12; i++ {
+		d := int(digits[i] - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+	return (10 - sum%10) % 10, nil
+}
+
+// encodeEAN13 accepts 12 digits, to which the check digit is added, or 13 digits whose
+// check digit must be correct.
+func encodeEAN13(value string) (*symbol, error) {
+	if !isDigits(value) || (len(value) != 12 && len(value) != 13) {
+		return nil, fmt.Errorf("EAN-13 codes have 13 digits (or 12 without the check digit)")
+	}
+	check, _ := EAN13CheckDigit(value[:12])
+	if len(value) == 13 {
+		if got := int(value[12] - '0'); got != check {
+			return nil, fmt.Errorf("invalid check digit %d, expected %d", got, check)
+		}
+	} else {
+		value += string(rune('0' + check))
+	}
+
+	parity := eanParity[value[0]-'0']
+	modules := quietZone(nil, 11)
+	modules = appendBits(modules, "101")
+	for i := 1; i <= 6; i++ {
+		d := value[i] - '0'
+		if parity[i-1] == 'G' {
+			modules = appendBits(modules, eanG[d])
+		} else {
+			modules = appendBits(modules, eanL[d])
+		}
+	}
+	modules = appendBits(modules, "01010")
+	for i := 7; i <= 12; i++ {
+		modules = appendBits(modules, eanR[value[i]-'0'])
+	}
+	modules = appendBits(modules, "101")
+	modules = quietZone(modules, 7)
+	return &symbol{modules: modules, text: value}, nil
+}
@@ -0,0 +1,279 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"github.com/codetheuri/poster-gen/pkg/logger"
+)
+
+// ErrPoolClosed is returned by Acquire once the pool has been shut down.
+var ErrPoolClosed = errors.New("browser pool is closed")
+
+// Config controls how many tabs the pool keeps and when they are recycled.
+type Config struct {
+	Size                int           // maximum number of tabs rendering at the same time
+	MaxUsesPerTab       int           // a tab is closed and replaced after this many renders (0 = unlimited)
+	HealthCheckInterval time.Duration // how often the shared browser is probed (0 = disabled)
+	HealthCheckTimeout  time.Duration // how long a single probe may take
+	ExecPath            string        // optional path to the Chrome/Chromium binary
+}
+
+// Pool keeps a single long-lived headless Chrome process and hands out reusable tabs.
+type Pool struct {
+	cfg Config
+	log logger.Logger
+
+	mu            sync.Mutex
+	allocCtx      context.Context
+	allocCancel   context.CancelFunc
+	browserCtx    context.Context
+	browserCancel context.CancelFunc
+	generation    int
+	closed        bool
+
+	slots chan struct{} // bounds the number of checked-out tabs
+	idle  chan *Tab     // tabs ready to be reused
+
+	stopHealth chan struct{}
+	healthDone chan struct{}
+}
+
+// Tab is a browser tab borrowed from the pool. Run chromedp actions against Context().
+type Tab struct {
+	ctx        context.Context
+	cancel     context.CancelFunc
+	uses       int
+	generation int
+}
+
+// Context returns the chromedp context bound to this tab.
+func (t *Tab) Context() context.Context {
+	return t.ctx
+}
+
+// NewPool creates a pool. Chrome itself is started lazily on the first Acquire,
+// so constructing a pool on a host without Chrome does not fail.
+func NewPool(cfg Config, log logger.Logger) *Pool {
+	if cfg.Size <= 0 {
+		cfg.Size = 1
+	}
+	if cfg.HealthCheckTimeout <= 0 {
+		cfg.HealthCheckTimeout = 5 * time.Second
+	}
+
+	p := &Pool{
+		cfg:        cfg,
+		log:        log,
+		slots:      make(chan struct{}, cfg.Size),
+		idle:       make(chan *Tab, cfg.Size),
+		stopHealth: make(chan struct{}),
+		healthDone: make(chan struct{}),
+	}
+
+	if cfg.HealthCheckInterval > 0 {
+		go p.healthLoop()
+	} else {
+		close(p.healthDone)
+	}
+	return p
+}
+
+// Acquire borrows a tab, waiting for a free slot until ctx is done.
+// Every successful Acquire must be paired with a Release.
+func (p *Pool) Acquire(ctx context.Context) (*Tab, error) {
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	// Reuse an idle tab from the current browser if one passes a quick health check.
+	for {
+		var tab *Tab
+		select {
+		case tab = <-p.idle:
+		default:
+		}
+		if tab == nil {
+			break
+		}
+		if p.isCurrent(tab) && p.ping(tab.ctx) == nil {
+			return tab, nil
+		}
+		p.log.Warn("Discarding stale or unhealthy browser tab", "generation", tab.generation)
+		tab.cancel()
+	}
+
+	tab, err := p.newTab()
+	if err != nil {
+		<-p.slots
+		return nil, err
+	}
+	return tab, nil
+}
+
+// Release returns a tab to the pool. Tabs that failed, reached their use limit
+// or belong to a browser that has since been restarted are closed instead of reused.
+func (p *Pool) Release(tab *Tab, renderErr error) {
+	if tab == nil {
+		return
+	}
+	defer func() { <-p.slots }()
+
+	tab.uses++
+	switch {
+	case renderErr != nil:
+		p.log.Warn("Recycling browser tab after failed render", "error", renderErr)
+		tab.cancel()
+	case p.cfg.MaxUsesPerTab > 0 && tab.uses >= p.cfg.MaxUsesPerTab:
+		p.log.Debug("Recycling browser tab after reaching its use limit", "uses", tab.uses)
+		tab.cancel()
+	case !p.isCurrent(tab):
+		tab.cancel()
+	default:
+		select {
+		case p.idle <- tab:
+		default:
+			tab.cancel()
+		}
+	}
+}
+
+// Close stops the health checker, closes every idle tab and shuts Chrome down.
+func (p *Pool) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	p.mu.Unlock()
+
+	close(p.stopHealth)
+	<-p.healthDone
+
+	p.drainIdle()
+
+	p.mu.Lock()
+	p.stopBrowserLocked()
+	p.mu.Unlock()
+	p.log.Info("Browser pool closed")
+}
+
+func (p *Pool) newTab() (*Tab, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, ErrPoolClosed
+	}
+	if err := p.ensureBrowserLocked(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := chromedp.NewContext(p.browserCtx)
+	// The first Run attaches the new target; no timeout here, see chromedp.Run docs.
+	if err := chromedp.Run(ctx); err != nil {
+		cancel()
+		p.log.Error("Failed to open browser tab, restarting browser", err)
+		p.stopBrowserLocked()
+		return nil, fmt.Errorf("failed to open browser tab: %w", err)
+	}
+	return &Tab{ctx: ctx, cancel: cancel, generation: p.generation}, nil
+}
+
+// ensureBrowserLocked starts Chrome if it is not running. p.mu must be held.
+func (p *Pool) ensureBrowserLocked() error {
+	if p.browserCtx != nil && p.browserCtx.Err() == nil {
+		return nil
+	}
+
+	opts := append([]chromedp.ExecAllocatorOption{}, chromedp.DefaultExecAllocatorOptions[:]...)
+	if p.cfg.ExecPath != "" {
+		opts = append(opts, chromedp.ExecPath(p.cfg.ExecPath))
+	}
+	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), opts...)
+	browserCtx, browserCancel := chromedp.NewContext(allocCtx)
+	if err := chromedp.Run(browserCtx); err != nil {
+		browserCancel()
+		allocCancel()
+		p.log.Error("Failed to start headless browser", err)
+		return fmt.Errorf("failed to start headless browser: %w", err)
+	}
+
+	p.allocCtx, p.allocCancel = allocCtx, allocCancel
+	p.browserCtx, p.browserCancel = browserCtx, browserCancel
+	p.generation++
+	p.log.Info("Headless browser started", "generation", p.generation, "pool_size", p.cfg.Size)
+	return nil
+}
+
+// stopBrowserLocked shuts the current browser down. p.mu must be held.
+func (p *Pool) stopBrowserLocked() {
+	if p.browserCancel != nil {
+		p.browserCancel()
+	}
+	if p.allocCancel != nil {
+		p.allocCancel()
+	}
+	p.browserCtx, p.browserCancel = nil, nil
+	p.allocCtx, p.allocCancel = nil, nil
+}
+
+func (p *Pool) isCurrent(tab *Tab) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return tab.generation == p.generation && p.browserCtx != nil && p.browserCtx.Err() == nil && tab.ctx.Err() == nil
+}
+
+func (p *Pool) ping(ctx context.Context) error {
+	pingCtx, cancel := context.WithTimeout(ctx, p.cfg.HealthCheckTimeout)
+	defer cancel()
+	var result int
+	return chromedp.Run(pingCtx, chromedp.Evaluate(`1`, &result))
+}
+
+func (p *Pool) drainIdle() {
+	for {
+		select {
+		case tab := <-p.idle:
+			tab.cancel()
+		default:
+			return
+		}
+	}
+}
+
+// healthLoop periodically probes the shared browser and restarts it if it crashed or hangs.
+func (p *Pool) healthLoop() {
+	defer close(p.healthDone)
+	ticker := time.NewTicker(p.cfg.HealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stopHealth:
+			return
+		case <-ticker.C:
+			p.mu.Lock()
+			browserCtx := p.browserCtx
+			p.mu.Unlock()
+			if browserCtx == nil {
+				continue // browser not started yet, nothing to check
+			}
+			if err := p.ping(browserCtx); err != nil {
+				p.log.Error("Headless browser failed health check, recycling", err)
+				p.mu.Lock()
+				if p.browserCtx == browserCtx {
+					p.stopBrowserLocked()
+				}
+				p.mu.Unlock()
+				p.drainIdle()
+			}
+		}
+	}
+}
@@ -103,6 +103,16 @@ func InternalServerError(message string, err error) AppError {
 	return New("INTERNAL_SERVER_ERROR", message, err)
 }
 
+// timeout errors (e.g. a render that exceeded its deadline)
+func TimeoutError(message string, err error) AppError {
+	return New("TIMEOUT_ERROR", message, err)
+}
+
+// service temporarily unable to accept work (e.g. a full render queue)
+func ServiceUnavailableError(message string, err error) AppError {
+	return New("SERVICE_UNAVAILABLE", message, err)
+}
+
 // external service error
 func ExternalServiceError(message string, err error) AppError {
 	return New("EXTERNAL_SERVICE_ERROR", message, err)
@@ -0,0 +1,269 @@
+// Package keqr builds and parses Kenyan merchant-presented payment QR payloads.
+//
+// Payloads follow the EMVCo merchant-presented mode used by the KE-QR standard: a
+// sequence of ID/length/value fields, where the ID and length are two digits each,
+// closed by a CRC-16/CCITT-FALSE checksum over everything up to and including the
+// checksum's own ID and length ("6304"). Banking and M-PESA apps refuse codes whose
+// checksum does not match, so payloads must be built here rather than by hand.
+package keqr
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Kind is the payment method a payload pays into.
+type Kind string
+
+const (
+	KindBuyGoods Kind = "buy_goods" // M-PESA Buy Goods till
+	KindPaybill  Kind = "paybill"   // M-PESA Paybill with an account number
+	KindBank     Kind = "bank"      // bank account, paid through the bank's paybill
+)
+
+// Top-level field IDs.
+const (
+	idPayloadFormat   = "00"
+	idInitiation      = "01"
+	idBuyGoods        = "28" // merchant account information: M-PESA till
+	idPaybill         = "29" // merchant account information: M-PESA paybill
+	idBank            = "30" // merchant account information: bank account
+	idCategoryCode    = "52"
+	idCurrency        = "53"
+	idAmount          = "54"
+	idCountry         = "58"
+	idMerchantName    = "59"
+	idMerchantCity    = "60"
+	idAdditionalData  = "62"
+	idCRC             = "63"
+	subIDGUID         = "00"
+	subIDMerchant     = "01"
+	subIDAccount      = "02"
+	subIDReference    = "05" // reference label inside the additional data template
+	payloadFormat     = "01"
+	initiationStatic  = "11" // the same code is scanned many times
+	initiationDynamic = "12" // the code carries a fixed amount
+	currencyKES       = "404"
+	countryKenya      = "KE"
+)
+
+// GUID identifies the KE-QR merchant account templates.
+const GUID = "ke.go.qr"
+
+// Defaults for optional merchant details.
+const (
+	DefaultCategoryCode = "0000"
+	DefaultCity         = "Nairobi"
+	maxMerchantName     = 25
+	maxMerchantCity     = 15
+)
+
+var (
+	digits = regexp.MustCompile(`^[0-9]+$`)
+	amount = regexp.MustCompile(`^[0-9]{1,10}(\.[0-9]{1,2})?$`)
+)
+
+// Payment describes what a code pays into.
+type Payment struct {
+	Kind         Kind
+	MerchantID   string // till number, paybill number or the bank's paybill number
+	Account      string // account number; required for paybill and bank payments
+	MerchantName string // shown by the paying app; written in ASCII and truncated to 25 characters
+	City         string // defaults to DefaultCity; written in ASCII and truncated to 15 characters
+	CategoryCode string // ISO 18245 merchant category code, defaults to DefaultCategoryCode
+	Amount       string // optional fixed amount in KES, e.g. "250" or "99.50"
+	Reference    string // optional reference label shown on the payment; written in ASCII
+}
+
+// Build validates p and returns its payload, checksum included. Text is transliterated to
+// ASCII first, as EMVCo requires, so "Café Njeri" is written as "Cafe Njeri".
+func Build(p Payment) (string, error) {
+	p.Account, p.MerchantName, p.City, p.Reference = ascii(p.Account), ascii(p.MerchantName), ascii(p.City), ascii(p.Reference)
+	if err := p.validate(); err != nil {
+		return "", err
+	}
+
+	account := []Field{{ID: subIDGUID, Value: GUID}, {ID: subIDMerchant, Value: p.MerchantID}}
+	if p.Kind != KindBuyGoods {
+		account = append(account, Field{ID: subIDAccount, Value: p.Account})
+	}
+	initiation := initiationStatic
+	if p.Amount != "" {
+		initiation = initiationDynamic
+	}
+	category := p.CategoryCode
+	if category == "" {
+		category = DefaultCategoryCode
+	}
+	city := p.City
+	if city == "" {
+		city = DefaultCity
+	}
+
+	fields := []Field{
+		{ID: idPayloadFormat, Value: payloadFormat},
+		{ID: idInitiation, Value: initiation},
+		{ID: accountID(p.Kind), Value: Encode(account)},
+		{ID: idCategoryCode, Value: category},
+		{ID: idCurrency, Value: currencyKES},
+	}
+	if p.Amount != "" {
+		fields = append(fields, Field{ID: idAmount, Value: p.Amount})
+	}
+	fields = append(fields,
+		Field{ID: idCountry, Value: countryKenya},
+		Field{ID: idMerchantName, Value: truncate(p.MerchantName, maxMerchantName)},
+		Field{ID: idMerchantCity, Value: truncate(city, maxMerchantCity)},
+	)
+	if p.Reference != "" {
+		fields = append(fields, Field{ID: idAdditionalData, Value: Encode([]Field{{ID: subIDReference, Value: p.Reference}})})
+	}
+
+	for _, f := range fields {
+		if len(f.Value) > 99 {
+			return "", fmt.Errorf("field %s is longer than 99 characters", f.ID)
+		}
+	}
+	payload := Encode(fields) + idCRC + "04"
+	return payload + fmt.Sprintf("%04X", CRC16([]byte(payload))), nil
+}
+
+// Parse verifies a payload's checksum and reads the payment it describes.
+func Parse(payload string) (*Payment, error) {
+	if len(payload) < 8 || payload[len(payload)-8:len(payload)-4] != idCRC+"04" {
+		return nil, fmt.Errorf("payload does not end with a checksum")
+	}
+	body, checksum := payload[:len(payload)-4], payload[len(payload)-4:]
+	if expected := fmt.Sprintf("%04X", CRC16([]byte(body))); !strings.EqualFold(checksum, expected) {
+		return nil, fmt.Errorf("checksum mismatch: payload has %s, expected %s", checksum, expected)
+	}
+	fields, err := Decode(payload[:len(payload)-8])
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Payment{}
+	for _, f := range fields {
+		switch f.ID {
+		case idPayloadFormat:
+			if f.Value != payloadFormat {
+				return nil, fmt.Errorf("unsupported payload format %q", f.Value)
+			}
+		case idBuyGoods, idPaybill, idBank:
+			p.Kind = kindOf(f.ID)
+			sub, err := Decode(f.Value)
+			if err != nil {
+				return nil, fmt.Errorf("merchant account information: %w", err)
+			}
+			if guid := lookup(sub, subIDGUID); guid != GUID {
+				return nil, fmt.Errorf("unknown merchant account identifier %q", guid)
+			}
+			p.MerchantID = lookup(sub, subIDMerchant)
+			p.Account = lookup(sub, subIDAccount)
+		case idCategoryCode:
+			p.CategoryCode = f.Value
+		case idCurrency:
+			if f.Value != currencyKES {
+				return nil, fmt.Errorf("unsupported currency %q", f.Value)
+			}
+		case idAmount:
+			p.Amount = f.Value
+		case idMerchantName:
+			p.MerchantName = f.Value
+		case idMerchantCity:
+			p.City = f.Value
+		case idAdditionalData:
+			sub, err := Decode(f.Value)
+			if err != nil {
+				return nil, fmt.Errorf("additional data: %w", err)
+			}
+			p.Reference = lookup(sub, subIDReference)
+		}
+	}
+	if p.Kind == "" {
+		return nil, fmt.Errorf("payload has no Kenyan merchant account information")
+	}
+	return p, nil
+}
+
+func (p Payment) validate() error {
+	if accountID(p.Kind) == "" {
+		return fmt.Errorf("unknown payment kind %q", p.Kind)
+	}
+	if !digits.MatchString(p.MerchantID) {
+		return fmt.Errorf("merchant number %q must contain only digits", p.MerchantID)
+	}
+	if p.Kind != KindBuyGoods && strings.TrimSpace(p.Account) == "" {
+		return fmt.Errorf("%s payments need an account number", p.Kind)
+	}
+	if strings.TrimSpace(p.MerchantName) == "" {
+		return fmt.Errorf("merchant name is required and must contain letters or digits a payment app can show")
+	}
+	if p.CategoryCode != "" && (len(p.CategoryCode) != 4 || !digits.MatchString(p.CategoryCode)) {
+		return fmt.Errorf("merchant category code %q must be four digits", p.CategoryCode)
+	}
+	if p.Amount != "" && !amount.MatchString(p.Amount) {
+		return fmt.Errorf("amount %q must be a number with at most two decimals", p.Amount)
+	}
+	return nil
+}
+
+func accountID(kind Kind) string {
+	switch kind {
+	case KindBuyGoods:
+		return idBuyGoods
+	case KindPaybill:
+		return idPaybill
+	case KindBank:
+		return idBank
+	}
+	return ""
+}
+
+func kindOf(id string) Kind {
+	switch id {
+	case idBuyGoods:
+		return KindBuyGoods
+	case idPaybill:
+		return KindPaybill
+	case idBank:
+		return KindBank
+	}
+	return ""
+}
+
+// asciiSpellings covers letters and punctuation that do not decompose into ASCII and a mark.
+var asciiSpellings = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'Æ': "AE", 'œ': "oe", 'Œ': "OE", 'ø': "o", 'Ø': "O", 'đ': "d", 'Đ': "D",
+	'ł': "l", 'Ł': "L", 'ı': "i", '‘': "'", '’': "'", '“': `"`, '”': `"`, '–': "-", '—': "-",
+}
+
+// ascii transliterates s to printable ASCII: accents are dropped, a few letters are spelled out
+// and characters with no ASCII spelling are left out. Runs of spaces become one.
+func ascii(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case asciiSpellings[r] != "":
+			b.WriteString(asciiSpellings[r])
+		case unicode.IsSpace(r):
+			b.WriteByte(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// truncate shortens s, which ascii has already cleaned, to at most n characters. In ASCII a
+// character is a byte, the unit field lengths count.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.TrimSpace(s[:n])
+}
@@ -0,0 +1,141 @@
+package keqr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildParseRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		payment Payment
+	}{
+		{"buy goods", Payment{Kind: KindBuyGoods, MerchantID: "5123456", MerchantName: "Mama Mboga"}},
+		{"paybill", Payment{Kind: KindPaybill, MerchantID: "247247", Account: "0712345678", MerchantName: "Shop", City: "Mombasa"}},
+		{"bank with amount", Payment{Kind: KindBank, MerchantID: "247247", Account: "1234567890", MerchantName: "Shop", Amount: "99.50", Reference: "INV-1", CategoryCode: "5411"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := Build(tt.payment)
+			if err != nil {
+				t.Fatalf("Build: %v", err)
+			}
+			got, err := Parse(payload)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", payload, err)
+			}
+
+			want := tt.payment
+			if want.City == "" {
+				want.City = DefaultCity
+			}
+			if want.CategoryCode == "" {
+				want.CategoryCode = DefaultCategoryCode
+			}
+			if *got != want {
+				t.Fatalf("Parse(Build(p)) = %+v, want %+v", *got, want)
+			}
+		})
+	}
+}
+
+func TestBuildPayloadLayout(t *testing.T) {
+	payload, err := Build(Payment{Kind: KindBuyGoods, MerchantID: "5123456", MerchantName: "Shop"})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if !strings.HasPrefix(payload, "000201"+"010211"+"2823"+"0008"+GUID+"0107"+"5123456") {
+		t.Fatalf("payload %q does not start with the format, static initiation and till", payload)
+	}
+
+	withAmount, err := Build(Payment{Kind: KindBuyGoods, MerchantID: "5123456", MerchantName: "Shop", Amount: "250"})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if !strings.HasPrefix(withAmount, "000201"+"010212") || !strings.Contains(withAmount, "5403250") {
+		t.Fatalf("payload with amount %q is not dynamic or lacks the amount", withAmount)
+	}
+}
+
+func TestBuildTruncatesMerchantDetails(t *testing.T) {
+	payload, err := Build(Payment{Kind: KindBuyGoods, MerchantID: "1", MerchantName: strings.Repeat("a", 40), City: strings.Repeat("b", 20)})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	p, err := Parse(payload)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(p.MerchantName) != maxMerchantName || len(p.City) != maxMerchantCity {
+		t.Fatalf("merchant name %q and city %q were not truncated to %d and %d characters", p.MerchantName, p.City, maxMerchantName, maxMerchantCity)
+	}
+}
+
+func TestBuildWritesTextInASCII(t *testing.T) {
+	payload, err := Build(Payment{Kind: KindBuyGoods, MerchantID: "1", MerchantName: "Café Ñjeri’s Crème Brûlée Kiosk", City: "Mūrang’a"})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	for i, r := range payload {
+		if r >= 0x80 {
+			t.Fatalf("payload has non-ASCII %q at %d: %s", r, i, payload)
+		}
+	}
+	p, err := Parse(payload)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if p.MerchantName != "Cafe Njeri's Creme Brulee" || p.City != "Murang'a" {
+		t.Fatalf("merchant name %q and city %q, want them transliterated and truncated", p.MerchantName, p.City)
+	}
+
+	if _, err := Build(Payment{Kind: KindBuyGoods, MerchantID: "1", MerchantName: "咖啡店"}); err == nil {
+		t.Fatal("Build accepted a merchant name without any ASCII spelling")
+	}
+}
+
+func TestBuildRejectsInvalidPayments(t *testing.T) {
+	valid := Payment{Kind: KindPaybill, MerchantID: "247247", Account: "123", MerchantName: "Shop"}
+	tests := []struct {
+		name   string
+		change func(p *Payment)
+	}{
+		{"unknown kind", func(p *Payment) { p.Kind = "cash" }},
+		{"non-digit merchant", func(p *Payment) { p.MerchantID = "24x247" }},
+		{"empty merchant", func(p *Payment) { p.MerchantID = "" }},
+		{"paybill without account", func(p *Payment) { p.Account = "" }},
+		{"blank account", func(p *Payment) { p.Account = "  " }},
+		{"no merchant name", func(p *Payment) { p.MerchantName = "" }},
+		{"short category code", func(p *Payment) { p.CategoryCode = "54" }},
+		{"amount with three decimals", func(p *Payment) { p.Amount = "1.005" }},
+		{"overlong account", func(p *Payment) { p.Account = strings.Repeat("1", 100) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := valid
+			tt.change(&p)
+			if payload, err := Build(p); err == nil {
+				t.Fatalf("Build(%+v) = %q, want an error", p, payload)
+			}
+		})
+	}
+}
+
+func TestParseRejectsBadChecksum(t *testing.T) {
+	payload, err := Build(Payment{Kind: KindBuyGoods, MerchantID: "5123456", MerchantName: "Shop"})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	tampered := strings.Replace(payload, "5123456", "5123457", 1)
+	if _, err := Parse(tampered); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Fatalf("Parse of a tampered payload = %v, want a checksum error", err)
+	}
+	if _, err := Parse(payload[:len(payload)-8]); err == nil {
+		t.Fatal("Parse of a payload without a checksum succeeded")
+	}
+	// Apps accept the checksum in either case.
+	lower := payload[:len(payload)-4] + strings.ToLower(payload[len(payload)-4:])
+	if _, err := Parse(lower); err != nil {
+		t.Fatalf("Parse with a lower-case checksum: %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package keqr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Field is one ID/length/value entry of a payload or of a nested template.
+type Field struct {
+	ID    string
+	Value string
+}
+
+// Encode joins fields in order. Lengths count bytes, as the EMVCo specification does; Build
+// only encodes ASCII, where a byte is a character, so paying apps read the same lengths.
+func Encode(fields []Field) string {
+	var b strings.Builder
+	for _, f := range fields {
+		fmt.Fprintf(&b, "%s%02d%s", f.ID, len(f.Value), f.Value)
+	}
+	return b.String()
+}
+
+// Decode splits s into its fields.
+func Decode(s string) ([]Field, error) {
+	var fields []Field
+	for i := 0; i < len(s); {
+		if i+4 > len(s) {
+			return nil, fmt.Errorf("truncated field at offset %d", i)
+		}
+		id := s[i : i+2]
+		length, err := strconv.Atoi(s[i+2 : i+4])
+		if err != nil {
+			return nil, fmt.Errorf("invalid length for field %s at offset %d", id, i)
+		}
+		start := i + 4
+		if start+length > len(s) {
+			return nil, fmt.Errorf("field %s at offset %d runs past the end of the payload", id, i)
+		}
+		fields = append(fields, Field{ID: id, Value: s[start : start+length]})
+		i = start + length
+	}
+	return fields, nil
+}
+
+// lookup returns the value of the first field with id.
+func lookup(fields []Field, id string) string {
+	for _, f := range fields {
+		if f.ID == id {
+			return f.Value
+		}
+	}
+	return ""
+}
+
+// CRC16 computes CRC-16/CCITT-FALSE (polynomial 0x1021, initial value 0xFFFF).
+func CRC16(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
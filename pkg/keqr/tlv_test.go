@@ -0,0 +1,39 @@
+package keqr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCRC16(t *testing.T) {
+	// Check value of CRC-16/CCITT-FALSE from the CRC catalogue.
+	if got := CRC16([]byte("123456789")); got != 0x29B1 {
+		t.Fatalf("CRC16(123456789) = %04X, want 29B1", got)
+	}
+	if got := CRC16(nil); got != 0xFFFF {
+		t.Fatalf("CRC16(nil) = %04X, want FFFF", got)
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	fields := []Field{{ID: "00", Value: "01"}, {ID: "59", Value: "Mama Mboga"}, {ID: "62", Value: ""}}
+	encoded := Encode(fields)
+	if want := "000201" + "5910Mama Mboga" + "6200"; encoded != want {
+		t.Fatalf("Encode = %q, want %q", encoded, want)
+	}
+	decoded, err := Decode(encoded)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, fields) {
+		t.Fatalf("Decode = %+v, want %+v", decoded, fields)
+	}
+}
+
+func TestDecodeRejectsMalformedInput(t *testing.T) {
+	for _, s := range []string{"000", "00xx01", "000501"} {
+		if _, err := Decode(s); err == nil {
+			t.Errorf("Decode(%q) succeeded, want an error", s)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+// Package qr renders QR codes as data URIs that can be placed straight into an
+// <img src> of a poster layout, so rendering never has to fetch an image.
+package qr
+
+import (
+	"encoding/base64"
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// Output formats.
+const (
+	FormatSVG = "svg"
+	FormatPNG = "png"
+)
+
+const (
+	// DefaultSize is the edge length in pixels used when Options.Size is not set.
+	DefaultSize = 256
+	// MaxSize bounds the edge length so a template cannot request a huge image.
+	MaxSize = 2048
+	// logoScale is the share of the code's width covered by a centred logo. High error
+	// correction restores up to 30% of the modules; a fifth of the width stays well inside that.
+	logoScale = 0.2
+	// MinContrast is the contrast ratio, as WCAG defines it, the modules need against the
+	// background for phone cameras to read a code reliably.
+	MinContrast = 4.5
+)
+
+// Options control how a code is drawn. Zero values pick sensible defaults.
+type Options struct {
+	Size       int    // edge length in pixels
+	Level      string // error correction: L, M, Q or H (default M, always H with a logo)
+	Format     string // svg (default) or png
+	Foreground string // hex colour of the modules, default #000000
+	Background string // hex colour behind the modules, default #ffffff
+	// Logo is an SVG document drawn in the centre. The result is always SVG then, with a
+	// PNG code embedded when Format is png.
+	Logo string
+}
+
+// Level parses an error-correction level name.
+func Level(name string) (qrcode.RecoveryLevel, error) {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "L", "LOW":
+		return qrcode.Low, nil
+	case "", "M", "MEDIUM":
+		return qrcode.Medium, nil
+	case "Q", "HIGH":
+		return qrcode.High, nil
+	case "H", "HIGHEST":
+		return qrcode.Highest, nil
+	default:
+		return 0, fmt.Errorf("unknown QR error correction level %q, use L, M, Q or H", name)
+	}
+}
+
+// Validate reports whether content fits in a QR code at the given level.
+func Validate(content, level string) error {
+	recovery, err := Level(level)
+	if err != nil {
+		return err
+	}
+	if content == "" {
+		return fmt.Errorf("QR content is empty")
+	}
+	_, err = qrcode.New(content, recovery)
+	return err
+}
+
+// DataURI encodes content and returns it as a data: URI.
+func DataURI(content string, opts Options) (string, error) {
+	if content == "" {
+		return "", fmt.Errorf("QR content is empty")
+	}
+	size := opts.Size
+	if size <= 0 {
+		size = DefaultSize
+	}
+	if size > MaxSize {
+		return "", fmt.Errorf("QR size %d exceeds the maximum of %d", size, MaxSize)
+	}
+	level := opts.Level
+	if opts.Logo != "" {
+		level = "H"
+	}
+	recovery, err := Level(level)
+	if err != nil {
+		return "", err
+	}
+	foreground, err := parseHexColor(opts.Foreground, color.RGBA{A: 0xff})
+	if err != nil {
+		return "", err
+	}
+	background, err := parseHexColor(opts.Background, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff})
+	if err != nil {
+		return "", err
+	}
+
+	code, err := qrcode.New(content, recovery)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode QR code: %w", err)
+	}
+	code.ForegroundColor = foreground
+	code.BackgroundColor = background
+
+	switch strings.ToLower(opts.Format) {
+	case "", FormatSVG:
+		return svgDataURI(drawSVG(code, size, opts.Logo)), nil
+	case FormatPNG:
+		png, err := code.PNG(size)
+		if err != nil {
+			return "", fmt.Errorf("failed to draw QR code: %w", err)
+		}
+		pngURI := "data:image/png;base64," + base64.StdEncoding.EncodeToString(png)
+		if opts.Logo == "" {
+			return pngURI, nil
+		}
+		return svgDataURI(wrapWithLogo(fmt.Sprintf(`<image href="%s" width="%d" height="%d"/>`, pngURI, size, size), size, background, opts.Logo)), nil
+	default:
+		return "", fmt.Errorf("unsupported QR format %q, use svg or png", opts.Format)
+	}
+}
+
+// drawSVG draws the modules as a single path, which keeps the markup small.
+func drawSVG(code *qrcode.QRCode, size int, logo string) string {
+	bitmap := code.Bitmap()
+	modules := len(bitmap)
+
+	var d strings.Builder
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&d, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	body := fmt.Sprintf(`<svg viewBox="0 0 %d %d" width="%d" height="%d" shape-rendering="crispEdges"><rect width="%d" height="%d" fill="%s"/><path d="%s" fill="%s"/></svg>`,
+		modules, modules, size, size, modules, modules, hexColor(code.BackgroundColor), d.String(), hexColor(code.ForegroundColor))
+	if logo == "" {
+		return `<svg xmlns="http://www.w3.org/2000/svg"` + strings.TrimPrefix(body, "<svg")
+	}
+	return wrapWithLogo(body, size, code.BackgroundColor, logo)
+}
+
+// wrapWithLogo places code in an SVG document with logo centred on a padded background square.
+func wrapWithLogo(code string, size int, background color.Color, logo string) string {
+	logoSize := float64(size) * logoScale
+	pad := logoSize * 0.15
+	offset := (float64(size) - logoSize) / 2
+	logoURI := svgDataURI(logo)
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">%s<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" rx="%.2f" fill="%s"/><image href="%s" x="%.2f" y="%.2f" width="%.2f" height="%.2f" preserveAspectRatio="xMidYMid meet"/></svg>`,
+		size, size, size, size, code,
+		offset-pad, offset-pad, logoSize+2*pad, logoSize+2*pad, pad, hexColor(background),
+		logoURI, offset, offset, logoSize, logoSize)
+}
+
+func svgDataURI(svg string) string {
+	return "data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString([]byte(svg))
+}
+
+// Readable reports whether a code drawn in foreground on background (#ffffff when empty)
+// scans reliably: both are hex colours, and the modules are darker than the background by at
+// least MinContrast. Many scanners cannot read light modules on a dark background.
+func Readable(foreground, background string) bool {
+	if strings.TrimSpace(foreground) == "" {
+		return false
+	}
+	fg, err := parseHexColor(foreground, color.RGBA{})
+	if err != nil {
+		return false
+	}
+	bg, err := parseHexColor(background, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff})
+	if err != nil {
+		return false
+	}
+	lf, lb := luminance(fg), luminance(bg)
+	return lf < lb && (lb+0.05)/(lf+0.05) >= MinContrast
+}
+
+// luminance is the relative luminance of c, from 0 for black to 1 for white.
+func luminance(c color.RGBA) float64 {
+	channel := func(v uint8) float64 {
+		s := float64(v) / 255
+		if s <= 0.03928 {
+			return s / 12.92
+		}
+		return math.Pow((s+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(c.R) + 0.7152*channel(c.G) + 0.0722*channel(c.B)
+}
+
+// parseHexColor reads #rgb or #rrggbb; an empty string yields fallback.
+func parseHexColor(value string, fallback color.RGBA) (color.RGBA, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "#")
+	if value == "" {
+		return fallback, nil
+	}
+	if len(value) == 3 {
+		value = string([]byte{value[0], value[0], value[1], value[1], value[2], value[2]})
+	}
+	if len(value) != 6 {
+		return fallback, fmt.Errorf("invalid colour %q, expected #rrggbb", value)
+	}
+	n, err := strconv.ParseUint(value, 16, 32)
+	if err != nil {
+		return fallback, fmt.Errorf("invalid colour %q, expected #rrggbb", value)
+	}
+	return color.RGBA{R: uint8(n >> 16), G: uint8(n >> 8), B: uint8(n), A: 0xff}, nil
+}
+
+func hexColor(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8)
+}
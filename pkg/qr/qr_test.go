@@ -0,0 +1,25 @@
+package qr
+
+import "testing"
+
+func TestReadable(t *testing.T) {
+	for _, tc := range []struct {
+		foreground, background string
+		want                   bool
+	}{
+		{"#000000", "", true},
+		{"#0369a1", "", true},
+		{"#036", "#ffffff", true},
+		{"#ffd700", "", false}, // gold on white is too faint
+		{"#ffffff", "#000000", false},
+		{"#0369a1", "#002244", false},
+		{"", "", false},
+		{"red", "", false},
+		{"rgb(0, 0, 0)", "", false},
+		{"#000000", "white", false},
+	} {
+		if got := Readable(tc.foreground, tc.background); got != tc.want {
+			t.Errorf("Readable(%q, %q) = %v, want %v", tc.foreground, tc.background, got, tc.want)
+		}
+	}
+}
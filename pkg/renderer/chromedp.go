@@ -0,0 +1,191 @@
+package renderer
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+	"github.com/codetheuri/poster-gen/pkg/browser"
+)
+
+// A4 in CSS pixels (96 px per inch).
+const (
+	a4ShortEdgePx = 794
+	a4LongEdgePx  = 1123
+)
+
+// pageOrientationJS reads the orientation declared in the document's @page rule.
+const pageOrientationJS = `(() => {
+    for (const sheet of Array.from(document.styleSheets)) {
+        let rules;
+        try { rules = sheet.cssRules; } catch (e) { continue; }
+        for (const rule of Array.from(rules)) {
+            if (rule instanceof CSSPageRule && /landscape/i.test(rule.style.getPropertyValue('size'))) {
+                return 'landscape';
+            }
+        }
+    }
+    return 'portrait';
+})()`
+
+// ChromeRenderer prints documents with headless Chrome, borrowing tabs from a shared pool.
+type ChromeRenderer struct {
+	pool   *browser.Pool
+	policy *NetworkPolicy
+}
+
+// NewChromeRenderer creates a renderer backed by the given browser pool. Every request the
+// document makes is checked against policy; a nil policy leaves the network unrestricted.
+func NewChromeRenderer(pool *browser.Pool, policy *NetworkPolicy) *ChromeRenderer {
+	return &ChromeRenderer{pool: pool, policy: policy}
+}
+
+func (r *ChromeRenderer) Name() string { return BackendChromedp }
+
+func (r *ChromeRenderer) Render(ctx context.Context, req Request) (out []byte, err error) {
+	tab, err := r.pool.Acquire(ctx)
+	if err != nil {
+		if ctxErr := contextError(ctx); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("failed to acquire browser tab: %w", err)
+	}
+	defer func() { r.pool.Release(tab, err) }()
+
+	// Actions run on the tab's context, which must outlive this call so the tab can be
+	// reused; cancel it as soon as the caller's ctx ends. A cancelled render counts as a
+	// failure, so Release closes the tab instead of returning a half-loaded page to the pool.
+	runCtx, cancel := context.WithCancel(tab.Context())
+	defer cancel()
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
+
+	capture := r.printPDF(&out)
+	if req.Format.IsRaster() {
+		capture = r.screenshot(req, &out)
+	}
+
+	err = chromedp.Run(runCtx,
+		r.isolateNetwork(runCtx, req),
+		// Tabs are reused, so drop any viewport left behind by a previous raster render.
+		emulation.ClearDeviceMetricsOverride(),
+		chromedp.Navigate("about:blank"),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			frameTree, err := page.GetFrameTree().Do(ctx)
+			if err != nil {
+				return err
+			}
+			return page.SetDocumentContent(frameTree.Frame.ID, req.HTML).Do(ctx)
+		}),
+		chromedp.Evaluate(`new Promise(resolve => {
+            if (document.readyState === 'complete') { resolve(); }
+            else { window.addEventListener('load', resolve); }
+        })`, nil),
+		capture,
+	)
+	if err != nil {
+		if ctxErr := contextError(ctx); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("chromedp failed: %w", err)
+	}
+	return out, nil
+}
+
+// isolateNetwork pauses every request the page makes and only lets through those the
+// policy allows. The listener is bound to runCtx, so it goes away with this render.
+func (r *ChromeRenderer) isolateNetwork(runCtx context.Context, req Request) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		if r.policy == nil {
+			return fetch.Disable().Do(ctx)
+		}
+		chromedp.ListenTarget(runCtx, func(ev any) {
+			paused, ok := ev.(*fetch.EventRequestPaused)
+			if !ok {
+				return
+			}
+			// Listeners must not block, and answering the event is itself a CDP call.
+			go func() {
+				execCtx := cdp.WithExecutor(runCtx, chromedp.FromContext(runCtx).Target)
+				if r.policy.Allows(paused.Request.URL) {
+					_ = fetch.ContinueRequest(paused.RequestID).Do(execCtx)
+					return
+				}
+				if req.OnBlocked != nil {
+					req.OnBlocked(paused.Request.URL)
+				}
+				_ = fetch.FailRequest(paused.RequestID, network.ErrorReasonBlockedByClient).Do(execCtx)
+			}()
+		})
+		return fetch.Enable().WithPatterns([]*fetch.RequestPattern{{URLPattern: "*"}}).Do(ctx)
+	})
+}
+
+func (r *ChromeRenderer) printPDF(out *[]byte) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		printParams := page.PrintToPDF().
+			WithPreferCSSPageSize(true).
+			WithPrintBackground(true).
+			WithMarginTop(0).WithMarginBottom(0).WithMarginLeft(0).WithMarginRight(0)
+
+		buf, _, err := printParams.Do(ctx)
+		if err != nil {
+			return err
+		}
+		*out = buf
+		return nil
+	})
+}
+
+// screenshot lays the page out at A4 size (honouring @page orientation) and
+// captures it at the requested pixel size or DPI.
+func (r *ChromeRenderer) screenshot(req Request, out *[]byte) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		var orientation string
+		if err := chromedp.Evaluate(pageOrientationJS, &orientation).Do(ctx); err != nil {
+			return err
+		}
+		cssWidth, cssHeight := float64(a4ShortEdgePx), float64(a4LongEdgePx)
+		if orientation == "landscape" {
+			cssWidth, cssHeight = cssHeight, cssWidth
+		}
+
+		scale := float64(DefaultDPI) / 96
+		switch {
+		case req.Width > 0 && req.Height > 0:
+			scale = float64(req.Width) / cssWidth
+			cssHeight = float64(req.Height) / scale
+		case req.Width > 0:
+			scale = float64(req.Width) / cssWidth
+		case req.Height > 0:
+			scale = float64(req.Height) / cssHeight
+		case req.DPI > 0:
+			scale = float64(req.DPI) / 96
+		}
+
+		width, height := int64(math.Round(cssWidth)), int64(math.Round(cssHeight))
+		if err := emulation.SetDeviceMetricsOverride(width, height, scale, false).Do(ctx); err != nil {
+			return err
+		}
+
+		params := page.CaptureScreenshot().
+			WithFormat(page.CaptureScreenshotFormat(req.Format)).
+			WithCaptureBeyondViewport(true).
+			WithClip(&page.Viewport{Width: float64(width), Height: float64(height), Scale: 1})
+		if req.Quality > 0 && req.Format != FormatPNG {
+			params = params.WithQuality(int64(req.Quality))
+		}
+		buf, err := params.Do(ctx)
+		if err != nil {
+			return err
+		}
+		*out = buf
+		return nil
+	})
+}
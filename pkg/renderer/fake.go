@@ -0,0 +1,135 @@
+package renderer
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"sync"
+
+	"github.com/jung-kurt/gofpdf"
+	"golang.org/x/net/html"
+)
+
+// fakeWebP is a valid 1x1 lossless WebP; the standard library has no WebP encoder.
+var fakeWebP, _ = base64.StdEncoding.DecodeString("UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA==")
+
+// FakeRenderer is a pure-Go, in-memory renderer for tests. It records every request
+// and produces a small PDF containing the visible text of the HTML (or a blank image
+// for raster formats), so callers still receive a valid document. It is never
+// registered outside tests: its output is not fit to hand to users.
+type FakeRenderer struct {
+	mu       sync.Mutex
+	requests []Request
+
+	// Err, when set, is returned by every Render call.
+	Err error
+}
+
+// NewFakeRenderer creates an empty fake renderer.
+func NewFakeRenderer() *FakeRenderer {
+	return &FakeRenderer{}
+}
+
+func (r *FakeRenderer) Name() string { return BackendFake }
+
+func (r *FakeRenderer) Render(ctx context.Context, req Request) ([]byte, error) {
+	r.mu.Lock()
+	r.requests = append(r.requests, req)
+	err := r.Err
+	r.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+	if req.Format.IsRaster() {
+		return fakeImage(req)
+	}
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "", 12)
+	pdf.MultiCell(0, 6, tr(visibleText(req.HTML)), "", "L", false)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("fake renderer failed: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Requests returns a copy of every request rendered so far.
+func (r *FakeRenderer) Requests() []Request {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Request(nil), r.requests...)
+}
+
+// fakeImage returns a blank white image of the requested size (A4 at the requested DPI by default).
+func fakeImage(req Request) ([]byte, error) {
+	if req.Format == FormatWebP {
+		return fakeWebP, nil
+	}
+	dpi := req.DPI
+	if dpi <= 0 {
+		dpi = DefaultDPI
+	}
+	width, height := req.Width, req.Height
+	if width <= 0 {
+		width = 210 * dpi * 10 / 254
+	}
+	if height <= 0 {
+		height = 297 * dpi * 10 / 254
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+
+	var buf bytes.Buffer
+	var err error
+	if req.Format == FormatJPEG {
+		err = jpeg.Encode(&buf, img, nil)
+	} else {
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fake renderer failed: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// visibleText extracts the text nodes of an HTML document, skipping <style> and <script>.
+func visibleText(doc string) string {
+	var parts []string
+	skip := 0
+	z := html.NewTokenizer(strings.NewReader(doc))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return strings.Join(parts, "\n")
+		case html.StartTagToken:
+			if name, _ := z.TagName(); string(name) == "style" || string(name) == "script" {
+				skip++
+			}
+		case html.EndTagToken:
+			if name, _ := z.TagName(); (string(name) == "style" || string(name) == "script") && skip > 0 {
+				skip--
+			}
+		case html.TextToken:
+			if skip == 0 {
+				if text := strings.TrimSpace(string(z.Text())); text != "" {
+					parts = append(parts, text)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package renderer
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+// blackholeProxy is an address nothing listens on; backends that cannot filter
+// individual requests send all traffic there so it fails immediately.
+const blackholeProxy = "http://127.0.0.1:9"
+
+// NetworkPolicy decides which URLs a document may load while it is rendered.
+//
+// Entries are either a bare scheme such as "data:" (any URL with that scheme) or an
+// absolute URL prefix such as "https://cdn.example.com/assets/". Prefixes match on
+// scheme, exact host and port, and whole path segments, so "https://cdn.example.com"
+// does not allow "https://cdn.example.com.evil.test". Everything else is blocked.
+type NetworkPolicy struct {
+	schemes  map[string]bool
+	prefixes []*url.URL
+}
+
+// NewNetworkPolicy builds a policy from allowlist entries. Entries that are neither a
+// scheme nor an absolute URL are ignored.
+func NewNetworkPolicy(allowlist []string) *NetworkPolicy {
+	p := &NetworkPolicy{schemes: make(map[string]bool)}
+	for _, entry := range allowlist {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if strings.HasSuffix(entry, ":") && !strings.Contains(entry, "/") {
+			p.schemes[strings.ToLower(strings.TrimSuffix(entry, ":"))] = true
+			continue
+		}
+		u, err := url.Parse(entry)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			continue
+		}
+		u.Scheme = strings.ToLower(u.Scheme)
+		u.Host = strings.ToLower(u.Host)
+		u.Path = strings.TrimSuffix(u.Path, "/")
+		p.prefixes = append(p.prefixes, u)
+	}
+	return p
+}
+
+// Allows reports whether a document may load rawURL. A nil policy allows everything.
+func (p *NetworkPolicy) Allows(rawURL string) bool {
+	if p == nil {
+		return true
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	scheme := strings.ToLower(u.Scheme)
+	// about:blank is the page the document is written into, not a network request.
+	if scheme == "about" || p.schemes[scheme] {
+		return true
+	}
+	host := strings.ToLower(u.Host)
+	urlPath := u.Path
+	if urlPath != "" {
+		urlPath = path.Clean(urlPath) // so "/assets/../internal" cannot pass as "/assets"
+	}
+	for _, prefix := range p.prefixes {
+		if prefix.Scheme != scheme || prefix.Host != host {
+			continue
+		}
+		if prefix.Path == "" || urlPath == prefix.Path || strings.HasPrefix(urlPath, prefix.Path+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// hosts returns the hosts of the allowed URL prefixes.
+func (p *NetworkPolicy) hosts() []string {
+	if p == nil {
+		return nil
+	}
+	hosts := make([]string, 0, len(p.prefixes))
+	for _, prefix := range p.prefixes {
+		hosts = append(hosts, prefix.Host)
+	}
+	return hosts
+}
@@ -0,0 +1,146 @@
+package renderer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Backend names accepted in configuration and on layouts. The fake backend is only
+// registered by tests.
+const (
+	BackendChromedp    = "chromedp"
+	BackendWkhtmltopdf = "wkhtmltopdf"
+	BackendFake        = "fake"
+)
+
+// Format is the output document type.
+type Format string
+
+const (
+	FormatPDF  Format = "pdf"
+	FormatPNG  Format = "png"
+	FormatJPEG Format = "jpeg"
+	FormatWebP Format = "webp"
+)
+
+// DefaultDPI is used for raster output when neither DPI nor a pixel size is requested.
+const DefaultDPI = 150
+
+// ErrUnsupportedFormat is returned by backends that cannot produce the requested format.
+var ErrUnsupportedFormat = errors.New("output format not supported by renderer")
+
+// ErrTimeout is returned when a render does not finish before the context deadline.
+var ErrTimeout = errors.New("render timed out")
+
+// Extension returns the file extension (without dot) for the format.
+func (f Format) Extension() string {
+	if f == FormatJPEG {
+		return "jpg"
+	}
+	if f == "" {
+		return string(FormatPDF)
+	}
+	return string(f)
+}
+
+// ContentType returns the MIME type of documents in this format.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatPNG:
+		return "image/png"
+	case FormatJPEG:
+		return "image/jpeg"
+	case FormatWebP:
+		return "image/webp"
+	default:
+		return "application/pdf"
+	}
+}
+
+// IsRaster reports whether the format is an image rather than a PDF.
+func (f Format) IsRaster() bool {
+	return f == FormatPNG || f == FormatJPEG || f == FormatWebP
+}
+
+// Request describes a single document to render.
+type Request struct {
+	HTML   string // fully rendered HTML, including inline CSS
+	Format Format // defaults to FormatPDF
+
+	// Raster options, ignored for PDF output. Width/Height (pixels) take
+	// precedence over DPI; when only one is given the page aspect ratio is kept.
+	DPI     int
+	Width   int
+	Height  int
+	Quality int // JPEG/WebP quality 1-100, 0 = backend default
+
+	// OnBlocked, when set, is called with every URL the document tried to load that the
+	// backend's network policy refused. It may be called from several goroutines.
+	OnBlocked func(url string)
+}
+
+// Renderer turns HTML into document bytes.
+type Renderer interface {
+	// Name returns the backend name, e.g. "chromedp".
+	Name() string
+	// Render produces the document for req. Implementations must be safe for concurrent use.
+	Render(ctx context.Context, req Request) ([]byte, error)
+}
+
+// Registry holds the available renderers and the one used when a layout does not pick a backend.
+type Registry struct {
+	mu          sync.RWMutex
+	renderers   map[string]Renderer
+	defaultName string
+}
+
+// NewRegistry creates an empty registry whose default backend is defaultName.
+func NewRegistry(defaultName string) *Registry {
+	return &Registry{
+		renderers:   make(map[string]Renderer),
+		defaultName: defaultName,
+	}
+}
+
+// Register adds (or replaces) a renderer under its Name().
+func (r *Registry) Register(renderer Renderer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.renderers[renderer.Name()] = renderer
+}
+
+// Get returns the renderer for name, falling back to the default backend when name is empty.
+func (r *Registry) Get(name string) (Renderer, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if name == "" {
+		name = r.defaultName
+	}
+	renderer, ok := r.renderers[name]
+	if !ok {
+		return nil, fmt.Errorf("renderer backend %q is not registered", name)
+	}
+	return renderer, nil
+}
+
+// Has reports whether a backend with the given name is registered.
+func (r *Registry) Has(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.renderers[name]
+	return ok
+}
+
+// contextError converts a finished context into the error a renderer should return,
+// mapping an exceeded deadline to ErrTimeout.
+func contextError(ctx context.Context) error {
+	if stdErr := ctx.Err(); stdErr != nil {
+		if errors.Is(stdErr, context.DeadlineExceeded) {
+			return fmt.Errorf("%w: %v", ErrTimeout, stdErr)
+		}
+		return stdErr
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package renderer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	wkhtmltopdf "github.com/SebastiaanKlippert/go-wkhtmltopdf"
+)
+
+// WkhtmltopdfRenderer prints documents with the wkhtmltopdf binary, for hosts without Chrome.
+type WkhtmltopdfRenderer struct {
+	policy *NetworkPolicy
+}
+
+// NewWkhtmltopdfRenderer creates a renderer. binPath may be empty, in which case
+// the binary is looked up next to the executable, in $PATH and in WKHTMLTOPDF_PATH.
+//
+// wkhtmltopdf cannot filter individual requests: with a policy, all traffic goes to an
+// unreachable proxy except traffic to the hosts of allowed URL prefixes, and blocked
+// requests are not reported through Request.OnBlocked.
+func NewWkhtmltopdfRenderer(binPath string, policy *NetworkPolicy) *WkhtmltopdfRenderer {
+	if binPath != "" {
+		wkhtmltopdf.SetPath(binPath)
+	}
+	return &WkhtmltopdfRenderer{policy: policy}
+}
+
+func (r *WkhtmltopdfRenderer) Name() string { return BackendWkhtmltopdf }
+
+func (r *WkhtmltopdfRenderer) Render(ctx context.Context, req Request) ([]byte, error) {
+	if req.Format != "" && req.Format != FormatPDF {
+		return nil, fmt.Errorf("wkhtmltopdf cannot produce %s: %w", req.Format, ErrUnsupportedFormat)
+	}
+	pdfg, err := wkhtmltopdf.NewPDFGenerator()
+	if err != nil {
+		return nil, fmt.Errorf("wkhtmltopdf unavailable: %w", err)
+	}
+	pdfg.PageSize.Set(wkhtmltopdf.PageSizeA4)
+	pdfg.MarginTop.Set(0)
+	pdfg.MarginBottom.Set(0)
+	pdfg.MarginLeft.Set(0)
+	pdfg.MarginRight.Set(0)
+	pdfg.Quiet.Set(true)
+
+	page := wkhtmltopdf.NewPageReader(strings.NewReader(req.HTML))
+	page.PrintMediaType.Set(true)
+	page.DisableLocalFileAccess.Set(true)
+	if r.policy != nil {
+		page.Proxy.Set(blackholeProxy)
+		page.ProxyHostnameLookup.Set(true)
+		for _, host := range r.policy.hosts() {
+			page.BypassProxyFor.Set(host)
+		}
+	}
+	pdfg.AddPage(page)
+
+	if err := pdfg.CreateContext(ctx); err != nil {
+		if ctxErr := contextError(ctx); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("wkhtmltopdf failed: %w", err)
+	}
+	return pdfg.Bytes(), nil
+}
@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocal(t *testing.T) {
+	s, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocal: %v", err)
+	}
+	testDriver(t, s)
+}
+
+func TestLocalStaysInsideRoot(t *testing.T) {
+	parent := t.TempDir()
+	root := filepath.Join(parent, "files")
+	s, err := NewLocal(root)
+	if err != nil {
+		t.Fatalf("NewLocal: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(parent, "secret.pdf"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(context.Background(), "../secret.pdf"); err != ErrInvalidKey {
+		t.Fatalf("Get outside the root = %v, want ErrInvalidKey", err)
+	}
+	if err := os.Mkdir(filepath.Join(root, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(context.Background(), "sub"); err != ErrNotFound {
+		t.Fatalf("Get of a directory = %v, want ErrNotFound", err)
+	}
+}
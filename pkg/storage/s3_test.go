@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// TestS3 runs against a local MinIO when S3_TEST_ENDPOINT is set, for example:
+//
+//	docker run -d -p 9000:9000 minio/minio server /data
+//	S3_TEST_ENDPOINT=localhost:9000 go test ./pkg/storage
+//
+// S3_TEST_ACCESS_KEY and S3_TEST_SECRET_KEY default to minioadmin, S3_TEST_BUCKET to
+// poster-gen-test; the bucket is created when missing.
+func TestS3(t *testing.T) {
+	cfg := s3TestConfig(t)
+	s, err := NewS3(cfg)
+	if err != nil {
+		t.Fatalf("NewS3: %v", err)
+	}
+	ctx := context.Background()
+	exists, err := s.client.BucketExists(ctx, cfg.S3Bucket)
+	if err != nil {
+		t.Fatalf("BucketExists: %v", err)
+	}
+	if !exists {
+		if err := s.client.MakeBucket(ctx, cfg.S3Bucket, minio.MakeBucketOptions{Region: cfg.S3Region}); err != nil {
+			t.Fatalf("MakeBucket: %v", err)
+		}
+	}
+	testDriver(t, s)
+}
+
+func s3TestConfig(t *testing.T) Config {
+	endpoint := os.Getenv("S3_TEST_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("S3_TEST_ENDPOINT not set; see TestS3 for running against MinIO")
+	}
+	env := func(name, fallback string) string {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+		return fallback
+	}
+	return Config{
+		Driver:      DriverS3,
+		S3Endpoint:  endpoint,
+		S3Region:    env("S3_TEST_REGION", "us-east-1"),
+		S3Bucket:    env("S3_TEST_BUCKET", "poster-gen-test"),
+		S3AccessKey: env("S3_TEST_ACCESS_KEY", "minioadmin"),
+		S3SecretKey: env("S3_TEST_SECRET_KEY", "minioadmin"),
+		S3UseSSL:    os.Getenv("S3_TEST_USE_SSL") == "true",
+	}
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// Supported storage drivers.
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+var (
+	// ErrNotFound is returned when no object exists under a key.
+	ErrNotFound = errors.New("storage object not found")
+	// ErrInvalidKey is returned for empty keys or keys that try to escape the storage root.
+	ErrInvalidKey = errors.New("invalid storage key")
+)
+
+// Object is a stored file opened for reading. Callers must close Body.
+type Object struct {
+	Body        io.ReadCloser
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// Storage persists generated files under slash-separated keys such as "abc123.pdf".
+type Storage interface {
+	// Put stores body under key, replacing any existing object. size may be -1 if unknown.
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get opens the object stored under key, or returns ErrNotFound.
+	Get(ctx context.Context, key string) (*Object, error)
+	// Delete removes the object; deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// Config selects a driver and holds the settings for each of them.
+type Config struct {
+	Driver string
+
+	// local driver
+	LocalRoot string // directory files are written to
+
+	// s3 driver (AWS S3, MinIO or any S3-compatible service)
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3UseSSL    bool
+}
+
+// New builds the storage driver selected by cfg.Driver.
+func New(cfg Config) (Storage, error) {
+	switch cfg.Driver {
+	case DriverLocal, "":
+		return NewLocal(cfg.LocalRoot)
+	case DriverS3:
+		return NewS3(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported storage driver: %s", cfg.Driver)
+	}
+}
+
+// CleanKey normalises a key and rejects absolute paths and ".." segments.
+func CleanKey(key string) (string, error) {
+	key = strings.TrimSpace(strings.ReplaceAll(key, "\\", "/"))
+	if key == "" || strings.HasPrefix(key, "/") {
+		return "", ErrInvalidKey
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == ".." {
+			return "", ErrInvalidKey
+		}
+	}
+	cleaned := path.Clean(key)
+	if cleaned == "." {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
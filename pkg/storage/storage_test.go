@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCleanKey(t *testing.T) {
+	valid := map[string]string{
+		"abc.pdf":          "abc.pdf",
+		"fonts/a.woff2":    "fonts/a.woff2",
+		"fonts//./a.woff2": "fonts/a.woff2",
+		`fonts\a.woff2`:    "fonts/a.woff2",
+		" abc.pdf ":        "abc.pdf",
+	}
+	for key, want := range valid {
+		got, err := CleanKey(key)
+		if err != nil || got != want {
+			t.Errorf("CleanKey(%q) = %q, %v; want %q", key, got, err, want)
+		}
+	}
+	for _, key := range []string{"", " ", "/etc/passwd", "../secret", "fonts/../../secret", `..\secret`, "."} {
+		if got, err := CleanKey(key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("CleanKey(%q) = %q, %v; want ErrInvalidKey", key, got, err)
+		}
+	}
+}
+
+// testDriver runs the behaviour every Storage driver must share against s.
+func testDriver(t *testing.T, s Storage) {
+	ctx := context.Background()
+	key := "test/" + strings.ReplaceAll(t.Name(), "/", "_") + ".pdf"
+	t.Cleanup(func() { s.Delete(ctx, key) })
+
+	put := func(content string) {
+		t.Helper()
+		if err := s.Put(ctx, key, strings.NewReader(content), int64(len(content)), "application/pdf"); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+	get := func() string {
+		t.Helper()
+		obj, err := s.Get(ctx, key)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		defer obj.Body.Close()
+		body, err := io.ReadAll(obj.Body)
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if obj.Size != int64(len(body)) || obj.ContentType != "application/pdf" {
+			t.Errorf("object has size %d and type %q, want %d and application/pdf", obj.Size, obj.ContentType, len(body))
+		}
+		return string(body)
+	}
+
+	put("first")
+	if got := get(); got != "first" {
+		t.Fatalf("Get = %q, want first", got)
+	}
+	put("second")
+	if got := get(); got != "second" {
+		t.Fatalf("Get after overwrite = %q, want second", got)
+	}
+
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after Delete = %v, want ErrNotFound", err)
+	}
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete of a missing key = %v, want nil", err)
+	}
+
+	for _, bad := range []string{"../escape.pdf", "/abs.pdf", ""} {
+		if err := s.Put(ctx, bad, strings.NewReader("x"), 1, "application/pdf"); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Put(%q) = %v, want ErrInvalidKey", bad, err)
+		}
+		if _, err := s.Get(ctx, bad); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Get(%q) = %v, want ErrInvalidKey", bad, err)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package tmplfuncs
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type rgb struct{ r, g, b float64 }
+
+func parseHex(v interface{}) (rgb, bool) {
+	s := strings.TrimPrefix(strings.TrimSpace(toString(v)), "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) != 6 {
+		return rgb{}, false
+	}
+	n, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return rgb{}, false
+	}
+	return rgb{float64(n >> 16 & 0xff), float64(n >> 8 & 0xff), float64(n & 0xff)}, true
+}
+
+func (c rgb) hex() template.CSS {
+	return template.CSS(fmt.Sprintf("#%02x%02x%02x", channel(c.r), channel(c.g), channel(c.b)))
+}
+
+func channel(f float64) uint8 {
+	return uint8(math.Max(0, math.Min(255, math.Round(f))))
+}
+
+// colourAmount reads the 0 to 1 amount of a colour function.
+func colourAmount(name string, amount interface{}) (float64, error) {
+	f, ok := toFloat(amount)
+	if !ok || f < 0 || f > 1 {
+		return 0, fmt.Errorf("%s amount must be a number between 0 and 1, got %v", name, amount)
+	}
+	return f, nil
+}
+
+// The colour functions return template.CSS so the result can be used inside style rules.
+// A value that is not a hex colour comes back as a plain string, which html/template escapes.
+
+func lighten(amount, colour interface{}) (interface{}, error) {
+	f, err := colourAmount("lighten", amount)
+	if err != nil {
+		return nil, err
+	}
+	c, ok := parseHex(colour)
+	if !ok {
+		return toString(colour), nil
+	}
+	return rgb{c.r + (255-c.r)*f, c.g + (255-c.g)*f, c.b + (255-c.b)*f}.hex(), nil
+}
+
+func darken(amount, colour interface{}) (interface{}, error) {
+	f, err := colourAmount("darken", amount)
+	if err != nil {
+		return nil, err
+	}
+	c, ok := parseHex(colour)
+	if !ok {
+		return toString(colour), nil
+	}
+	return rgb{c.r * (1 - f), c.g * (1 - f), c.b * (1 - f)}.hex(), nil
+}
+
+func alpha(amount, colour interface{}) (interface{}, error) {
+	f, err := colourAmount("alpha", amount)
+	if err != nil {
+		return nil, err
+	}
+	c, ok := parseHex(colour)
+	if !ok {
+		return toString(colour), nil
+	}
+	a := strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64)
+	return template.CSS(fmt.Sprintf("rgba(%d, %d, %d, %s)", channel(c.r), channel(c.g), channel(c.b), a)), nil
+}
@@ -0,0 +1,40 @@
+package tmplfuncs
+
+import (
+	"html/template"
+	"testing"
+)
+
+func TestColours(t *testing.T) {
+	tests := []struct {
+		name   string
+		fn     func(amount, colour interface{}) (interface{}, error)
+		amount interface{}
+		colour interface{}
+		want   interface{}
+	}{
+		{"lighten", lighten, 0.5, "#000000", template.CSS("#808080")},
+		{"lighten short form", lighten, 0.2, "#0369a1", template.CSS("#3587b4")},
+		{"lighten fully", lighten, 1, "#123", template.CSS("#ffffff")},
+		{"darken", darken, 0.5, "#ffffff", template.CSS("#808080")},
+		{"darken none", darken, "0", "#0369A1", template.CSS("#0369a1")},
+		{"alpha", alpha, 0.25, "#0369a1", template.CSS("rgba(3, 105, 161, 0.25)")},
+		{"alpha rounds", alpha, 0.333, "#fff", template.CSS("rgba(255, 255, 255, 0.33)")},
+		{"not a colour", lighten, 0.5, "red", "red"},
+		{"missing colour", darken, 0.5, nil, ""},
+	}
+	for _, tt := range tests {
+		got, err := tt.fn(tt.amount, tt.colour)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: got %#v, %v; want %#v", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestColourAmountOutOfRange(t *testing.T) {
+	for _, amount := range []interface{}{-0.1, 1.5, "half", nil} {
+		if _, err := lighten(amount, "#000"); err == nil {
+			t.Errorf("lighten(%#v) succeeded, want an error", amount)
+		}
+	}
+}
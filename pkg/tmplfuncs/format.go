@@ -0,0 +1,179 @@
+package tmplfuncs
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateLayouts are the forms a date string may take in poster data.
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"02/01/2006",
+}
+
+// toFloat reads a number, or a string holding one. Thousands separators are ignored.
+func toFloat(v interface{}) (float64, bool) {
+	if v == nil {
+		return 0, false
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	case reflect.String:
+		s := strings.ReplaceAll(strings.TrimSpace(rv.String()), ",", "")
+		f, err := strconv.ParseFloat(s, 64)
+		return f, err == nil && !math.IsNaN(f) && !math.IsInf(f, 0)
+	default:
+		return 0, false
+	}
+}
+
+// formatAmount writes f with comma thousands separators and the given number of decimals.
+func formatAmount(f float64, decimals int) string {
+	s := strconv.FormatFloat(math.Abs(f), 'f', decimals, 64)
+	whole, fraction := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, fraction = s[:i], s[i:]
+	}
+	out := groupFromRight(whole, 3, ",") + fraction
+	if f < 0 && strings.Trim(out, "0.,") != "" {
+		out = "-" + out
+	}
+	return out
+}
+
+func groupFromRight(digits string, size int, sep string) string {
+	if size < 1 || len(digits) <= size {
+		return digits
+	}
+	var b strings.Builder
+	first := len(digits) % size
+	if first > 0 {
+		b.WriteString(digits[:first])
+	}
+	for i := first; i < len(digits); i += size {
+		if b.Len() > 0 {
+			b.WriteString(sep)
+		}
+		b.WriteString(digits[i : i+size])
+	}
+	return b.String()
+}
+
+// kes formats a shilling amount, showing cents only when there are any.
+func kes(v interface{}) string {
+	f, ok := toFloat(v)
+	if !ok {
+		return toString(v)
+	}
+	f = math.Round(f*100) / 100
+	decimals := 0
+	if f != math.Trunc(f) {
+		decimals = 2
+	}
+	return "KES " + formatAmount(f, decimals)
+}
+
+func number(v interface{}) string {
+	f, ok := toFloat(v)
+	if !ok {
+		return toString(v)
+	}
+	decimals := -1
+	if f == math.Trunc(f) {
+		decimals = 0
+	}
+	return formatAmount(f, decimals)
+}
+
+// group splits the digits of v into groups of size from the right. Anything that is not
+// made of digits (spaces and dashes aside) is returned unchanged.
+func group(size int, sep string, v interface{}) string {
+	s := toString(v)
+	digits := strings.NewReplacer(" ", "", "-", "").Replace(s)
+	if digits == "" {
+		return s
+	}
+	for i := 0; i < len(digits); i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			return s
+		}
+	}
+	return groupFromRight(digits, size, sep)
+}
+
+// kenyanSubscriber returns the nine digits after the country code of a Kenyan mobile
+// number written as 07xx/01xx, 7xx/1xx, 2547xx or +2547xx.
+func kenyanSubscriber(v interface{}) (string, bool) {
+	s := strings.NewReplacer(" ", "", "-", "", "(", "", ")", "").Replace(toString(v))
+	switch {
+	case strings.HasPrefix(s, "+254"):
+		s = s[4:]
+	case strings.HasPrefix(s, "254") && len(s) == 12:
+		s = s[3:]
+	case strings.HasPrefix(s, "0") && len(s) == 10:
+		s = s[1:]
+	}
+	if len(s) != 9 || (s[0] != '7' && s[0] != '1') {
+		return "", false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return "", false
+		}
+	}
+	return s, true
+}
+
+func phone(v interface{}) string {
+	s, ok := kenyanSubscriber(v)
+	if !ok {
+		return toString(v)
+	}
+	return "0" + s[:3] + " " + s[3:6] + " " + s[6:]
+}
+
+func phoneIntl(v interface{}) string {
+	s, ok := kenyanSubscriber(v)
+	if !ok {
+		return toString(v)
+	}
+	return "+254 " + s[:3] + " " + s[3:6] + " " + s[6:]
+}
+
+// date formats a time.Time, a date string in one of dateLayouts or Unix seconds.
+func date(layout string, v interface{}) string {
+	switch t := v.(type) {
+	case time.Time:
+		return t.Format(layout)
+	case *time.Time:
+		if t != nil {
+			return t.Format(layout)
+		}
+		return ""
+	case string:
+		s := strings.TrimSpace(t)
+		for _, l := range dateLayouts {
+			if parsed, err := time.Parse(l, s); err == nil {
+				return parsed.Format(layout)
+			}
+		}
+		return t
+	}
+	if f, ok := toFloat(v); ok {
+		return time.Unix(int64(f), 0).UTC().Format(layout)
+	}
+	return toString(v)
+}
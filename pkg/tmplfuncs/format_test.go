@@ -0,0 +1,119 @@
+package tmplfuncs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKES(t *testing.T) {
+	tests := []struct {
+		in   interface{}
+		want string
+	}{
+		{1500, "KES 1,500"},
+		{1500.5, "KES 1,500.50"},
+		{"1,234,567.891", "KES 1,234,567.89"},
+		{0.004, "KES 0"},
+		{-250, "KES -250"},
+		{"free", "free"},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := kes(tt.in); got != tt.want {
+			t.Errorf("kes(%#v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNumber(t *testing.T) {
+	tests := []struct {
+		in   interface{}
+		want string
+	}{
+		{1234567, "1,234,567"},
+		{float64(1234567), "1,234,567"},
+		{1234.5, "1,234.5"},
+		{999, "999"},
+		{int64(-1000), "-1,000"},
+		{uint(1000), "1,000"},
+		{"n/a", "n/a"},
+	}
+	for _, tt := range tests {
+		if got := number(tt.in); got != tt.want {
+			t.Errorf("number(%#v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestGroup(t *testing.T) {
+	tests := []struct {
+		size int
+		sep  string
+		in   interface{}
+		want string
+	}{
+		{3, " ", "5123456", "5 123 456"},
+		{4, "-", "1234 5678 90", "12-3456-7890"},
+		{3, " ", 247247, "247 247"},
+		{2, " ", "12", "12"},
+		{3, " ", "A12345", "A12345"},
+		{0, " ", "12345", "12345"},
+		{3, " ", "", ""},
+	}
+	for _, tt := range tests {
+		if got := group(tt.size, tt.sep, tt.in); got != tt.want {
+			t.Errorf("group(%d, %q, %#v) = %q, want %q", tt.size, tt.sep, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPhone(t *testing.T) {
+	local := map[string]string{
+		"0712345678":         "0712 345 678",
+		"0112 345 678":       "0112 345 678",
+		"712345678":          "0712 345 678",
+		"254712345678":       "0712 345 678",
+		"+254 (712) 345-678": "0712 345 678",
+	}
+	for in, want := range local {
+		if got := phone(in); got != want {
+			t.Errorf("phone(%q) = %q, want %q", in, got, want)
+		}
+		if got, wantIntl := phoneIntl(in), "+254 "+want[1:]; got != wantIntl {
+			t.Errorf("phoneIntl(%q) = %q, want %q", in, got, wantIntl)
+		}
+	}
+	// Numbers that are not Kenyan mobiles are left as they are.
+	for _, in := range []string{"0201234567", "+1 555 0100", "07123", "call us"} {
+		if got := phone(in); got != in {
+			t.Errorf("phone(%q) = %q, want it unchanged", in, got)
+		}
+		if got := phoneIntl(in); got != in {
+			t.Errorf("phoneIntl(%q) = %q, want it unchanged", in, got)
+		}
+	}
+}
+
+func TestDate(t *testing.T) {
+	when := time.Date(2026, 10, 16, 14, 30, 0, 0, time.UTC)
+	tests := []struct {
+		in   interface{}
+		want string
+	}{
+		{when, "16 Oct 2026"},
+		{&when, "16 Oct 2026"},
+		{(*time.Time)(nil), ""},
+		{"2026-10-16", "16 Oct 2026"},
+		{"2026-10-16T14:30:00Z", "16 Oct 2026"},
+		{"2026-10-16 14:30", "16 Oct 2026"},
+		{"16/10/2026", "16 Oct 2026"},
+		{when.Unix(), "16 Oct 2026"},
+		{float64(when.Unix()), "16 Oct 2026"},
+		{"next week", "next week"},
+	}
+	for _, tt := range tests {
+		if got := date("2 Jan 2006", tt.in); got != tt.want {
+			t.Errorf("date(%#v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
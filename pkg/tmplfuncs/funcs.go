@@ -0,0 +1,166 @@
+// Package tmplfuncs is the function library available to every poster layout.
+//
+// Functions that take the value being formatted take it last, so they read well in pipelines:
+//
+//	{{.primary_color | default "#0369a1" | lighten 0.2}}
+//	{{.amount | kes}}
+//	{{.expiry_date | date "2 Jan 2006"}}
+//
+// Text:
+//
+//	default d v   v, or d when v is empty (nil, "", 0, false or an empty list)
+//	upper v       v in upper case
+//	lower v       v in lower case
+//	title v       v with the first letter of every word in upper case
+//	split sep v   v cut at every sep, parts trimmed and empty ones dropped
+//	chunk n v     a list, or the characters of a string, in groups of n
+//
+// Numbers and dates:
+//
+//	kes v         a Kenya shilling amount: "KES 1,500" or "KES 1,500.50"
+//	number v      v with comma thousands separators: "1,234,567"
+//	group n sep v the digits of v in groups of n from the right: group 3 " " → "123 456"
+//	phone v       a Kenyan number in local form: "0712 345 678"
+//	phoneIntl v   a Kenyan number in international form: "+254 712 345 678"
+//	date layout v a time or date string formatted with a Go layout such as "2 Jan 2006"
+//
+// Colours take #rgb or #rrggbb and an amount between 0 and 1:
+//
+//	lighten a c   c mixed with white
+//	darken a c    c mixed with black
+//	alpha a c     c as rgba() with opacity a
+//
+// Values the formatting functions cannot make sense of, such as placeholder text in a
+// thumbnail, are returned unchanged rather than failing the render.
+package tmplfuncs
+
+import (
+	"fmt"
+	"html/template"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Map returns the functions. Each call returns a new map the caller may add to.
+func Map() template.FuncMap {
+	return template.FuncMap{
+		"default":   defaultValue,
+		"upper":     func(v interface{}) string { return strings.ToUpper(toString(v)) },
+		"lower":     func(v interface{}) string { return strings.ToLower(toString(v)) },
+		"title":     title,
+		"split":     split,
+		"chunk":     chunk,
+		"kes":       kes,
+		"number":    number,
+		"group":     group,
+		"phone":     phone,
+		"phoneIntl": phoneIntl,
+		"date":      date,
+		"lighten":   lighten,
+		"darken":    darken,
+		"alpha":     alpha,
+	}
+}
+
+func defaultValue(d, v interface{}) interface{} {
+	if empty(v) {
+		return d
+	}
+	return v
+}
+
+func empty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil()
+	default:
+		return rv.IsZero()
+	}
+}
+
+// toString turns a template value into text; missing values become "".
+func toString(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func title(v interface{}) string {
+	runes := []rune(toString(v))
+	start := true
+	for i, r := range runes {
+		if unicode.IsSpace(r) || r == '-' {
+			start = true
+			continue
+		}
+		if start {
+			runes[i] = unicode.ToUpper(r)
+		}
+		start = false
+	}
+	return string(runes)
+}
+
+func split(sep string, v interface{}) []string {
+	parts := []string{}
+	for _, part := range strings.Split(toString(v), sep) {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+func chunk(size int, v interface{}) (interface{}, error) {
+	if size < 1 {
+		return nil, fmt.Errorf("chunk size must be at least 1, got %d", size)
+	}
+	if v == nil {
+		return []string{}, nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String:
+		s := rv.String()
+		chunks := []string{}
+		for len(s) > 0 {
+			end, n := 0, 0
+			for end < len(s) && n < size {
+				_, width := utf8.DecodeRuneInString(s[end:])
+				end += width
+				n++
+			}
+			chunks = append(chunks, s[:end])
+			s = s[end:]
+		}
+		return chunks, nil
+	case reflect.Array, reflect.Slice:
+		chunks := [][]interface{}{}
+		for i := 0; i < rv.Len(); i += size {
+			end := i + size
+			if end > rv.Len() {
+				end = rv.Len()
+			}
+			items := make([]interface{}, 0, end-i)
+			for j := i; j < end; j++ {
+				items = append(items, rv.Index(j).Interface())
+			}
+			chunks = append(chunks, items)
+		}
+		return chunks, nil
+	default:
+		return nil, fmt.Errorf("chunk expects a list or a string, got %T", v)
+	}
+}
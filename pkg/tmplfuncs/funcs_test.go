@@ -0,0 +1,111 @@
+package tmplfuncs
+
+import (
+	"html/template"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// render executes src with the function library, as a layout would.
+func render(t *testing.T, src string, data interface{}) string {
+	t.Helper()
+	tmpl, err := template.New("layout").Funcs(Map()).Parse(src)
+	if err != nil {
+		t.Fatalf("parse %q: %v", src, err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		t.Fatalf("execute %q: %v", src, err)
+	}
+	return b.String()
+}
+
+func TestPipelines(t *testing.T) {
+	data := map[string]interface{}{
+		"amount":      1500.5,
+		"phone":       "0712345678",
+		"name":        "mama mboga",
+		"till":        "5123456",
+		"empty":       "",
+		"expiry_date": "2026-10-16",
+	}
+	tests := map[string]string{
+		`{{.PrimaryColor | default "#0369a1"}}`:         "#0369a1",
+		`{{.name | default "Shop"}}`:                    "mama mboga",
+		`{{.empty | default "n/a"}}`:                    "n/a",
+		`{{.name | title}}`:                             "Mama Mboga",
+		`{{.name | upper}}`:                             "MAMA MBOGA",
+		`{{.amount | kes}}`:                             "KES 1,500.50",
+		`{{.phone | phone}}`:                            "0712 345 678",
+		`{{.till | group 3 " "}}`:                       "5 123 456",
+		`{{.expiry_date | date "2 Jan 2006"}}`:          "16 Oct 2026",
+		`{{range .till | chunk 3}}[{{.}}]{{end}}`:       "[512][345][6]",
+		`{{range split "," "a, b,,c"}}({{.}}){{end}}`:   "(a)(b)(c)",
+		`{{.missing | default "#0369a1" | darken 0.5}}`: "#023551",
+	}
+	for src, want := range tests {
+		if got := render(t, src, data); got != want {
+			t.Errorf("%s = %q, want %q", src, got, want)
+		}
+	}
+}
+
+func TestColourInStyle(t *testing.T) {
+	// Colour helpers return CSS, so html/template keeps them inside style attributes.
+	got := render(t, `<div style="color: {{lighten 0.5 .c}}"></div>`, map[string]string{"c": "#000"})
+	if want := `<div style="color: #808080"></div>`; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestDefault(t *testing.T) {
+	for _, v := range []interface{}{nil, "", 0, 0.0, false, []string{}, map[string]int{}, (*int)(nil)} {
+		if got := defaultValue("d", v); got != "d" {
+			t.Errorf("default(%#v) = %v, want d", v, got)
+		}
+	}
+	for _, v := range []interface{}{"x", 1, true, []string{"a"}} {
+		if got := defaultValue("d", v); !reflect.DeepEqual(got, v) {
+			t.Errorf("default(%#v) = %v, want the value", v, got)
+		}
+	}
+}
+
+func TestTitle(t *testing.T) {
+	tests := map[string]string{
+		"mama mboga":     "Mama Mboga",
+		"jua-kali works": "Jua-Kali Works",
+		"ñame shop":      "Ñame Shop",
+		"":               "",
+	}
+	for in, want := range tests {
+		if got := title(in); got != want {
+			t.Errorf("title(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := title(nil); got != "" {
+		t.Errorf("title(nil) = %q", got)
+	}
+}
+
+func TestChunk(t *testing.T) {
+	got, err := chunk(2, "añb")
+	if err != nil || !reflect.DeepEqual(got, []string{"añ", "b"}) {
+		t.Errorf("chunk(2, añb) = %v, %v", got, err)
+	}
+	got, err = chunk(2, []int{1, 2, 3})
+	if err != nil || !reflect.DeepEqual(got, [][]interface{}{{1, 2}, {3}}) {
+		t.Errorf("chunk(2, [1 2 3]) = %v, %v", got, err)
+	}
+	got, err = chunk(3, nil)
+	if err != nil || !reflect.DeepEqual(got, []string{}) {
+		t.Errorf("chunk(3, nil) = %v, %v", got, err)
+	}
+	if _, err := chunk(0, "abc"); err == nil {
+		t.Error("chunk(0) succeeded, want an error")
+	}
+	if _, err := chunk(2, 42); err == nil {
+		t.Error("chunk of a number succeeded, want an error")
+	}
+}
@@ -43,10 +43,14 @@ func RespondError(w http.ResponseWriter, err error, defaultStatus int, opts ...A
 			statusCode = http.StatusNotFound
 		case "INVALID_INPUT":
 			statusCode = http.StatusBadRequest
-		case "FORBIDDEN":
+		case "FORBIDDEN", "AUTHORIZATION_ERROR":
 			statusCode = http.StatusForbidden
 		case "CONFLICT_ERROR":
 			statusCode = http.StatusConflict
+		case "TIMEOUT_ERROR":
+			statusCode = http.StatusGatewayTimeout
+		case "SERVICE_UNAVAILABLE":
+			statusCode = http.StatusServiceUnavailable
 		case "CONFIG_ERROR", "DATABASE_ERROR":
 			statusCode = http.StatusInternalServerError
 		case "UNAUTHORIZED":
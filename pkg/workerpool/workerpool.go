@@ -0,0 +1,112 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/codetheuri/poster-gen/pkg/logger"
+)
+
+var (
+	// ErrQueueFull is returned by Submit when every worker is busy and the backlog is full.
+	ErrQueueFull = errors.New("worker pool queue is full")
+	// ErrStopped is returned by Submit once Shutdown has been called.
+	ErrStopped = errors.New("worker pool is shutting down")
+)
+
+// Job is a unit of background work. ctx is cancelled only if Shutdown gives up waiting.
+type Job func(ctx context.Context)
+
+// Pool runs jobs on a fixed number of goroutines with a bounded backlog.
+type Pool struct {
+	log    logger.Logger
+	jobs   chan Job
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.RWMutex
+	stopped bool
+}
+
+// New starts a pool with the given number of workers and backlog size.
+func New(workers, queueSize int, log logger.Logger) *Pool {
+	if workers <= 0 {
+		workers = 1
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pool{
+		log:    log,
+		jobs:   make(chan Job, queueSize),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// Submit queues a job without blocking.
+func (p *Pool) Submit(job Job) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.stopped {
+		return ErrStopped
+	}
+	select {
+	case p.jobs <- job:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Shutdown stops accepting jobs and waits for queued and running jobs to finish.
+// If ctx ends first, running jobs are cancelled and ctx.Err() is returned.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.stopped {
+		p.stopped = true
+		close(p.jobs)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.log.Warn("Worker pool did not drain before shutdown deadline, cancelling running jobs")
+		p.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+	for job := range p.jobs {
+		p.run(job)
+	}
+}
+
+func (p *Pool) run(job Job) {
+	defer func() {
+		if r := recover(); r != nil {
+			p.log.Error("Worker pool job panicked", nil, "panic", r)
+		}
+	}()
+	job(p.ctx)
+}